@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/belingud/go-gptcomet/internal/client"
+	"github.com/belingud/go-gptcomet/internal/config"
+	"github.com/belingud/go-gptcomet/internal/lint"
+	"github.com/belingud/go-gptcomet/internal/prompt"
+	"github.com/belingud/go-gptcomet/internal/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// chooseCandidate generates n candidate messages and lets the user pick,
+// edit or regenerate them. The picked message is translated and linted,
+// and checked again after an edit. It returns an
+// empty message if the user cancelled, and the first candidate without
+// asking if autoYes is set.
+func chooseCandidate(cfgManager *config.Manager, llmClient *client.Client, diff string, p prompt.Prompt, n int, scopes []string, autoYes bool) (string, error) {
+	rules, err := cfgManager.GetLintRules()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		fmt.Printf("Generating %d candidate messages...\n", n)
+		candidates, err := llmClient.GenerateCommitMessages(diff, p, n)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate commit messages: %w", err)
+		}
+		if provider := llmClient.LastProvider(); provider != llmClient.Provider() {
+			fmt.Printf("Commit messages generated by fallback provider %s\n", provider)
+		}
+		reportUsage(cfgManager, llmClient)
+
+		if rules.Enabled {
+			for i := range candidates {
+				candidates[i] = lint.Fix(candidates[i], rules)
+			}
+		}
+
+		action, msg := ui.CandidatePick, candidates[0]
+		if !autoYes {
+			picker := ui.NewCandidatePicker(candidates)
+			if _, err := tea.NewProgram(picker).Run(); err != nil {
+				return "", fmt.Errorf("failed to run candidate picker: %w", err)
+			}
+			action, msg = picker.Action(), picker.Selected()
+		}
+
+		switch action {
+		case ui.CandidateRegenerate:
+			continue
+		case ui.CandidateCancel:
+			return "", nil
+		}
+
+		msg, err = finishMessage(cfgManager, llmClient, msg, scopes)
+		if err != nil {
+			return "", err
+		}
+		if action == ui.CandidateEdit {
+			edited, err := editText(msg)
+			if err != nil {
+				return "", err
+			}
+			return checkMessage(cfgManager, edited)
+		}
+		return msg, nil
+	}
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/belingud/go-gptcomet/internal/changelog"
+	"github.com/belingud/go-gptcomet/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+// Changelog output formats
+const (
+	changelogMarkdown       = "markdown"
+	changelogKeepAChangelog = "keepachangelog"
+)
+
+// NewChangelogCmd creates a new changelog command
+func NewChangelogCmd() *cobra.Command {
+	var (
+		repoPath string
+		from     string
+		to       string
+		version  string
+		format   string
+		output   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "changelog",
+		Short: "Generate release notes from the commits between two tags",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != changelogMarkdown && format != changelogKeepAChangelog {
+				return fmt.Errorf("invalid format %q, expected %s or %s", format, changelogMarkdown, changelogKeepAChangelog)
+			}
+			if repoPath == "" {
+				var err error
+				repoPath, err = os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get current directory: %w", err)
+				}
+			}
+
+			vcs := &git.GitVCS{}
+			cfgManager, err := loadConfig(cmd, repoPath)
+			if err != nil {
+				return err
+			}
+
+			if !cmd.Flags().Changed("from") {
+				if from, err = vcs.GetPreviousTag(repoPath, to); err != nil {
+					return fmt.Errorf("failed to find the previous tag: %w", err)
+				}
+			}
+			commits, err := vcs.GetCommits(repoPath, from, to)
+			if err != nil {
+				return fmt.Errorf("failed to get commits: %w", err)
+			}
+			if len(commits) == 0 {
+				return fmt.Errorf("no commits found between %s and %s", from, to)
+			}
+			if from == "" {
+				fmt.Fprintf(os.Stderr, "Using %d commits up to %s\n", len(commits), to)
+			} else {
+				fmt.Fprintf(os.Stderr, "Using %d commits from %s to %s\n", len(commits), from, to)
+			}
+
+			llmClient, err := newLLMClient(cfgManager)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, "🤖 Hang tight, I'm writing the release notes!")
+			setPromptVars(cfgManager, vcs, repoPath, "")
+			prompt, err := cfgManager.GetChangelogPrompt()
+			if err != nil {
+				return err
+			}
+			answer, err := llmClient.GenerateCommitMessage(changelog.Format(changelog.Group(commits)), prompt)
+			if err != nil {
+				return fmt.Errorf("failed to generate release notes: %w", err)
+			}
+			reportUsage(cfgManager, llmClient)
+			notes := changelog.Clean(answer) + "\n"
+
+			if format == changelogMarkdown {
+				if output == "" {
+					fmt.Fprint(cmd.OutOrStdout(), notes)
+					return nil
+				}
+				if err := os.WriteFile(output, []byte(notes), 0644); err != nil {
+					return fmt.Errorf("failed to write %s: %w", output, err)
+				}
+				fmt.Fprintf(os.Stderr, "Wrote the release notes to %s\n", output)
+				return nil
+			}
+
+			if version == "" {
+				version = changelog.Unreleased
+				if to != "HEAD" {
+					version = strings.TrimPrefix(to, "v")
+				}
+			}
+			date := time.Now().Format("2006-01-02")
+			if to != "HEAD" {
+				if date, err = vcs.GetCommitDate(repoPath, to); err != nil {
+					return fmt.Errorf("failed to get the date of %s: %w", to, err)
+				}
+			}
+			if output == "" {
+				root, err := git.TopLevel(repoPath)
+				if err != nil {
+					return err
+				}
+				output = filepath.Join(root, "CHANGELOG.md")
+			}
+
+			content, err := os.ReadFile(output)
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to read %s: %w", output, err)
+			}
+			updated := changelog.Insert(string(content), notes, version, date)
+			if err := os.WriteFile(output, []byte(updated), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+			fmt.Fprintf(os.Stderr, "Added the release notes of %s to %s\n", version, output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "Repository path, the current directory by default")
+	cmd.Flags().StringVar(&from, "from", "", "Tag or commit to start after, the tag before --to by default")
+	cmd.Flags().StringVar(&to, "to", "HEAD", "Tag or commit to end at")
+	cmd.Flags().StringVar(&version, "version", "", "Version of the Keep a Changelog section, --to without a leading v or Unreleased by default")
+	cmd.Flags().StringVar(&format, "format", changelogMarkdown, "Output format, markdown or keepachangelog")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "File to write the markdown to, or the changelog to insert into (CHANGELOG.md by default)")
+
+	return cmd
+}
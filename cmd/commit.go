@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
 
@@ -11,6 +14,9 @@ import (
 	"github.com/belingud/go-gptcomet/internal/config"
 	"github.com/belingud/go-gptcomet/internal/debug"
 	"github.com/belingud/go-gptcomet/internal/git"
+	"github.com/belingud/go-gptcomet/internal/lint"
+	"github.com/belingud/go-gptcomet/internal/prompt"
+	"github.com/belingud/go-gptcomet/internal/scope"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
@@ -30,9 +36,15 @@ var (
 
 const (
 	LANGUAGE_KEY = "output.lang"
+
+	// maxScopeCandidates is the number of inferred scopes up to which the
+	// generated message must use one of them when scope.paths is set, more
+	// scopes mean a change across the repository
+	maxScopeCandidates = 3
 )
 
 type textEditor struct {
+	title    string // what is edited, such as "commit message"
 	textarea textarea.Model
 	err      error
 }
@@ -62,12 +74,19 @@ func (m textEditor) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m textEditor) View() string {
 	return fmt.Sprintf(
-		"Edit commit message (Ctrl+C or Alt+Esc to save and exit):\n\n%s",
+		"Edit %s (Ctrl+C or Alt+Esc to save and exit):\n\n%s",
+		m.title,
 		m.textarea.View(),
 	)
 }
 
 func editText(initialText string) (string, error) {
+	return editTextAs("commit message", initialText, 10, 4096)
+}
+
+// editTextAs edits initialText in a textarea of the given height, titled
+// with what is edited. A charLimit of 0 allows text of any length.
+func editTextAs(title, initialText string, height, charLimit int) (string, error) {
 	// Get terminal width
 	width, _, err := term.GetSize(int(syscall.Stdout))
 	if err != nil {
@@ -79,11 +98,12 @@ func editText(initialText string) (string, error) {
 	ta.Focus()
 	ta.ShowLineNumbers = false
 	ta.Prompt = ""
-	ta.CharLimit = 4096
+	ta.CharLimit = charLimit
 	ta.SetWidth(width - 4) // Leave some margin for borders
-	ta.SetHeight(10)       // Set a reasonable height
+	ta.SetHeight(height)
 
 	m := textEditor{
+		title:    title,
 		textarea: ta,
 		err:      nil,
 	}
@@ -106,14 +126,268 @@ func formatCommitMessage(msg string) string {
 	return boxStyle.Render(successStyle.Render(msg))
 }
 
+// newLLMClient creates the client of the configured provider, with the
+// configured fallback providers tried in order when it fails
+func newLLMClient(cfgManager *config.Manager) (*client.Client, error) {
+	clientConfig, err := cfgManager.GetClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	llmClient, err := client.New(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	for _, provider := range cfgManager.GetFallbackProviders() {
+		if provider == clientConfig.Provider {
+			continue
+		}
+		fallbackConfig, err := cfgManager.GetFallbackProviderConfig(provider)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fallback provider %s: %w", provider, err)
+		}
+		fallback, err := client.New(fallbackConfig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fallback provider %s: %w", provider, err)
+		}
+		llmClient.WithFallbacks(fallback)
+	}
+	return llmClient, nil
+}
+
+// translateMessage translates msg to output.lang unless it is English
+func translateMessage(cfgManager *config.Manager, llmClient *client.Client, msg string) (string, error) {
+	langValue, ok := cfgManager.Get(LANGUAGE_KEY)
+	if !ok {
+		return "", fmt.Errorf("failed to get output.lang: configuration key not found")
+	}
+	lang, ok := langValue.(string)
+	if !ok {
+		return "", fmt.Errorf("output.lang is not a string: %v", langValue)
+	}
+	if lang == "en" {
+		return msg, nil
+	}
+
+	prompt, err := cfgManager.GetTranslationPrompt()
+	if err != nil {
+		return "", err
+	}
+	translated, err := llmClient.TranslateMessage(prompt, msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to translate commit message: %w", err)
+	}
+	reportUsage(cfgManager, llmClient)
+	return translated, nil
+}
+
+// setPromptVars makes the name and branch of the repository, the ticket key
+// found in the branch name, the files changed by diff and the recent commit
+// messages available to the prompt templates, and returns them
+func setPromptVars(cfgManager *config.Manager, vcs git.VCS, repoPath, diff string) prompt.Vars {
+	return setPromptVarsFrom(cfgManager, vcs, repoPath, diff, "HEAD")
+}
+
+// setPromptVarsFrom is setPromptVars taking the recent commits from the
+// revision from, so that a commit being rewritten is not among them
+func setPromptVarsFrom(cfgManager *config.Manager, vcs git.VCS, repoPath, diff, from string) prompt.Vars {
+	vars := prompt.Vars{Repo: filepath.Base(repoPath)}
+	if _, ok := vcs.(*git.GitVCS); ok {
+		if root, err := git.TopLevel(repoPath); err == nil {
+			vars.Repo = filepath.Base(root)
+		}
+	}
+	if branch, err := vcs.GetCurrentBranch(repoPath); err == nil {
+		vars.Branch = branch
+		if settings, err := cfgManager.GetIssueSettings(); err == nil {
+			vars.IssueKey = settings.Find(branch)
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: no ticket key for the prompt: %v\n", err)
+		}
+	} else {
+		debug.Printf("No branch for the prompt: %v", err)
+	}
+	for _, file := range git.ParseDiff(diff) {
+		vars.Files = append(vars.Files, file.Path)
+	}
+	vars.RecentCommits = recentCommits(cfgManager, vcs, repoPath, from, vars.Files)
+	cfgManager.SetPromptVars(vars)
+	return vars
+}
+
+// recentCommits returns the messages of the latest commits reachable from
+// from, newest first, as many as history.count. With history.touched_paths
+// only the commits that changed one of files are used. Failures are
+// printed as a warning.
+func recentCommits(cfgManager *config.Manager, vcs git.VCS, repoPath, from string, files []string) []string {
+	settings, err := cfgManager.GetHistorySettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: no recent commits for the prompt: %v\n", err)
+		return nil
+	}
+	if settings.Count == 0 {
+		return nil
+	}
+	var paths []string
+	if settings.TouchedPaths {
+		paths = files
+	}
+	commits, err := vcs.GetRecentCommits(repoPath, from, settings.Count, paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: no recent commits for the prompt: %v\n", err)
+		return nil
+	}
+	debug.Printf("Got %d recent commits for the prompt", len(commits))
+
+	messages := make([]string, 0, len(commits))
+	for _, commit := range commits {
+		messages = append(messages, commit.Message())
+	}
+	return messages
+}
+
+// condenseDiff summarizes diff in chunks if it is too large for prompt p,
+// see client.Client.CondenseDiff
+func condenseDiff(cfgManager *config.Manager, llmClient *client.Client, diff string, p prompt.Prompt) (string, error) {
+	chunkPrompt, err := cfgManager.GetSummarizeChunkPrompt()
+	if err != nil {
+		return "", err
+	}
+	combinePrompt, err := cfgManager.GetCombineSummariesPrompt()
+	if err != nil {
+		return "", err
+	}
+	condensed, err := llmClient.CondenseDiff(context.Background(), diff, p, chunkPrompt, combinePrompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize diff: %w", err)
+	}
+	return condensed, nil
+}
+
+// inferScopes returns the scopes of the staged files that are not
+// ignored, restricted to the scopes allowed by the lint rules
+func inferScopes(cfgManager *config.Manager, vcs git.VCS, repoPath string) ([]string, error) {
+	stagedFiles, err := vcs.GetStagedFiles(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	root := repoPath
+	if _, ok := vcs.(*git.GitVCS); ok {
+		if root, err = git.TopLevel(repoPath); err != nil {
+			return nil, err
+		}
+	}
+	return scopesOfFiles(cfgManager, root, stagedFiles)
+}
+
+// scopesOfFiles returns the scopes of the files, relative to root, that
+// are not ignored, restricted to the scopes allowed by the lint rules
+func scopesOfFiles(cfgManager *config.Manager, root string, files []string) ([]string, error) {
+	settings, err := cfgManager.GetScopeSettings()
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, nil
+	}
+
+	ignorePatterns := cfgManager.GetFileIgnore()
+	var kept []string
+	for _, file := range files {
+		if !git.ShouldIgnoreFile(file, ignorePatterns) {
+			kept = append(kept, file)
+		}
+	}
+
+	rules, err := cfgManager.GetLintRules()
+	if err != nil {
+		return nil, err
+	}
+	var scopes []string
+	for _, s := range settings.Infer(root, kept) {
+		if len(rules.Scopes) == 0 || slices.Contains(rules.Scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	debug.Printf("Inferred scopes: %v", scopes)
+	return scopes, nil
+}
+
+// withScopes adds the inferred scopes to prompt p
+func withScopes(p prompt.Prompt, scopes []string) prompt.Prompt {
+	if len(scopes) == 0 {
+		return p
+	}
+	return lint.InsertInstructions(p, scope.Instructions(scopes))
+}
+
+// scopeCandidates returns the inferred scopes the message must use one
+// of. They are only enforced when scope.paths maps paths to scopes, the
+// scopes guessed from the directories are merely suggested, and not when
+// there are too many of them.
+func scopeCandidates(cfgManager *config.Manager, scopes []string) ([]string, error) {
+	settings, err := cfgManager.GetScopeSettings()
+	if err != nil {
+		return nil, err
+	}
+	if len(settings.Mappings) == 0 || len(scopes) > maxScopeCandidates {
+		return nil, nil
+	}
+	return scopes, nil
+}
+
+// lintMessage fixes the trivial lint violations of msg and asks the model
+// to fix the others, up to lint.max_attempts times. Violations left after
+// that and those of rules that only warn are printed as a warning, the
+// message is still used. The message must use one of the scopes returned
+// by scopeCandidates, and the ticket key is added even if linting is
+// turned off.
+func lintMessage(cfgManager *config.Manager, llmClient *client.Client, msg string, scopes []string) (string, error) {
+	rules, err := cfgManager.GetLintRules()
+	if err != nil {
+		return "", err
+	}
+	if !rules.Enabled {
+		return lint.AddIssueKey(msg, rules), nil
+	}
+	if rules.Candidates, err = scopeCandidates(cfgManager, scopes); err != nil {
+		return "", err
+	}
+
+	msg = lint.Fix(msg, rules)
+	violations := lint.Lint(msg, rules)
+	failing := lint.Errors(violations)
+	for attempt := 0; attempt < rules.MaxAttempts && len(failing) > 0; attempt++ {
+		debug.Printf("Commit message violates %d lint rules, asking for a fix", len(failing))
+		repairPrompt, err := cfgManager.GetRepairPrompt()
+		if err != nil {
+			return "", err
+		}
+		fixed, err := llmClient.GenerateCommitMessage(lint.Report(msg, failing), repairPrompt)
+		if err != nil {
+			return "", fmt.Errorf("failed to fix commit message: %w", err)
+		}
+		reportUsage(cfgManager, llmClient)
+		msg = lint.Fix(fixed, rules)
+		violations = lint.Lint(msg, rules)
+		failing = lint.Errors(violations)
+	}
+
+	warnViolations(failing, violations)
+	return msg, nil
+}
+
 // NewCommitCmd creates a new commit command
 func NewCommitCmd() *cobra.Command {
 	var (
-		repoPath string
-		rich     bool
-		dryRun   bool
-		useSVN   bool
-		autoYes  bool
+		repoPath      string
+		rich          bool
+		dryRun        bool
+		useSVN        bool
+		autoYes       bool
+		noStream      bool
+		numCandidates int
 	)
 
 	cmd := &cobra.Command{
@@ -155,16 +429,13 @@ func NewCommitCmd() *cobra.Command {
 			}
 			debug.Println("Found staged changes")
 
-			// Get config path from root command
-			configPath, err := cmd.Root().PersistentFlags().GetString("config")
-			if err != nil {
-				return fmt.Errorf("failed to get config path: %w", err)
-			}
-
 			// Create config manager
-			cfgManager, err := config.New(configPath)
+			cfgManager, err := loadConfig(cmd, repoPath)
 			if err != nil {
-				return fmt.Errorf("failed to create config manager: %w", err)
+				return err
+			}
+			if err := useProfile(cmd, cfgManager); err != nil {
+				return err
 			}
 
 			// Get filtered diff
@@ -177,14 +448,29 @@ func NewCommitCmd() *cobra.Command {
 			}
 			debug.Printf("Got diff length: %d", len(diff))
 
-			// Get client config
-			clientConfig, err := cfgManager.GetClientConfig()
+			llmClient, err := newLLMClient(cfgManager)
+			if err != nil {
+				return err
+			}
+
+			scopes, err := inferScopes(cfgManager, vcs, repoPath)
+			if err != nil {
+				return fmt.Errorf("failed to infer scopes: %w", err)
+			}
+			vars := setPromptVars(cfgManager, vcs, repoPath, diff)
+			llmClient.SetExamples(vars.RecentCommits)
+			prompt, err := cfgManager.GetPrompt(rich)
 			if err != nil {
 				return err
 			}
+			prompt = withScopes(prompt, scopes)
 
-			// Create client
-			client := client.New(clientConfig)
+			// Summarize diffs that are too large for the provider in chunks
+			// and generate the message from the summaries instead
+			diff, err = condenseDiff(cfgManager, llmClient, diff, prompt)
+			if err != nil {
+				return err
+			}
 
 			reader := bufio.NewReader(os.Stdin)
 			var commitMsg string
@@ -194,33 +480,42 @@ func NewCommitCmd() *cobra.Command {
 				}
 				fmt.Println("🤖 Hang tight, I'm cooking up something good!")
 
-				// Get prompt based on rich flag
-				prompt := cfgManager.GetPrompt(rich)
+				if commitMsg == "" && numCandidates > 1 {
+					commitMsg, err = chooseCandidate(cfgManager, llmClient, diff, prompt, numCandidates, scopes, autoYes)
+					if err != nil {
+						return err
+					}
+					if commitMsg == "" {
+						fmt.Println("Operation cancelled")
+						return nil
+					}
+				}
 
 				if commitMsg == "" {
 					// Generate commit message
 					var err error
-					commitMsg, err = client.GenerateCommitMessage(diff, prompt)
+					if noStream {
+						commitMsg, err = llmClient.GenerateCommitMessage(diff, prompt)
+					} else {
+						// Print the message token by token as it arrives
+						commitMsg, err = llmClient.StreamCommitMessage(diff, prompt, func(chunk string) {
+							fmt.Print(chunk)
+						})
+						fmt.Println()
+					}
 					if err != nil {
 						return fmt.Errorf("failed to generate commit message: %w", err)
 					}
-				}
+					if provider := llmClient.LastProvider(); provider != llmClient.Provider() {
+						fmt.Printf("Commit message generated by fallback provider %s\n", provider)
+					}
+					reportUsage(cfgManager, llmClient)
 
-				// If output.lang is not "en", prompt for translation
-				var lang string
-				langValue, ok := cfgManager.Get(LANGUAGE_KEY)
-				if !ok {
-					return fmt.Errorf("failed to get output.lang: configuration key not found")
-				}
-				lang, ok = langValue.(string)
-				if !ok {
-					return fmt.Errorf("output.lang is not a string: %v", langValue)
-				}
-				if lang != "en" {
-					translatePrompt := cfgManager.GetTranslationPrompt()
-					commitMsg, err = client.TranslateMessage(translatePrompt, commitMsg, lang)
+					// Translate the message if output.lang is not "en" and
+					// lint the result
+					commitMsg, err = finishMessage(cfgManager, llmClient, commitMsg, scopes)
 					if err != nil {
-						return fmt.Errorf("failed to translate commit message: %w", err)
+						return err
 					}
 				}
 				fmt.Printf("\nGenerated commit message:\n%s\n", formatCommitMessage(commitMsg))
@@ -282,7 +577,9 @@ func NewCommitCmd() *cobra.Command {
 						fmt.Printf("Error editing message: %v\n", err)
 						continue
 					}
-					commitMsg = edited
+					if commitMsg, err = checkMessage(cfgManager, edited); err != nil {
+						return err
+					}
 					continue
 				default:
 					fmt.Println("Invalid option, please try again")
@@ -294,9 +591,12 @@ func NewCommitCmd() *cobra.Command {
 
 	cmd.Flags().StringVarP(&repoPath, "config", "c", "", "Config path")
 	cmd.Flags().BoolVarP(&rich, "rich", "r", false, "Generate rich commit message with details")
+	registerProfileFlag(cmd)
 	cmd.Flags().BoolVarP(&autoYes, "yes", "y", false, "Automatically commit without asking")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the generated commit message and exit without committing")
 	cmd.Flags().BoolVar(&useSVN, "svn", false, "Use SVN instead of Git")
+	cmd.Flags().BoolVar(&noStream, "no-stream", false, "Wait for the complete response instead of streaming it")
+	cmd.Flags().IntVar(&numCandidates, "candidates", 1, "Generate this many alternative messages and pick one of them")
 
 	return cmd
 }
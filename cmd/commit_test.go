@@ -238,15 +238,31 @@ func (m *mockLLM) ParseResponse(response []byte) (string, error) {
 	return string(response), nil
 }
 
-func (m *mockLLM) GetUsage(data []byte) (string, error) {
-	return "", nil
+func (m *mockLLM) GetUsage(data []byte) (*types.Usage, error) {
+	return nil, nil
 }
 
-func (m *mockLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+func (m *mockLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (*types.CompletionResponse, error) {
 	if m.makeRequest != nil {
-		return m.makeRequest(ctx, client, message, history)
+		content, err := m.makeRequest(ctx, client, message, history)
+		if err != nil {
+			return nil, err
+		}
+		return &types.CompletionResponse{Content: content}, nil
+	}
+	return &types.CompletionResponse{Content: "mock response"}, nil
+}
+
+func (m *mockLLM) MakeStreamRequest(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	resp, err := m.MakeRequest(ctx, client, message, history)
+	if err == nil && onChunk != nil {
+		onChunk(resp.Content)
 	}
-	return "mock response", nil
+	return resp, err
+}
+
+func (m *mockLLM) ParseStreamChunk(data []byte) (string, error) {
+	return string(data), nil
 }
 
 func (m *mockLLM) GenerateCommitMessage(diff string, prompt string) (string, error) {
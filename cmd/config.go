@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/belingud/go-gptcomet/internal/config"
 	"github.com/belingud/go-gptcomet/internal/debug"
@@ -21,16 +24,10 @@ func NewConfigCmd() *cobra.Command {
 		Use:   "config",
 		Short: "Manage configuration",
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			// Get config path from root command
-			configPath, err := cmd.Root().PersistentFlags().GetString("config")
-			if err != nil {
-				return fmt.Errorf("failed to get config path: %w", err)
-			}
-
 			// Create config manager
-			cfgManager, err := config.New(configPath)
+			cfgManager, err := loadConfig(cmd, "")
 			if err != nil {
-				return fmt.Errorf("failed to create config manager: %w", err)
+				return err
 			}
 
 			// Store config manager in context
@@ -58,17 +55,11 @@ func NewConfigCmd() *cobra.Command {
 			if !ok {
 				return fmt.Errorf("config key not found: %s", args[0])
 			}
-			// If the value is a map, check for api_key and mask it
-			if m, ok := value.(map[string]interface{}); ok {
-				config.MaskConfigAPIKeys(m)
-			}
 
-			// Check if the value is an API key
-			if strings.HasSuffix(args[0], ".api_key") {
-				if strValue, ok := value.(string); ok {
-					value = config.MaskAPIKey(strValue, 4)
-				}
+			if showOrigin, _ := cmd.Flags().GetBool("show-origin"); showOrigin {
+				return writeOrigins(cmd.OutOrStdout(), cfgManager, args[0])
 			}
+			value = config.MaskValue(args[0], value)
 
 			data, err := json.MarshalIndent(value, "", "  ")
 			if err != nil {
@@ -128,6 +119,7 @@ func NewConfigCmd() *cobra.Command {
 			return nil
 		},
 	}
+	getCmd.Flags().Bool("show-origin", false, "Show the layer that supplied every value")
 	resetCmd.Flags().Bool("prompt", false, "Reset only prompt configuration")
 
 	// set command
@@ -279,3 +271,63 @@ func NewConfigCmd() *cobra.Command {
 	cmd.AddCommand(getCmd, listCmd, resetCmd, setCmd, pathCmd, removeCmd, appendCmd, keysCmd)
 	return cmd
 }
+
+// loadConfig creates the config manager for cmd. The repository config is
+// looked up from repoPath, and the --set flags of the root command override
+// the values of all other layers.
+func loadConfig(cmd *cobra.Command, repoPath string) (*config.Manager, error) {
+	flags := cmd.Root().PersistentFlags()
+	configPath, err := flags.GetString("config")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config path: %w", err)
+	}
+
+	opts := config.Options{RepoPath: repoPath}
+	if flags.Lookup("set") != nil {
+		values, err := flags.GetStringArray("set")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get config overrides: %w", err)
+		}
+		opts.Overrides, err = parseOverrides(values)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cfgManager, err := config.Load(configPath, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config manager: %w", err)
+	}
+	return cfgManager, nil
+}
+
+// parseOverrides parses key=value pairs given with --set
+func parseOverrides(values []string) (map[string]string, error) {
+	overrides := make(map[string]string, len(values))
+	for _, kv := range values {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid --set value %q, must be key=value", kv)
+		}
+		overrides[strings.TrimSpace(key)] = value
+	}
+	return overrides, nil
+}
+
+// writeOrigins prints every value below key together with the layer that
+// supplied it
+func writeOrigins(out io.Writer, cfgManager *config.Manager, key string) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, origin := range cfgManager.GetOrigins(key) {
+		value, _ := cfgManager.Get(origin.Key)
+		value = config.MaskValue(origin.Key, value)
+		var data bytes.Buffer
+		enc := json.NewEncoder(&data)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(value); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s", origin, origin.Key, data.String())
+	}
+	return w.Flush()
+}
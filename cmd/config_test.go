@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/belingud/go-gptcomet/internal/config"
 	"github.com/belingud/go-gptcomet/internal/testutils"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
@@ -125,6 +126,7 @@ openai:
   <provider>.completion_path
   <provider>.extra_headers
   <provider>.frequency_penalty
+  <provider>.max_input_tokens
   <provider>.max_tokens
   <provider>.model
   <provider>.proxy
@@ -132,13 +134,58 @@ openai:
   <provider>.temperature
   <provider>.top_p
   console.verbose
+  custom_providers.<name>.answer_path
+  custom_providers.<name>.api_base
+  custom_providers.<name>.auth
+  custom_providers.<name>.auth_header
+  custom_providers.<name>.auth_param
+  custom_providers.<name>.completion_path
+  custom_providers.<name>.display_name
+  custom_providers.<name>.fields
+  custom_providers.<name>.model
+  custom_providers.<name>.stream_path
+  custom_providers.<name>.usage_paths
+  fallback_providers
   file_ignore
+  history.count
+  history.touched_paths
+  issue.pattern
+  issue.placement
+  issue.trailer
+  lint.commitlint
+  lint.enabled
+  lint.max_attempts
+  lint.max_body_line_length
+  lint.max_header_length
+  lint.require_scope
+  lint.rules
+  lint.scopes
+  lint.types
   output.lang
   output.rich_template
+  pricing
   prompt.brief_commit_message
+  prompt.changelog
+  prompt.combine_summaries
+  prompt.explain_commit
+  prompt.explain_file
+  prompt.profile
+  prompt.profiles.<name>.format
+  prompt.profiles.<name>.lang
+  prompt.profiles.<name>.lint
+  prompt.profiles.<name>.system
+  prompt.profiles.<name>.template
+  prompt.pull_request
+  prompt.repair_commit_message
+  prompt.review
   prompt.rich_commit_message
+  prompt.split_commits
+  prompt.summarize_chunk
   prompt.translation
   provider
+  scope.enabled
+  scope.monorepo
+  scope.paths
 `,
 		},
 	}
@@ -236,3 +283,24 @@ openai:
 	// Check if the API key is masked in the output
 	assert.Contains(t, s, "api_key: sk-or-v1-abc**")
 }
+
+func TestWriteOrigins_SecretRef(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+openai:
+  api_key: cmd:pass show openai
+claude:
+  api_key: sk-ant-secret
+`)
+	defer cleanup()
+	cfgManager, err := config.Load(configFile, config.Options{RepoPath: t.TempDir()})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeOrigins(&buf, cfgManager, "openai.api_key"))
+	assert.Contains(t, buf.String(), `"cmd:pass show openai"`)
+
+	buf.Reset()
+	require.NoError(t, writeOrigins(&buf, cfgManager, "claude"))
+	assert.Contains(t, buf.String(), `"sk-ant*******"`)
+}
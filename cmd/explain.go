@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/belingud/go-gptcomet/internal/config"
+	"github.com/belingud/go-gptcomet/internal/debug"
+	"github.com/belingud/go-gptcomet/internal/git"
+	"github.com/belingud/go-gptcomet/internal/prompt"
+
+	"github.com/spf13/cobra"
+)
+
+// readExplainFile reads the file name, relative to repoPath unless it is
+// absolute. Binary files and files matching file_ignore are refused.
+func readExplainFile(cfgManager *config.Manager, repoPath, name string) (string, error) {
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(repoPath, path)
+	}
+	if root, err := git.TopLevel(repoPath); err == nil {
+		if rel, err := filepath.Rel(root, path); err == nil && git.ShouldIgnoreFile(filepath.ToSlash(rel), cfgManager.GetFileIgnore()) {
+			return "", fmt.Errorf("%s matches file_ignore", rel)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%s is neither a revision nor a file", name)
+		}
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if bytes.IndexByte(content, 0) >= 0 {
+		return "", fmt.Errorf("%s is a binary file", name)
+	}
+	if strings.TrimSpace(string(content)) == "" {
+		return "", fmt.Errorf("%s is empty", name)
+	}
+	return string(content), nil
+}
+
+// NewExplainCmd creates a new explain command
+func NewExplainCmd() *cobra.Command {
+	var repoPath string
+
+	cmd := &cobra.Command{
+		Use:   "explain <rev|range|path>",
+		Short: "Explain what a commit or a range of commits did, or summarize a file",
+		Long: `Explain what a commit or a range of commits did and why, or summarize a file.
+
+The argument is a commit such as HEAD~2 or a tag, a range such as
+v0.1.27..v0.1.28, or the path of a file. Revisions take precedence over
+files of the same name, prefix a file with ./ to explain it instead.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target := args[0]
+			if repoPath == "" {
+				var err error
+				repoPath, err = os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get current directory: %w", err)
+				}
+			}
+
+			vcs := &git.GitVCS{}
+			cfgManager, err := loadConfig(cmd, repoPath)
+			if err != nil {
+				return err
+			}
+			llmClient, err := newLLMClient(cfgManager)
+			if err != nil {
+				return err
+			}
+
+			file := !git.IsRange(target) && !vcs.IsCommit(repoPath, target)
+			var text string
+			var p prompt.Prompt
+			if file {
+				debug.Printf("Explaining the file %s", target)
+				content, err := readExplainFile(cfgManager, repoPath, target)
+				if err != nil {
+					return err
+				}
+				setPromptVars(cfgManager, vcs, repoPath, "")
+				if p, err = cfgManager.GetExplainPrompt(true); err != nil {
+					return err
+				}
+				if content, err = condenseDiff(cfgManager, llmClient, content, p); err != nil {
+					return err
+				}
+				text = "File: " + filepath.ToSlash(target) + "\n\n" + content
+			} else {
+				debug.Printf("Explaining the revision %s", target)
+				log, err := vcs.GetRevisionLog(repoPath, target)
+				if err != nil {
+					return fmt.Errorf("failed to get log: %w", err)
+				}
+				if strings.TrimSpace(log) == "" {
+					return fmt.Errorf("no commits found in %s", target)
+				}
+				diff, err := vcs.GetRevisionDiff(repoPath, target, cfgManager)
+				if err != nil {
+					return fmt.Errorf("failed to get diff: %w", err)
+				}
+				setPromptVars(cfgManager, vcs, repoPath, diff)
+				if p, err = cfgManager.GetExplainPrompt(false); err != nil {
+					return err
+				}
+				if diff, err = condenseDiff(cfgManager, llmClient, diff, p); err != nil {
+					return err
+				}
+				text = "Commits:\n" + strings.TrimSpace(log) + "\n\nDiff:\n" + diff
+			}
+
+			fmt.Fprintf(os.Stderr, "🤖 Hang tight, I'm explaining %s!\n", target)
+			explanation, err := llmClient.GenerateCodeExplanation(text, p)
+			if err != nil {
+				return fmt.Errorf("failed to generate explanation: %w", err)
+			}
+			reportUsage(cfgManager, llmClient)
+
+			fmt.Fprintln(cmd.OutOrStdout(), explanation)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "Repository path, the current directory by default")
+
+	return cmd
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/belingud/go-gptcomet/internal/debug"
+	"github.com/belingud/go-gptcomet/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+// NewHookCmd creates a new hook command
+func NewHookCmd() *cobra.Command {
+	var repoPath string
+
+	cmd := &cobra.Command{
+		Use:   "hook",
+		Short: "Manage the prepare-commit-msg git hook that pre-fills generated messages",
+	}
+
+	getRepoPath := func() (string, error) {
+		if repoPath != "" {
+			return repoPath, nil
+		}
+		dir, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current directory: %w", err)
+		}
+		return dir, nil
+	}
+
+	installCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install the prepare-commit-msg hook, keeping an existing hook",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := getRepoPath()
+			if err != nil {
+				return err
+			}
+			executable, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("failed to get executable path: %w", err)
+			}
+
+			state, err := git.InstallHook(path, executable)
+			if err != nil {
+				return fmt.Errorf("failed to install hook: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Installed %s\n", state.Path)
+			if state.Chained != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "The previous hook was moved to %s and runs first\n", state.Chained)
+			}
+			return nil
+		},
+	}
+
+	uninstallCmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the prepare-commit-msg hook and restore the previous one",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := getRepoPath()
+			if err != nil {
+				return err
+			}
+
+			state, err := git.GetHookState(path)
+			if err != nil {
+				return err
+			}
+			if !state.Installed {
+				fmt.Fprintf(cmd.OutOrStdout(), "No gptcomet hook installed in %s\n", state.Path)
+				return nil
+			}
+
+			state, err = git.UninstallHook(path)
+			if err != nil {
+				return fmt.Errorf("failed to uninstall hook: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed %s\n", state.Path)
+			if state.Foreign {
+				fmt.Fprintln(cmd.OutOrStdout(), "Restored the previous hook")
+			}
+			return nil
+		},
+	}
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the prepare-commit-msg hook is installed",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := getRepoPath()
+			if err != nil {
+				return err
+			}
+
+			state, err := git.GetHookState(path)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			switch {
+			case state.Installed:
+				fmt.Fprintf(out, "Installed: %s\n", state.Path)
+			case state.Foreign:
+				fmt.Fprintf(out, "Not installed, %s is another hook\n", state.Path)
+			default:
+				fmt.Fprintf(out, "Not installed: %s\n", state.Path)
+			}
+			if state.Chained != "" {
+				fmt.Fprintf(out, "Chained hook: %s\n", state.Chained)
+			}
+			return nil
+		},
+	}
+
+	runCmd := &cobra.Command{
+		Use:   "run <msgfile> [source] [sha]",
+		Short: "Generate the commit message into msgfile, called by the hook",
+		Args:  cobra.RangeArgs(1, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			source := ""
+			if len(args) > 1 {
+				source = args[1]
+			}
+			if !git.ShouldGenerateForSource(source) {
+				debug.Printf("Skipping commit with message source %s", source)
+				return nil
+			}
+
+			path, err := getRepoPath()
+			if err != nil {
+				return err
+			}
+
+			// Never block the commit, git opens the editor either way
+			if err := runHook(cmd, path, args[0]); err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "gptcomet: %v\n", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&repoPath, "repo", "", "Repository path, the current directory by default")
+	cmd.AddCommand(installCmd, uninstallCmd, statusCmd, runCmd)
+
+	return cmd
+}
+
+// runHook generates a message for the staged changes of repoPath and
+// writes it to msgFile
+func runHook(cmd *cobra.Command, repoPath, msgFile string) error {
+	cfgManager, err := loadConfig(cmd, repoPath)
+	if err != nil {
+		return err
+	}
+	if err := useProfile(cmd, cfgManager); err != nil {
+		return err
+	}
+
+	vcs := &git.GitVCS{}
+	diff, err := vcs.GetStagedDiffFiltered(repoPath, cfgManager)
+	if err != nil {
+		return fmt.Errorf("failed to get diff: %w", err)
+	}
+	if diff == "" {
+		return nil
+	}
+
+	llmClient, err := newLLMClient(cfgManager)
+	if err != nil {
+		return err
+	}
+
+	scopes, err := inferScopes(cfgManager, vcs, repoPath)
+	if err != nil {
+		return fmt.Errorf("failed to infer scopes: %w", err)
+	}
+	vars := setPromptVars(cfgManager, vcs, repoPath, diff)
+	llmClient.SetExamples(vars.RecentCommits)
+	prompt, err := cfgManager.GetPrompt(false)
+	if err != nil {
+		return err
+	}
+	prompt = withScopes(prompt, scopes)
+	diff, err = condenseDiff(cfgManager, llmClient, diff, prompt)
+	if err != nil {
+		return err
+	}
+
+	msg, err := llmClient.GenerateCommitMessage(diff, prompt)
+	if err != nil {
+		return fmt.Errorf("failed to generate commit message: %w", err)
+	}
+	reportUsage(cfgManager, llmClient)
+
+	msg, err = finishMessage(cfgManager, llmClient, msg, scopes)
+	if err != nil {
+		return err
+	}
+	return git.PrefillMessage(msgFile, msg)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/belingud/go-gptcomet/internal/client"
+	"github.com/belingud/go-gptcomet/internal/config"
+	"github.com/belingud/go-gptcomet/internal/lint"
+)
+
+// finishMessage translates a generated message to output.lang and lints
+// the translation, so that the message which is committed is the one
+// checked against the lint rules, ticket key included
+func finishMessage(cfgManager *config.Manager, llmClient *client.Client, msg string, scopes []string) (string, error) {
+	msg, err := translateMessage(cfgManager, llmClient, msg)
+	if err != nil {
+		return "", err
+	}
+	return lintMessage(cfgManager, llmClient, msg, scopes)
+}
+
+// checkMessage fixes the trivial lint violations of msg, a message edited
+// by the user, such as a dropped ticket key. The other violations are
+// printed as a warning, the model is not asked to fix them. The ticket key
+// is added even if linting is turned off.
+func checkMessage(cfgManager *config.Manager, msg string) (string, error) {
+	rules, err := cfgManager.GetLintRules()
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(msg) == "" {
+		return msg, nil
+	}
+	if !rules.Enabled {
+		return lint.AddIssueKey(msg, rules), nil
+	}
+
+	msg = lint.Fix(msg, rules)
+	violations := lint.Lint(msg, rules)
+	warnViolations(lint.Errors(violations), violations)
+	return msg, nil
+}
+
+// warnViolations prints the violations left in a message, failing being
+// those that are not only warnings
+func warnViolations(failing, violations []lint.Violation) {
+	if len(failing) > 0 {
+		fmt.Fprintln(os.Stderr, "Warning: the commit message still violates lint rules:")
+		for _, v := range failing {
+			fmt.Fprintf(os.Stderr, "  - %s\n", v)
+		}
+	}
+	if len(violations) > len(failing) {
+		fmt.Fprintln(os.Stderr, "Warning: the commit message triggers lint warnings:")
+		for _, v := range violations {
+			if v.Warning {
+				fmt.Fprintf(os.Stderr, "  - %s\n", v)
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/config"
+	"github.com/belingud/go-gptcomet/internal/prompt"
+	"github.com/belingud/go-gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAnsweringConfig returns a config whose provider is a test server
+// passing the last user message of every request to answer
+func newAnsweringConfig(t *testing.T, extra string, answer func(message string) string) *config.Manager {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		message := req.Messages[len(req.Messages)-1].Content
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []interface{}{map[string]interface{}{"message": map[string]string{"content": answer(message)}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+openai:
+  api_base: `+server.URL+`
+  api_key: sk-test
+  model: gpt-4o
+  retries: 0
+`+extra)
+	t.Cleanup(cleanup)
+	cfgManager, err := config.New(configFile)
+	require.NoError(t, err)
+	return cfgManager
+}
+
+func TestFinishMessage_LintsTranslation(t *testing.T) {
+	var repairs []string
+	cfgManager := newAnsweringConfig(t, "output:\n  lang: zh-cn\n", func(message string) string {
+		if strings.Contains(message, "Violations:") {
+			repairs = append(repairs, message)
+			return "fix: 处理空的差异"
+		}
+		// The translation loses the Conventional Commits header
+		return "修复：处理空的差异"
+	})
+	llmClient, err := newLLMClient(cfgManager)
+	require.NoError(t, err)
+
+	msg, err := finishMessage(cfgManager, llmClient, "fix: handle empty diffs", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "fix: 处理空的差异", msg)
+	require.Len(t, repairs, 1)
+	assert.Contains(t, repairs[0], "header-format")
+}
+
+func TestFinishMessage_IssueKey(t *testing.T) {
+	cfgManager := newAnsweringConfig(t, `output:
+  lang: zh-cn
+issue:
+  pattern: '[A-Z]+-\d+'
+  placement: footer
+`, func(message string) string {
+		// The translation drops the footer with the ticket key
+		return "fix: 处理空的差异"
+	})
+	cfgManager.SetPromptVars(prompt.Vars{IssueKey: "PROJ-1234"})
+	llmClient, err := newLLMClient(cfgManager)
+	require.NoError(t, err)
+
+	msg, err := finishMessage(cfgManager, llmClient, "fix: handle empty diffs\n\nRefs: PROJ-1234", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "fix: 处理空的差异\n\nRefs: PROJ-1234", msg)
+
+	// An edit dropping the key gets it back, with linting turned off too
+	msg, err = checkMessage(cfgManager, "fix: handle empty diffs")
+	require.NoError(t, err)
+	assert.Equal(t, "fix: handle empty diffs\n\nRefs: PROJ-1234", msg)
+	require.NoError(t, cfgManager.Set("lint.enabled", false))
+	msg, err = checkMessage(cfgManager, "fix: handle empty diffs")
+	require.NoError(t, err)
+	assert.Equal(t, "fix: handle empty diffs\n\nRefs: PROJ-1234", msg)
+}
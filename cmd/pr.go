@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/belingud/go-gptcomet/internal/debug"
+	"github.com/belingud/go-gptcomet/internal/git"
+	"github.com/belingud/go-gptcomet/internal/lint"
+	"github.com/belingud/go-gptcomet/internal/pr"
+
+	"github.com/spf13/cobra"
+)
+
+// NewPRCmd creates a new pr command
+func NewPRCmd() *cobra.Command {
+	var (
+		repoPath string
+		base     string
+		output   string
+		useSVN   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "pr",
+		Short: "Generate a pull request title and description from the changes since a base branch",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repoPath == "" {
+				var err error
+				repoPath, err = os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get current directory: %w", err)
+				}
+			}
+
+			vcsType := git.Git
+			if useSVN {
+				vcsType = git.SVN
+			}
+			vcs, err := git.NewVCS(vcsType)
+			if err != nil {
+				return fmt.Errorf("failed to create VCS (%s): %w", vcsType, err)
+			}
+
+			cfgManager, err := loadConfig(cmd, repoPath)
+			if err != nil {
+				return err
+			}
+
+			log, err := vcs.GetRangeLog(repoPath, base)
+			if err != nil {
+				return fmt.Errorf("failed to get log: %w", err)
+			}
+			if strings.TrimSpace(log) == "" {
+				return fmt.Errorf("no commits found between %s and HEAD", base)
+			}
+			diff, err := vcs.GetRangeDiff(repoPath, base, cfgManager)
+			if err != nil {
+				return fmt.Errorf("failed to get diff: %w", err)
+			}
+
+			// The template is looked up at the root of the working tree
+			root := repoPath
+			if vcsType == git.Git {
+				if root, err = git.TopLevel(repoPath); err != nil {
+					return err
+				}
+			}
+			template, err := pr.FindTemplate(root)
+			if err != nil {
+				return fmt.Errorf("failed to read pull request template: %w", err)
+			}
+			setPromptVars(cfgManager, vcs, repoPath, diff)
+			prompt, err := cfgManager.GetPullRequestPrompt()
+			if err != nil {
+				return err
+			}
+			if template != "" {
+				debug.Println("Using the pull request template of the repository")
+				prompt = lint.InsertInstructions(prompt, pr.Instructions(template))
+			}
+
+			llmClient, err := newLLMClient(cfgManager)
+			if err != nil {
+				return err
+			}
+			diff, err = condenseDiff(cfgManager, llmClient, diff, prompt)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(os.Stderr, "🤖 Hang tight, I'm writing the pull request description!")
+			answer, err := llmClient.GenerateCommitMessage(pr.Input(log, diff), prompt)
+			if err != nil {
+				return fmt.Errorf("failed to generate pull request description: %w", err)
+			}
+			reportUsage(cfgManager, llmClient)
+			description, err := pr.Parse(answer)
+			if err != nil {
+				return fmt.Errorf("failed to parse pull request description: %w", err)
+			}
+
+			if output == "" {
+				fmt.Fprint(cmd.OutOrStdout(), description.String())
+				return nil
+			}
+			if err := os.WriteFile(output, []byte(description.String()), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+			fmt.Fprintf(os.Stderr, "Wrote the pull request description to %s\n", output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "Repository path, the current directory by default")
+	cmd.Flags().StringVar(&base, "base", "main", "Branch the pull request is merged into, a revision for SVN")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the title and description to this file instead of stdout")
+	cmd.Flags().BoolVar(&useSVN, "svn", false, "Use SVN instead of Git")
+
+	return cmd
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/belingud/go-gptcomet/internal/config"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// useProfile makes the commit message prompts of cfgManager use the
+// profile given with --profile, or the default profile of the config
+func useProfile(cmd *cobra.Command, cfgManager *config.Manager) error {
+	name := ""
+	if flag := cmd.Flags().Lookup("profile"); flag != nil {
+		name = flag.Value.String()
+	}
+	return cfgManager.UseProfile(name)
+}
+
+// registerProfileFlag adds the --profile flag to cmd
+func registerProfileFlag(cmd *cobra.Command) {
+	cmd.Flags().String("profile", "", "Prompt profile to use, prompt.profile by default")
+}
+
+// profileName returns the profile named in args, or the default profile
+func profileName(cfgManager *config.Manager, args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	if name := cfgManager.GetDefaultProfile(); name != "" {
+		return name, nil
+	}
+	return "", fmt.Errorf("no profile given and prompt.profile is not set")
+}
+
+// isYes reports whether answer accepts a [Y]es/[n]o question
+func isYes(answer string) bool {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+		return true
+	}
+	return false
+}
+
+// NewPromptCmd creates a new prompt command
+func NewPromptCmd() *cobra.Command {
+	var repoPath string
+
+	cmd := &cobra.Command{
+		Use:   "prompt",
+		Short: "Manage prompt profiles",
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the prompt profiles, the default one marked with *",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfgManager, err := loadConfig(cmd, repoPath)
+			if err != nil {
+				return err
+			}
+			profiles, err := cfgManager.GetProfiles()
+			if err != nil {
+				return err
+			}
+			if len(profiles) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No prompt profiles configured")
+				return nil
+			}
+
+			defaultName := cfgManager.GetDefaultProfile()
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "  NAME\tFORMAT\tLANG\tLINT")
+			for _, profile := range profiles {
+				mark := " "
+				if profile.Name == defaultName {
+					mark = "*"
+				}
+				format := profile.Format
+				if format == "" {
+					format = config.FormatBrief
+				}
+				lang, lint := "-", "-"
+				if profile.Lang != "" {
+					lang = profile.Lang
+				}
+				if profile.Lint != nil {
+					lint = "off"
+					if *profile.Lint {
+						lint = "on"
+					}
+				}
+				fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\n", mark, profile.Name, format, lang, lint)
+			}
+			return w.Flush()
+		},
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show [name]",
+		Short: "Show the settings of a prompt profile, the default one if no name is given",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfgManager, err := loadConfig(cmd, repoPath)
+			if err != nil {
+				return err
+			}
+			name, err := profileName(cfgManager, args)
+			if err != nil {
+				return err
+			}
+			profile, err := cfgManager.GetProfile(name)
+			if err != nil {
+				return err
+			}
+
+			data, err := yaml.Marshal(profile)
+			if err != nil {
+				return fmt.Errorf("failed to format profile %s: %w", name, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "# prompt.profiles.%s\n%s", name, data)
+			if profile.Template == "" {
+				key := "brief_commit_message"
+				if profile.Rich() {
+					key = "rich_commit_message"
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "# the template is prompt.%s\n", key)
+			}
+			return nil
+		},
+	}
+
+	editCmd := &cobra.Command{
+		Use:   "edit <name>",
+		Short: "Edit a prompt profile, or create it if it does not exist",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			cfgManager, err := loadConfig(cmd, repoPath)
+			if err != nil {
+				return err
+			}
+			profile, err := cfgManager.GetProfile(name)
+			exists := err == nil
+			if !exists {
+				// Start a new profile from the brief commit message prompt
+				template, _ := cfgManager.Get("prompt.brief_commit_message")
+				profile = config.Profile{Name: name, Format: config.FormatBrief, Template: fmt.Sprint(template)}
+			}
+
+			data, err := yaml.Marshal(profile)
+			if err != nil {
+				return fmt.Errorf("failed to format profile %s: %w", name, err)
+			}
+			original := strings.TrimSpace(string(data))
+			text := original
+			reader := bufio.NewReader(os.Stdin)
+			for {
+				edited, err := editTextAs("prompt profile "+name, text, 20, 0)
+				if err != nil {
+					return err
+				}
+				if edited == "" {
+					fmt.Println("Operation cancelled")
+					return nil
+				}
+				if exists && edited == original {
+					fmt.Println("No changes")
+					return nil
+				}
+
+				var updated config.Profile
+				if err = yaml.Unmarshal([]byte(edited), &updated); err == nil {
+					updated.Name = name
+					err = cfgManager.SetProfile(updated)
+				}
+				if err == nil {
+					fmt.Printf("Saved prompt profile %s to %s\n", name, cfgManager.GetPath())
+					return nil
+				}
+				fmt.Fprintf(os.Stderr, "Invalid profile: %v\n", err)
+				fmt.Print("Edit it again? ([Y]es/[n]o): ")
+				answer, readErr := reader.ReadString('\n')
+				if readErr != nil || !isYes(answer) {
+					return fmt.Errorf("invalid prompt profile %s: %w", name, err)
+				}
+				text = edited
+			}
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&repoPath, "repo", "", "Repository path, for the profiles of its config, the current directory by default")
+	cmd.AddCommand(listCmd, showCmd, editCmd)
+
+	return cmd
+}
@@ -5,11 +5,9 @@ import (
 	"os"
 	"strings"
 
-	"github.com/belingud/go-gptcomet/internal/config"
 	"github.com/belingud/go-gptcomet/internal/debug"
 	"github.com/belingud/go-gptcomet/internal/llm"
 	"github.com/belingud/go-gptcomet/internal/ui"
-	"github.com/belingud/go-gptcomet/pkg/types"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
@@ -20,21 +18,24 @@ func NewProviderCmd() *cobra.Command {
 		Use:   "newprovider",
 		Short: "Configure a new provider interactively",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// get providers list
-			providers := llm.GetProviders()
-
 			// In test environment, skip interactive selection
 			if os.Getenv("GPTCOMET_TEST") == "1" {
 				// Just list providers
 				fmt.Fprintln(cmd.OutOrStdout(), "Available providers:")
-				for _, p := range providers {
+				for _, p := range llm.GetProviders() {
 					fmt.Fprintln(cmd.OutOrStdout(), "-", p)
 				}
 				return nil
 			}
 
+			// Create config manager, which registers the custom providers
+			cfgManager, err := loadConfig(cmd, "")
+			if err != nil {
+				return err
+			}
+
 			// Create and run provider selector
-			selector := ui.NewProviderSelector(providers)
+			selector := ui.NewProviderSelector(llm.GetProviders())
 			p := tea.NewProgram(selector)
 			m, err := p.Run()
 			if err != nil {
@@ -46,14 +47,12 @@ func NewProviderCmd() *cobra.Command {
 				return nil
 			}
 
-			// Create provider instance with config
-			provider, err := llm.NewProvider(providerName, &types.ClientConfig{})
-			if err != nil {
-				return fmt.Errorf("failed to create provider: %w", err)
+			// Get required config with default values from the registry
+			info, ok := llm.GetProviderInfo(providerName)
+			if !ok {
+				return fmt.Errorf("failed to create provider: unknown provider: %s", providerName)
 			}
-
-			// Get required config with default values
-			requiredConfig := provider.GetRequiredConfig()
+			requiredConfig := info.RequiredConfig
 			debug.Printf("Required config: %v", requiredConfig)
 
 			// Create and run config input
@@ -73,18 +72,6 @@ func NewProviderCmd() *cobra.Command {
 			configs := model2.GetConfigs()
 			debug.Printf("Config values: %v", configs)
 
-			// Get config path from root command
-			configPath, err := cmd.Root().PersistentFlags().GetString("config")
-			if err != nil {
-				return fmt.Errorf("failed to get config path: %w", err)
-			}
-
-			// Create config manager
-			cfgManager, err := config.New(configPath)
-			if err != nil {
-				return fmt.Errorf("failed to create config manager: %w", err)
-			}
-
 			// Check if provider config already exists
 			existingConfig, _ := cfgManager.Get(providerName)
 			if existingConfig != nil {
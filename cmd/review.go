@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/belingud/go-gptcomet/internal/debug"
+	"github.com/belingud/go-gptcomet/internal/git"
+	"github.com/belingud/go-gptcomet/internal/review"
+
+	"github.com/spf13/cobra"
+)
+
+// Review output formats
+const (
+	reviewText  = "text"
+	reviewJSON  = "json"
+	reviewSARIF = "sarif"
+)
+
+// NewReviewCmd creates a new review command
+func NewReviewCmd() *cobra.Command {
+	var (
+		repoPath string
+		format   string
+		output   string
+		failOn   string
+		useSVN   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "review",
+		Short: "Review the staged changes for bugs and other problems",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != reviewText && format != reviewJSON && format != reviewSARIF {
+				return fmt.Errorf("invalid format %q, expected %s, %s or %s", format, reviewText, reviewJSON, reviewSARIF)
+			}
+			threshold, ok := review.ParseSeverity(failOn)
+			if !ok && failOn != "none" {
+				return fmt.Errorf("invalid severity %q, expected %s, %s, %s or none", failOn, review.Low, review.Medium, review.High)
+			}
+			if repoPath == "" {
+				var err error
+				repoPath, err = os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get current directory: %w", err)
+				}
+			}
+
+			vcsType := git.Git
+			if useSVN {
+				vcsType = git.SVN
+			}
+			vcs, err := git.NewVCS(vcsType)
+			if err != nil {
+				return fmt.Errorf("failed to create VCS (%s): %w", vcsType, err)
+			}
+
+			hasStagedChanges, err := vcs.HasStagedChanges(repoPath)
+			if err != nil {
+				return fmt.Errorf("failed to check staged changes: %w", err)
+			}
+			if !hasStagedChanges {
+				return fmt.Errorf("no staged changes found")
+			}
+
+			cfgManager, err := loadConfig(cmd, repoPath)
+			if err != nil {
+				return err
+			}
+			diff, err := vcs.GetStagedDiffFiltered(repoPath, cfgManager)
+			if err != nil {
+				return fmt.Errorf("failed to get diff: %w", err)
+			}
+			if diff == "" {
+				return fmt.Errorf("no staged changes found after filtering")
+			}
+			debug.Printf("Got diff length: %d", len(diff))
+
+			llmClient, err := newLLMClient(cfgManager)
+			if err != nil {
+				return err
+			}
+			setPromptVars(cfgManager, vcs, repoPath, diff)
+			prompt, err := cfgManager.GetReviewPrompt()
+			if err != nil {
+				return err
+			}
+			condensed, err := condenseDiff(cfgManager, llmClient, diff, prompt)
+			if err != nil {
+				return err
+			}
+			// The summaries of a condensed diff have no lines to number
+			input := review.Number(diff)
+			if condensed != diff {
+				debug.Printf("Reviewing a condensed diff, findings get no line numbers")
+				input = condensed
+			}
+
+			fmt.Fprintln(os.Stderr, "🤖 Hang tight, I'm reviewing the staged changes!")
+			answer, err := llmClient.GenerateCommitMessage(input, prompt)
+			if err != nil {
+				return fmt.Errorf("failed to review the changes: %w", err)
+			}
+			reportUsage(cfgManager, llmClient)
+			findings, err := review.Parse(answer)
+			if err != nil {
+				return fmt.Errorf("failed to parse the review: %w", err)
+			}
+			if condensed != diff {
+				findings = review.WithoutLines(findings)
+			}
+
+			var report []byte
+			switch format {
+			case reviewJSON:
+				report, err = json.MarshalIndent(findings, "", "  ")
+			case reviewSARIF:
+				report, err = review.SARIF(findings, cmd.Root().Version)
+			default:
+				report = []byte(review.Format(findings))
+			}
+			if err != nil {
+				return fmt.Errorf("failed to format the findings: %w", err)
+			}
+			if format != reviewText {
+				report = append(report, '\n')
+			}
+
+			if output == "" {
+				fmt.Fprint(cmd.OutOrStdout(), string(report))
+			} else {
+				if err := os.WriteFile(output, report, 0644); err != nil {
+					return fmt.Errorf("failed to write %s: %w", output, err)
+				}
+				fmt.Fprintf(os.Stderr, "Wrote %d findings to %s\n", len(findings), output)
+			}
+
+			if threshold != "" {
+				if n := review.Count(findings, threshold); n > 0 {
+					return fmt.Errorf("found %d findings of %s severity or above", n, threshold)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "Repository path, the current directory by default")
+	cmd.Flags().StringVar(&format, "format", reviewText, "Output format, text, json or sarif")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the findings to this file instead of stdout")
+	cmd.Flags().StringVar(&failOn, "fail-on", review.High, "Exit with an error on findings of this severity or above: low, medium, high or none")
+	cmd.Flags().BoolVar(&useSVN, "svn", false, "Use SVN instead of Git")
+
+	return cmd
+}
@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/belingud/go-gptcomet/internal/client"
+	"github.com/belingud/go-gptcomet/internal/config"
+	"github.com/belingud/go-gptcomet/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+// generateForDiff generates a linted and translated commit message for
+// diff, the changes of the existing commit rev rather than the staged ones.
+// The recent commits shown to the prompt start before rev, so neither rev
+// nor the commits after it are taken as examples.
+func generateForDiff(cfgManager *config.Manager, llmClient *client.Client, repoPath, rev, diff string, rich bool) (string, error) {
+	diff = git.FilterDiff(diff, cfgManager.GetFileIgnore())
+	if strings.TrimSpace(diff) == "" {
+		return "", fmt.Errorf("no changes found after filtering")
+	}
+
+	root, err := git.TopLevel(repoPath)
+	if err != nil {
+		return "", err
+	}
+	var files []string
+	for _, file := range git.ParseDiff(diff) {
+		files = append(files, file.Path)
+	}
+	scopes, err := scopesOfFiles(cfgManager, root, files)
+	if err != nil {
+		return "", fmt.Errorf("failed to infer scopes: %w", err)
+	}
+	setPromptVarsFrom(cfgManager, &git.GitVCS{}, repoPath, diff, rev+"^")
+	prompt, err := cfgManager.GetPrompt(rich)
+	if err != nil {
+		return "", err
+	}
+	prompt = withScopes(prompt, scopes)
+
+	diff, err = condenseDiff(cfgManager, llmClient, diff, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Println("🤖 Hang tight, I'm cooking up something good!")
+	msg, err := llmClient.GenerateCommitMessage(diff, prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate commit message: %w", err)
+	}
+	if provider := llmClient.LastProvider(); provider != llmClient.Provider() {
+		fmt.Printf("Commit message generated by fallback provider %s\n", provider)
+	}
+	reportUsage(cfgManager, llmClient)
+
+	return finishMessage(cfgManager, llmClient, msg, scopes)
+}
+
+// confirmMessage shows msg and asks question until the user accepts,
+// edits or cancels it, calling regenerate for a new message on retry.
+// Edited messages are checked with checkMessage. It
+// returns an empty message if the user cancelled, and msg without asking
+// if autoYes is set.
+func confirmMessage(cfgManager *config.Manager, msg, question string, autoYes bool, regenerate func() (string, error)) (string, error) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("\nGenerated commit message:\n%s\n", formatCommitMessage(msg))
+		if autoYes {
+			return msg, nil
+		}
+
+		fmt.Printf("\n%s ([Y]es/[n]o/[r]etry/[e]dit): ", question)
+		answer, err := reader.ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("failed to read answer: %w", err)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "", "y", "yes":
+			return msg, nil
+		case "n", "no":
+			return "", nil
+		case "r", "retry":
+			if msg, err = regenerate(); err != nil {
+				return "", err
+			}
+		case "e", "edit":
+			edited, err := editText(msg)
+			if err != nil {
+				fmt.Printf("Error editing message: %v\n", err)
+				continue
+			}
+			if msg, err = checkMessage(cfgManager, edited); err != nil {
+				return "", err
+			}
+		default:
+			fmt.Println("Invalid option, please try again")
+		}
+	}
+}
+
+// rewriteFlags are the flags shared by the reword and amend commands
+type rewriteFlags struct {
+	repoPath string
+	rich     bool
+	dryRun   bool
+	autoYes  bool
+}
+
+func (f *rewriteFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.repoPath, "repo", "", "Repository path, the current directory by default")
+	cmd.Flags().BoolVarP(&f.rich, "rich", "r", false, "Generate rich commit message with details")
+	cmd.Flags().BoolVar(&f.dryRun, "dry-run", false, "Print the generated commit message and exit without rewriting")
+	cmd.Flags().BoolVarP(&f.autoYes, "yes", "y", false, "Automatically rewrite without asking")
+	registerProfileFlag(cmd)
+}
+
+// setup returns the repository path, config manager and client
+func (f *rewriteFlags) setup(cmd *cobra.Command) (string, *config.Manager, *client.Client, error) {
+	repoPath := f.repoPath
+	if repoPath == "" {
+		var err error
+		repoPath, err = os.Getwd()
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+
+	cfgManager, err := loadConfig(cmd, repoPath)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if err := useProfile(cmd, cfgManager); err != nil {
+		return "", nil, nil, err
+	}
+	llmClient, err := newLLMClient(cfgManager)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return repoPath, cfgManager, llmClient, nil
+}
+
+// NewRewordCmd creates a new reword command
+func NewRewordCmd() *cobra.Command {
+	var flags rewriteFlags
+
+	cmd := &cobra.Command{
+		Use:   "reword [<rev>]",
+		Short: "Replace the message of an existing commit, HEAD by default, with a generated one",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rev := "HEAD"
+			if len(args) > 0 {
+				rev = args[0]
+			}
+
+			repoPath, cfgManager, llmClient, err := flags.setup(cmd)
+			if err != nil {
+				return err
+			}
+
+			current, err := git.GetCommitMessage(repoPath, rev)
+			if err != nil {
+				return fmt.Errorf("failed to get commit message: %w", err)
+			}
+			diff, err := git.GetCommitDiff(repoPath, rev)
+			if err != nil {
+				return fmt.Errorf("failed to get diff: %w", err)
+			}
+			fmt.Printf("Current message of %s:\n%s\n", rev, formatCommitMessage(current))
+
+			generate := func() (string, error) {
+				return generateForDiff(cfgManager, llmClient, repoPath, rev, diff, flags.rich)
+			}
+			msg, err := generate()
+			if err != nil {
+				return err
+			}
+			if flags.dryRun {
+				fmt.Printf("\nGenerated commit message:\n%s\n", formatCommitMessage(msg))
+				return nil
+			}
+
+			msg, err = confirmMessage(cfgManager, msg, fmt.Sprintf("Would you like to reword %s?", rev), flags.autoYes, generate)
+			if err != nil {
+				return err
+			}
+			if msg == "" {
+				fmt.Println("Operation cancelled")
+				return nil
+			}
+
+			if err := git.RewordCommit(repoPath, rev, msg); err != nil {
+				return fmt.Errorf("failed to reword commit: %w", err)
+			}
+			fmt.Printf("\nSuccessfully reworded %s\n", rev)
+			return nil
+		},
+	}
+	flags.register(cmd)
+
+	return cmd
+}
+
+// NewAmendCmd creates a new amend command
+func NewAmendCmd() *cobra.Command {
+	var flags rewriteFlags
+
+	cmd := &cobra.Command{
+		Use:   "amend",
+		Short: "Fold the staged changes into HEAD and generate a message for the combined changes",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoPath, cfgManager, llmClient, err := flags.setup(cmd)
+			if err != nil {
+				return err
+			}
+
+			diff, err := git.GetAmendDiff(repoPath)
+			if err != nil {
+				return fmt.Errorf("failed to get diff: %w", err)
+			}
+
+			generate := func() (string, error) {
+				return generateForDiff(cfgManager, llmClient, repoPath, "HEAD", diff, flags.rich)
+			}
+			msg, err := generate()
+			if err != nil {
+				return err
+			}
+			if flags.dryRun {
+				fmt.Printf("\nGenerated commit message:\n%s\n", formatCommitMessage(msg))
+				return nil
+			}
+
+			msg, err = confirmMessage(cfgManager, msg, "Would you like to amend HEAD?", flags.autoYes, generate)
+			if err != nil {
+				return err
+			}
+			if msg == "" {
+				fmt.Println("Operation cancelled")
+				return nil
+			}
+
+			if err := git.AmendCommit(repoPath, msg); err != nil {
+				return fmt.Errorf("failed to amend commit: %w", err)
+			}
+			commitInfo, err := (&git.GitVCS{}).GetCommitInfo(repoPath, "")
+			if err != nil {
+				return fmt.Errorf("failed to get commit info: %w", err)
+			}
+			fmt.Printf("\nSuccessfully amended commit:\n%s\n", commitInfo)
+			return nil
+		},
+	}
+	flags.register(cmd)
+
+	return cmd
+}
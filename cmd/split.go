@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/belingud/go-gptcomet/internal/client"
+	"github.com/belingud/go-gptcomet/internal/config"
+	"github.com/belingud/go-gptcomet/internal/debug"
+	"github.com/belingud/go-gptcomet/internal/git"
+	"github.com/belingud/go-gptcomet/internal/split"
+	"github.com/belingud/go-gptcomet/internal/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+// maxSplitHunkLines is the number of lines of every hunk shown to the model
+// when planning a split
+const maxSplitHunkLines = 80
+
+// planSplit asks the model to group hunks into commits. A commit the model
+// left without a message gets one generated from its patch, every message
+// is translated and linted against the scopes of its files.
+func planSplit(cfgManager *config.Manager, llmClient *client.Client, hunks []split.Hunk, root string) ([]split.Group, error) {
+	ignorePatterns := cfgManager.GetFileIgnore()
+	ignored := func(path string) bool {
+		return git.ShouldIgnoreFile(path, ignorePatterns)
+	}
+
+	splitPrompt, err := cfgManager.GetSplitPrompt()
+	if err != nil {
+		return nil, err
+	}
+	answer, err := llmClient.GenerateCommitMessage(split.Describe(hunks, ignored, maxSplitHunkLines), splitPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan the split: %w", err)
+	}
+	reportUsage(cfgManager, llmClient)
+	groups, err := split.ParsePlan(answer, hunks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the split plan: %w", err)
+	}
+	debug.Printf("Split plan has %d commits", len(groups))
+
+	for i := range groups {
+		scopes, err := scopesOfFiles(cfgManager, root, split.Files(hunks, groups[i].Hunks))
+		if err != nil {
+			return nil, err
+		}
+
+		msg := groups[i].Message
+		if msg == "" {
+			prompt, err := cfgManager.GetPrompt(false)
+			if err != nil {
+				return nil, err
+			}
+			prompt = withScopes(prompt, scopes)
+			msg, err = llmClient.GenerateCommitMessage(split.Patch(hunks, groups[i].Hunks), prompt)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate commit message: %w", err)
+			}
+			reportUsage(cfgManager, llmClient)
+		}
+		if msg, err = finishMessage(cfgManager, llmClient, msg, scopes); err != nil {
+			return nil, err
+		}
+		groups[i].Message = msg
+	}
+	return groups, nil
+}
+
+// splitFiles returns the files of every group
+func splitFiles(groups []split.Group, hunks []split.Hunk) [][]string {
+	files := make([][]string, len(groups))
+	for i, g := range groups {
+		files[i] = split.Files(hunks, g.Hunks)
+	}
+	return files
+}
+
+// printSplit prints the commits of a split plan
+func printSplit(groups []split.Group, hunks []split.Hunk) {
+	for i, files := range splitFiles(groups, hunks) {
+		fmt.Printf("\nCommit %d of %d (%s):\n%s\n", i+1, len(groups), strings.Join(files, ", "), formatCommitMessage(groups[i].Message))
+	}
+}
+
+// NewSplitCmd creates a new split command
+func NewSplitCmd() *cobra.Command {
+	var (
+		repoPath string
+		dryRun   bool
+		autoYes  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "split",
+		Short: "Split the staged changes into several commits with generated messages",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if repoPath == "" {
+				var err error
+				repoPath, err = os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get current directory: %w", err)
+				}
+			}
+
+			vcs := &git.GitVCS{}
+			hasStagedChanges, err := vcs.HasStagedChanges(repoPath)
+			if err != nil {
+				return fmt.Errorf("failed to check staged changes: %w", err)
+			}
+			if !hasStagedChanges {
+				return fmt.Errorf("no staged changes found")
+			}
+
+			cfgManager, err := loadConfig(cmd, repoPath)
+			if err != nil {
+				return err
+			}
+			if err := useProfile(cmd, cfgManager); err != nil {
+				return err
+			}
+
+			patch, err := git.GetStagedPatch(repoPath)
+			if err != nil {
+				return fmt.Errorf("failed to get diff: %w", err)
+			}
+			hunks := split.Hunks(patch)
+			if len(hunks) < 2 {
+				return fmt.Errorf("the staged changes are a single hunk, use gptcomet commit instead")
+			}
+			root, err := git.TopLevel(repoPath)
+			if err != nil {
+				return err
+			}
+
+			llmClient, err := newLLMClient(cfgManager)
+			if err != nil {
+				return err
+			}
+			setPromptVars(cfgManager, vcs, repoPath, patch)
+
+			var groups []split.Group
+		plan:
+			for {
+				fmt.Printf("🤖 Grouping %d hunks into commits...\n", len(hunks))
+				groups, err = planSplit(cfgManager, llmClient, hunks, root)
+				if err != nil {
+					return err
+				}
+				if dryRun || autoYes {
+					printSplit(groups, hunks)
+					if dryRun {
+						return nil
+					}
+					break
+				}
+
+				for {
+					messages := make([]string, len(groups))
+					for i, g := range groups {
+						messages[i] = g.Message
+					}
+					review := ui.NewSplitReview(messages, splitFiles(groups, hunks))
+					if _, err := tea.NewProgram(review).Run(); err != nil {
+						return fmt.Errorf("failed to run split review: %w", err)
+					}
+
+					switch review.Action() {
+					case ui.CandidateCancel:
+						fmt.Println("Operation cancelled")
+						return nil
+					case ui.CandidateRegenerate:
+						continue plan
+					case ui.CandidateEdit:
+						edited, err := editText(review.Selected())
+						if err != nil {
+							return err
+						}
+						if edited != "" {
+							if groups[review.Index()].Message, err = checkMessage(cfgManager, edited); err != nil {
+								return err
+							}
+						}
+					case ui.CandidatePick:
+						break plan
+					}
+				}
+			}
+
+			commits := make([]git.PatchCommit, len(groups))
+			for i, g := range groups {
+				commits[i] = git.PatchCommit{Message: g.Message, Patch: split.Patch(hunks, g.Hunks)}
+			}
+			if err := git.CommitPatches(repoPath, commits); err != nil {
+				return fmt.Errorf("failed to split the staged changes, the index was restored: %w", err)
+			}
+			fmt.Printf("\nCreated %d commits\n", len(commits))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repoPath, "repo", "", "Repository path, the current directory by default")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the planned commits without creating them")
+	cmd.Flags().BoolVarP(&autoYes, "yes", "y", false, "Create the planned commits without reviewing them")
+	registerProfileFlag(cmd)
+
+	return cmd
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/belingud/go-gptcomet/internal/client"
+	"github.com/belingud/go-gptcomet/internal/config"
+	"github.com/belingud/go-gptcomet/internal/usage"
+	"github.com/belingud/go-gptcomet/pkg/types"
+
+	"github.com/spf13/cobra"
+)
+
+// NewUsageCmd creates a new usage command
+func NewUsageCmd() *cobra.Command {
+	var (
+		days int
+		by   []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Report token usage and cost by day, provider and model",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfgManager, err := loadConfig(cmd, "")
+			if err != nil {
+				return err
+			}
+
+			ledger := usage.NewLedger(cfgManager.GetUsageLedgerPath())
+			records, err := ledger.Load()
+			if err != nil {
+				return err
+			}
+
+			if days > 0 {
+				since := time.Now().AddDate(0, 0, -days)
+				filtered := records[:0]
+				for _, r := range records {
+					if r.Time.After(since) {
+						filtered = append(filtered, r)
+					}
+				}
+				records = filtered
+			}
+
+			out := cmd.OutOrStdout()
+			if len(records) == 0 {
+				fmt.Fprintf(out, "No usage recorded in %s\n", ledger.Path())
+				return nil
+			}
+
+			groups := map[string]func(usage.Record) string{
+				"day":      usage.ByDay,
+				"provider": usage.ByProvider,
+				"model":    usage.ByModel,
+			}
+			for _, name := range by {
+				group, ok := groups[name]
+				if !ok {
+					return fmt.Errorf("invalid grouping %q, must be one of: day, provider, model", name)
+				}
+				writeUsageTable(out, name, usage.Summarize(records, group))
+				fmt.Fprintln(out)
+			}
+
+			total := usage.Summarize(records, func(usage.Record) string { return "total" })
+			writeUsageTable(out, "", total)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&days, "days", 0, "Only report the last N days")
+	cmd.Flags().StringSliceVar(&by, "by", []string{"day", "provider", "model"}, "Group totals by day, provider and/or model")
+
+	return cmd
+}
+
+// writeUsageTable prints totals as an aligned table headed by title
+func writeUsageTable(out io.Writer, title string, totals []usage.Total) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	if title != "" {
+		fmt.Fprintf(w, "%s\trequests\tinput\tcached\toutput\tcost\t\n", title)
+	}
+	for _, t := range totals {
+		cost := fmt.Sprintf("$%.4f", t.Cost)
+		if t.Unpriced > 0 {
+			cost += "*"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\t\n", t.Key, t.Requests, t.InputTokens, t.CachedTokens, t.OutputTokens, cost)
+	}
+	w.Flush()
+}
+
+// reportUsage prints the token usage and cost of the requests made by
+// llmClient since the last report and records them in the usage ledger
+func reportUsage(cfgManager *config.Manager, llmClient *client.Client) {
+	requests := llmClient.TakeUsage()
+	if len(requests) == 0 {
+		return
+	}
+
+	var (
+		total   types.Usage
+		cost    float64
+		priced  = true
+		records = make([]usage.Record, 0, len(requests))
+		now     = time.Now()
+	)
+	for _, req := range requests {
+		record := usage.Record{
+			Time:         now,
+			Provider:     req.Provider,
+			Model:        req.Model,
+			InputTokens:  req.Usage.InputTokens,
+			OutputTokens: req.Usage.OutputTokens,
+			CachedTokens: req.Usage.CachedTokens,
+		}
+		if price, ok := cfgManager.GetModelPrice(req.Model); ok {
+			record.Cost = usage.Cost(req.Usage, price)
+			record.Priced = true
+		} else {
+			priced = false
+		}
+		records = append(records, record)
+
+		total.InputTokens += req.Usage.InputTokens
+		total.OutputTokens += req.Usage.OutputTokens
+		total.CachedTokens += req.Usage.CachedTokens
+		cost += record.Cost
+	}
+
+	msg := fmt.Sprintf("Token usage> input: %d (cached: %d), output: %d", total.InputTokens, total.CachedTokens, total.OutputTokens)
+	if priced {
+		msg += fmt.Sprintf(", cost: $%.6f", cost)
+	} else {
+		msg += ", cost: unknown (set pricing.<model> in the config)"
+	}
+	fmt.Fprintln(os.Stderr, msg)
+
+	if err := usage.NewLedger(cfgManager.GetUsageLedgerPath()).Append(records...); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record usage: %v\n", err)
+	}
+}
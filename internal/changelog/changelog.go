@@ -0,0 +1,185 @@
+// Package changelog groups commits by their Conventional Commits type into
+// release notes and inserts them into a Keep a Changelog file.
+package changelog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/belingud/go-gptcomet/internal/git"
+	"github.com/belingud/go-gptcomet/internal/lint"
+)
+
+// Unreleased is the version of changes that are not released yet
+const Unreleased = "Unreleased"
+
+// Sections are the Keep a Changelog sections in the order they appear,
+// followed by Other for the changes that may not matter to users
+var Sections = []string{"Added", "Changed", "Deprecated", "Removed", "Fixed", "Security", "Other"}
+
+// typeSections maps Conventional Commits types to sections, other types
+// and commits that are not conventional go to Other
+var typeSections = map[string]string{
+	"feat":      "Added",
+	"perf":      "Changed",
+	"refactor":  "Changed",
+	"deprecate": "Deprecated",
+	"remove":    "Removed",
+	"revert":    "Removed",
+	"fix":       "Fixed",
+	"security":  "Security",
+	"build":     "Other",
+	"chore":     "Other",
+	"ci":        "Other",
+	"docs":      "Other",
+	"style":     "Other",
+	"test":      "Other",
+}
+
+// DefaultHeader starts a new changelog file
+const DefaultHeader = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).
+`
+
+// versionHeading starts the heading of a version section
+const versionHeading = "## "
+
+// Entry is a commit in a section
+type Entry struct {
+	Hash     string
+	Scope    string
+	Breaking bool
+	Subject  string
+	Body     string
+}
+
+// Section is a group of entries
+type Section struct {
+	Title   string
+	Entries []Entry
+}
+
+// Group sorts the commits into Sections by their type, dropping empty
+// sections. A "BREAKING CHANGE" footer marks a commit as breaking too.
+func Group(commits []git.Commit) []Section {
+	entries := make(map[string][]Entry)
+	for _, c := range commits {
+		entry := Entry{Hash: c.Hash, Subject: c.Subject, Body: c.Body}
+		section := "Other"
+		if h, ok := lint.ParseHeader(c.Subject); ok {
+			if s, ok := typeSections[strings.ToLower(h.Type)]; ok {
+				section = s
+			}
+			entry.Scope = h.Scope
+			entry.Breaking = h.Breaking
+			entry.Subject = h.Subject
+		}
+		if strings.Contains(c.Body, "BREAKING CHANGE") {
+			entry.Breaking = true
+		}
+		entries[section] = append(entries[section], entry)
+	}
+
+	var sections []Section
+	for _, title := range Sections {
+		if len(entries[title]) > 0 {
+			sections = append(sections, Section{Title: title, Entries: entries[title]})
+		}
+	}
+	return sections
+}
+
+// Format lists the sections as markdown for the model, every entry with
+// its scope, hash and indented body
+func Format(sections []Section) string {
+	var s strings.Builder
+	for i, section := range sections {
+		if i > 0 {
+			s.WriteString("\n")
+		}
+		fmt.Fprintf(&s, "### %s\n\n", section.Title)
+		for _, e := range section.Entries {
+			s.WriteString("- ")
+			if e.Breaking {
+				s.WriteString("**BREAKING** ")
+			}
+			if e.Scope != "" {
+				fmt.Fprintf(&s, "%s: ", e.Scope)
+			}
+			fmt.Fprintf(&s, "%s (%s)\n", e.Subject, e.Hash)
+			for _, line := range strings.Split(e.Body, "\n") {
+				if strings.TrimSpace(line) != "" {
+					s.WriteString("  " + line + "\n")
+				}
+			}
+		}
+	}
+	return s.String()
+}
+
+// Clean removes a markdown fence around the notes of the model
+func Clean(notes string) string {
+	notes = strings.TrimSpace(notes)
+	if strings.HasPrefix(notes, "```") && strings.HasSuffix(notes, "```") {
+		notes = strings.TrimSuffix(notes, "```")
+		if _, rest, ok := strings.Cut(notes, "\n"); ok {
+			notes = rest
+		}
+	}
+	return strings.TrimSpace(notes)
+}
+
+// Insert adds the notes of version, released on date, to the Keep a
+// Changelog file content. The new section goes above the other versions
+// but below an Unreleased section, which is replaced if version is
+// Unreleased. An empty file gets DefaultHeader.
+func Insert(content, notes, version, date string) string {
+	heading := fmt.Sprintf("## [%s]", version)
+	if version != Unreleased && date != "" {
+		heading += " - " + date
+	}
+	section := heading + "\n\n" + strings.TrimSpace(notes) + "\n"
+
+	if strings.TrimSpace(content) == "" {
+		return DefaultHeader + "\n" + section
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	first := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, versionHeading) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return strings.TrimRight(content, "\n") + "\n\n" + section
+	}
+
+	// lines[start:end] are replaced by the new section
+	start, end := first, first
+	if strings.HasPrefix(strings.ToLower(lines[first]), strings.ToLower("## ["+Unreleased+"]")) {
+		next := first + 1
+		for next < len(lines) && !strings.HasPrefix(lines[next], versionHeading) {
+			next++
+		}
+		if version == Unreleased {
+			end = next
+		} else {
+			start, end = next, next
+		}
+	}
+
+	before := strings.Join(lines[:start], "")
+	if before != "" && !strings.HasSuffix(before, "\n\n") {
+		before += "\n"
+	}
+	after := strings.Join(lines[end:], "")
+	if after != "" {
+		section += "\n"
+	}
+	return before + section + after
+}
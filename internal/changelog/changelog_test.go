@@ -0,0 +1,92 @@
+package changelog
+
+import (
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/git"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "a1", Subject: "feat(cli): add the changelog command", Body: "Group commits by type."},
+		{Hash: "b2", Subject: "fix: handle empty diffs"},
+		{Hash: "c3", Subject: "Update readme"},
+		{Hash: "d4", Subject: "refactor!: drop the v1 config", Body: "BREAKING CHANGE: the v1 keys are gone"},
+		{Hash: "e5", Subject: "feat: stream messages"},
+	}
+
+	sections := Group(commits)
+	assert.Equal(t, []Section{
+		{Title: "Added", Entries: []Entry{
+			{Hash: "a1", Scope: "cli", Subject: "add the changelog command", Body: "Group commits by type."},
+			{Hash: "e5", Subject: "stream messages"},
+		}},
+		{Title: "Changed", Entries: []Entry{
+			{Hash: "d4", Breaking: true, Subject: "drop the v1 config", Body: "BREAKING CHANGE: the v1 keys are gone"},
+		}},
+		{Title: "Fixed", Entries: []Entry{{Hash: "b2", Subject: "handle empty diffs"}}},
+		{Title: "Other", Entries: []Entry{{Hash: "c3", Subject: "Update readme"}}},
+	}, sections)
+
+	assert.Equal(t, "### Added\n\n"+
+		"- cli: add the changelog command (a1)\n  Group commits by type.\n"+
+		"- stream messages (e5)\n\n"+
+		"### Changed\n\n"+
+		"- **BREAKING** drop the v1 config (d4)\n  BREAKING CHANGE: the v1 keys are gone\n\n"+
+		"### Fixed\n\n- handle empty diffs (b2)\n\n"+
+		"### Other\n\n- Update readme (c3)\n", Format(sections))
+}
+
+func TestClean(t *testing.T) {
+	assert.Equal(t, "### Added\n\n- x", Clean("```markdown\n### Added\n\n- x\n```"))
+	assert.Equal(t, "### Added\n\n- x", Clean("\n### Added\n\n- x\n"))
+}
+
+func TestInsert(t *testing.T) {
+	const notes = "### Fixed\n\n- Empty diffs no longer fail"
+	const released = "# Changelog\n\n## [0.1.0] - 2024-01-01\n\n### Added\n\n- First release\n"
+	const unreleased = "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Old notes\n\n## [0.1.0] - 2024-01-01\n\n- First release\n"
+
+	tests := []struct {
+		name    string
+		content string
+		version string
+		want    string
+	}{
+		{
+			name:    "new file",
+			version: "0.2.0",
+			want:    DefaultHeader + "\n## [0.2.0] - 2024-02-01\n\n" + notes + "\n",
+		},
+		{
+			name:    "above released",
+			content: released,
+			version: "0.2.0",
+			want:    "# Changelog\n\n## [0.2.0] - 2024-02-01\n\n" + notes + "\n\n## [0.1.0] - 2024-01-01\n\n### Added\n\n- First release\n",
+		},
+		{
+			name:    "below unreleased",
+			content: unreleased,
+			version: "0.2.0",
+			want:    "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Old notes\n\n## [0.2.0] - 2024-02-01\n\n" + notes + "\n\n## [0.1.0] - 2024-01-01\n\n- First release\n",
+		},
+		{
+			name:    "replace unreleased",
+			content: unreleased,
+			version: Unreleased,
+			want:    "# Changelog\n\n## [Unreleased]\n\n" + notes + "\n\n## [0.1.0] - 2024-01-01\n\n- First release\n",
+		},
+		{
+			name:    "no versions",
+			content: "# Changelog\n",
+			version: "0.2.0",
+			want:    "# Changelog\n\n## [0.2.0] - 2024-02-01\n\n" + notes + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Insert(tt.content, notes, tt.version, "2024-02-01"))
+		})
+	}
+}
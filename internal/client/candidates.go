@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/belingud/go-gptcomet/internal/debug"
+	"github.com/belingud/go-gptcomet/internal/llm"
+	"github.com/belingud/go-gptcomet/internal/prompt"
+	"github.com/belingud/go-gptcomet/pkg/types"
+)
+
+// GenerateCommitMessages generates n alternative commit messages for the
+// given diff. Providers supporting several answers per request generate
+// them in one request, for the others n requests are sent concurrently.
+func (c *Client) GenerateCommitMessages(diff string, p prompt.Prompt, n int) ([]string, error) {
+	if n <= 1 {
+		msg, err := c.GenerateCommitMessage(diff, p)
+		if err != nil {
+			return nil, err
+		}
+		return []string{msg}, nil
+	}
+
+	formattedPrompt, history := c.newCommitRequest(p, diff)
+	ctx := context.Background()
+	resp, err := c.withFallback(ctx, func(cl *Client) (*types.CompletionResponse, error) {
+		return cl.choices(ctx, formattedPrompt, history, n)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]string, 0, len(resp.Choices))
+	for _, choice := range resp.Choices {
+		if choice = strings.TrimSpace(choice); choice != "" {
+			messages = append(messages, choice)
+		}
+	}
+	return messages, nil
+}
+
+// choices requests n answers to message from this client's own provider
+func (c *Client) choices(ctx context.Context, message string, history []types.Message, n int) (*types.CompletionResponse, error) {
+	name := c.config.Provider
+	if name == "" {
+		name = llm.DefaultProvider
+	}
+	provider, ok := c.llm.(llm.ChoicesLLM)
+	if info, found := llm.GetProviderInfo(name); !found || !info.Capabilities.Choices {
+		ok = false
+	}
+	if !ok {
+		return c.concurrentChoices(ctx, message, history, n)
+	}
+
+	client, err := c.getClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client: %w", err)
+	}
+
+	var resp *types.CompletionResponse
+	err = c.withRetry(ctx, func() error {
+		var err error
+		resp, err = provider.MakeChoicesRequest(ctx, client, message, history, n)
+		if err == nil && len(resp.Choices) == 0 {
+			err = fmt.Errorf("%w: no answers", llm.ErrParseResponse)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	return resp, nil
+}
+
+// concurrentChoices sends n requests for message at once. It fails only if
+// all of them fail, the usage of the successful ones is added up.
+func (c *Client) concurrentChoices(ctx context.Context, message string, history []types.Message, n int) (*types.CompletionResponse, error) {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		errs  []error
+		usage *types.Usage
+		// answers keeps the order of the requests
+		answers = make([]string, n)
+	)
+
+	debug.Printf("Requesting %d answers concurrently", n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := c.chat(ctx, message, history)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			answers[i] = resp.Content
+			if resp.Usage != nil {
+				if usage == nil {
+					usage = &types.Usage{}
+				}
+				usage.InputTokens += resp.Usage.InputTokens
+				usage.OutputTokens += resp.Usage.OutputTokens
+				usage.CachedTokens += resp.Usage.CachedTokens
+				usage.TotalTokens += resp.Usage.TotalTokens
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(errs) == n {
+		return nil, errors.Join(errs...)
+	}
+	for _, err := range errs {
+		debug.Printf("Request for an alternative answer failed: %v", err)
+	}
+
+	resp := &types.CompletionResponse{Raw: make(map[string]interface{}), Usage: usage}
+	for _, answer := range answers {
+		if answer != "" {
+			resp.Choices = append(resp.Choices, answer)
+		}
+	}
+	resp.Content = resp.Choices[0]
+	return resp, nil
+}
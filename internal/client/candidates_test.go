@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/prompt"
+	"github.com/belingud/go-gptcomet/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCommitMessages_Concurrent(t *testing.T) {
+	var calls int32
+	client := &Client{
+		config: &types.ClientConfig{Timeout: 10, Provider: "mock"},
+		llm: &MockLLM{
+			makeRequestFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+				if atomic.AddInt32(&calls, 1) == 2 {
+					return "", errors.New("bad request")
+				}
+				return "feat: candidate\n", nil
+			},
+			usage: &types.Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+			name:  "mock",
+		},
+	}
+
+	msgs, err := client.GenerateCommitMessages("diff", prompt.Prompt{User: prompt.Placeholder}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feat: candidate", "feat: candidate"}, msgs)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// The usage of the successful requests is recorded as one request
+	usage := client.TakeUsage()
+	require.Len(t, usage, 1)
+	assert.Equal(t, types.Usage{InputTokens: 20, OutputTokens: 10, TotalTokens: 30}, usage[0].Usage)
+}
+
+func TestGenerateCommitMessages_AllFail(t *testing.T) {
+	client := newMockClient("mock", func() (string, error) {
+		return "", errors.New("bad request")
+	})
+
+	_, err := client.GenerateCommitMessages("diff", prompt.Prompt{User: prompt.Placeholder}, 2)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad request")
+}
+
+func TestGenerateCommitMessages_Single(t *testing.T) {
+	client := newMockClient("mock", func() (string, error) {
+		return "fix: single", nil
+	})
+
+	msgs, err := client.GenerateCommitMessages("diff", prompt.Prompt{User: prompt.Placeholder}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fix: single"}, msgs)
+}
@@ -9,82 +9,77 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/net/proxy"
 
 	"github.com/belingud/go-gptcomet/internal/debug"
 	"github.com/belingud/go-gptcomet/internal/llm"
+	"github.com/belingud/go-gptcomet/internal/prompt"
 	"github.com/belingud/go-gptcomet/pkg/types"
 )
 
 // Client represents an LLM client
 type Client struct {
-	config *types.ClientConfig
-	llm    llm.LLM
+	config    *types.ClientConfig
+	llm       llm.LLM
+	fallbacks []*Client
+	examples  []types.Message
+
+	mu           sync.Mutex
+	lastProvider string
+	usage        []RequestUsage
 }
 
-// New creates a new client with the given config
-func New(config *types.ClientConfig) *Client {
-	var provider llm.LLM
-	switch config.Provider {
-	case "openai":
-		provider = llm.NewOpenAILLM(config)
-	case "claude":
-		provider = llm.NewClaudeLLM(config)
-	case "gemini":
-		provider = llm.NewGeminiLLM(config)
-	case "mistral":
-		provider = llm.NewMistralLLM(config)
-	case "xai":
-		provider = llm.NewXAILLM(config)
-	case "cohere":
-		provider = llm.NewCohereLLM(config)
-	case "tongyi":
-		provider = llm.NewTongyiLLM(config)
-	case "deepseek":
-		provider = llm.NewDeepSeekLLM(config)
-	case "chatglm":
-		provider = llm.NewChatGLMLLM(config)
-	case "azure":
-		provider = llm.NewAzureLLM(config)
-	case "vertex":
-		provider = llm.NewVertexLLM(config)
-	case "kimi":
-		provider = llm.NewKimiLLM(config)
-	case "ollama":
-		provider = llm.NewOllamaLLM(config)
-	case "silicon":
-		provider = llm.NewSiliconLLM(config)
-	case "sambanova":
-		provider = llm.NewSambanovaLLM(config)
-	default:
-		// Default to OpenAI if provider is not specified
-		provider = llm.NewOpenAILLM(config)
+// New creates a new client with the given config. The provider is resolved
+// through the provider registry, an empty provider means the default one.
+func New(config *types.ClientConfig) (*Client, error) {
+	name := config.Provider
+	if name == "" {
+		name = llm.DefaultProvider
+	}
+
+	provider, err := llm.NewProvider(name, config)
+	if err != nil {
+		return nil, fmt.Errorf("%w (available providers: %s)", err, strings.Join(llm.GetProviders(), ", "))
 	}
 
 	return &Client{
 		config: config,
 		llm:    provider,
-	}
+	}, nil
 }
 
-// Chat sends a chat message to the LLM provider
+// Chat sends a chat message to the LLM provider, trying the fallback
+// providers in turn when it fails
 func (c *Client) Chat(ctx context.Context, message string, history []types.Message) (*types.CompletionResponse, error) {
+	return c.withFallback(ctx, func(cl *Client) (*types.CompletionResponse, error) {
+		return cl.chat(ctx, message, history)
+	})
+}
+
+// chat sends a chat message to this client's own provider
+func (c *Client) chat(ctx context.Context, message string, history []types.Message) (*types.CompletionResponse, error) {
 	client, err := c.getClient()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get client: %w", err)
 	}
 
-	content, err := c.llm.MakeRequest(ctx, client, message, history)
+	var resp *types.CompletionResponse
+	err = c.withRetry(ctx, func() error {
+		var err error
+		resp, err = c.llm.MakeRequest(ctx, client, message, history)
+		if err == nil && strings.TrimSpace(resp.Content) == "" {
+			err = fmt.Errorf("%w: empty answer", llm.ErrParseResponse)
+		}
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 
-	return &types.CompletionResponse{
-		Content: content,
-		Raw:     make(map[string]interface{}),
-	}, nil
+	return resp, nil
 }
 
 // createProxyTransport creates an http.Transport with proxy settings based on the configuration
@@ -155,7 +150,11 @@ func (c *Client) sendRawRequest(req *types.CompletionRequest) (string, error) {
 	}
 
 	// Make the request using the LLM provider
-	return c.llm.MakeRequest(context.Background(), client, req.Messages[len(req.Messages)-1].Content, req.Messages[:len(req.Messages)-1])
+	resp, err := c.llm.MakeRequest(context.Background(), client, req.Messages[len(req.Messages)-1].Content, req.Messages[:len(req.Messages)-1])
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
 }
 
 // getClient returns an HTTP client configured with proxy settings if specified
@@ -175,13 +174,14 @@ func (c *Client) getClient() (*http.Client, error) {
 	return client, nil
 }
 
-// TranslateMessage translates the given message to the specified language
-func (c *Client) TranslateMessage(prompt string, message string, lang string) (string, error) {
+// TranslateMessage translates the given message with prompt p, which names
+// the target language and has a {{ placeholder }} for the message
+func (c *Client) TranslateMessage(p prompt.Prompt, message string) (string, error) {
 	// Format the prompt
-	formattedPrompt := fmt.Sprintf(prompt, message, lang)
+	formattedPrompt, history := newRequest(p, message)
 
 	// Send the request
-	resp, err := c.Chat(context.Background(), formattedPrompt, nil)
+	resp, err := c.Chat(context.Background(), formattedPrompt, history)
 	if err != nil {
 		return "", err
 	}
@@ -189,12 +189,13 @@ func (c *Client) TranslateMessage(prompt string, message string, lang string) (s
 	return strings.TrimSpace(resp.Content), nil
 }
 
-// GenerateCommitMessage generates a commit message for the given diff
-func (c *Client) GenerateCommitMessage(diff string, prompt string) (string, error) {
-	formattedPrompt := fmt.Sprintf(prompt, diff)
+// GenerateCommitMessage generates a commit message for the given diff,
+// following the examples given with SetExamples
+func (c *Client) GenerateCommitMessage(diff string, p prompt.Prompt) (string, error) {
+	formattedPrompt, history := c.newCommitRequest(p, diff)
 
 	// Send the request
-	resp, err := c.Chat(context.Background(), formattedPrompt, nil)
+	resp, err := c.Chat(context.Background(), formattedPrompt, history)
 	if err != nil {
 		return "", err
 	}
@@ -202,13 +203,13 @@ func (c *Client) GenerateCommitMessage(diff string, prompt string) (string, erro
 	return strings.TrimSpace(resp.Content), nil
 }
 
-// GenerateCodeExplanation generates an explanation for the given code in the specified language
-func (c *Client) GenerateCodeExplanation(message, lang string) (string, error) {
-	const prompt = "Explain the following %s code:\n\n%s"
-	formattedPrompt := fmt.Sprintf(prompt, lang, message)
+// GenerateCodeExplanation explains the given code, commits or diff with
+// prompt p, which has a {{ placeholder }} for them
+func (c *Client) GenerateCodeExplanation(code string, p prompt.Prompt) (string, error) {
+	formattedPrompt, history := newRequest(p, code)
 
 	// Send the request
-	resp, err := c.Chat(context.Background(), formattedPrompt, nil)
+	resp, err := c.Chat(context.Background(), formattedPrompt, history)
 	if err != nil {
 		return "", err
 	}
@@ -216,20 +217,79 @@ func (c *Client) GenerateCodeExplanation(message, lang string) (string, error) {
 	return strings.TrimSpace(resp.Content), nil
 }
 
-// Stream sends a chat message to the LLM provider and streams the response
-func (c *Client) Stream(ctx context.Context, message string, history []types.Message) (*types.CompletionResponse, error) {
+// Stream sends a chat message to the LLM provider and streams the response,
+// calling onChunk with every piece of text as soon as it arrives. The
+// fallback providers are tried in turn when it fails.
+func (c *Client) Stream(ctx context.Context, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	return c.withFallback(ctx, func(cl *Client) (*types.CompletionResponse, error) {
+		return cl.stream(ctx, message, history, onChunk)
+	})
+}
+
+// stream streams a chat message from this client's own provider. If the
+// provider cannot stream, the complete answer is passed to onChunk at once.
+func (c *Client) stream(ctx context.Context, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	if !c.supportsStreaming() {
+		resp, err := c.chat(ctx, message, history)
+		if err == nil && onChunk != nil {
+			onChunk(resp.Content)
+		}
+		return resp, err
+	}
+
 	client, err := c.getClient()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get client: %w", err)
 	}
 
-	content, err := c.llm.MakeRequest(ctx, client, message, history)
+	// Only retry as long as nothing has been passed to onChunk yet, a retry
+	// after partial output would print the message twice
+	streamed := false
+	var resp *types.CompletionResponse
+	err = c.withRetry(ctx, func() error {
+		var err error
+		resp, err = c.llm.MakeStreamRequest(ctx, client, message, history, func(chunk string) {
+			streamed = true
+			if onChunk != nil {
+				onChunk(chunk)
+			}
+		})
+		if err != nil && streamed {
+			return &permanentError{err: err}
+		}
+		if err == nil && strings.TrimSpace(resp.Content) == "" {
+			err = fmt.Errorf("%w: empty answer", llm.ErrParseResponse)
+		}
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return nil, fmt.Errorf("failed to make stream request: %w", err)
 	}
 
-	return &types.CompletionResponse{
-		Content: content,
-		Raw:     make(map[string]interface{}),
-	}, nil
+	return resp, nil
+}
+
+// supportsStreaming reports whether the provider of this client can
+// stream. Providers missing from the registry are assumed to.
+func (c *Client) supportsStreaming() bool {
+	name := c.config.Provider
+	if name == "" {
+		name = llm.DefaultProvider
+	}
+	info, found := llm.GetProviderInfo(name)
+	return !found || info.Capabilities.Streaming
+}
+
+// StreamCommitMessage generates a commit message for the given diff like
+// GenerateCommitMessage, passing the message to onChunk token by token
+func (c *Client) StreamCommitMessage(diff string, p prompt.Prompt, onChunk func(string)) (string, error) {
+	formattedPrompt, history := c.newCommitRequest(p, diff)
+
+	// Send the request
+	resp, err := c.Stream(context.Background(), formattedPrompt, history, onChunk)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(resp.Content), nil
 }
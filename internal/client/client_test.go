@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/belingud/go-gptcomet/internal/llm"
+	"github.com/belingud/go-gptcomet/internal/prompt"
 	"github.com/belingud/go-gptcomet/pkg/config"
 	"github.com/belingud/go-gptcomet/pkg/types"
 	"github.com/stretchr/testify/assert"
@@ -16,12 +17,14 @@ import (
 // MockLLM implements the LLM interface for testing
 type MockLLM struct {
 	makeRequestFunc       func(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error)
+	makeStreamFunc        func(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (string, error)
 	buildHeadersFunc      func() map[string]string
 	buildURLFunc          func() string
 	formatMessagesFunc    func(model string, messages []types.Message) (interface{}, error)
 	getRequiredConfigFunc func() map[string]config.ConfigRequirement
-	getUsageFunc          func(data []byte) (string, error)
+	getUsageFunc          func(data []byte) (*types.Usage, error)
 	parseResponseFunc     func(response []byte) (string, error)
+	usage                 *types.Usage
 	name                  string
 }
 
@@ -29,8 +32,27 @@ func (m *MockLLM) Name() string {
 	return m.name
 }
 
-func (m *MockLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
-	return m.makeRequestFunc(ctx, client, message, history)
+func (m *MockLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (*types.CompletionResponse, error) {
+	return m.response(m.makeRequestFunc(ctx, client, message, history))
+}
+
+func (m *MockLLM) MakeStreamRequest(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	if m.makeStreamFunc != nil {
+		return m.response(m.makeStreamFunc(ctx, client, message, history, onChunk))
+	}
+	return m.response(m.makeRequestFunc(ctx, client, message, history))
+}
+
+// response wraps the content returned by the mock functions
+func (m *MockLLM) response(content string, err error) (*types.CompletionResponse, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &types.CompletionResponse{Content: content, Usage: m.usage}, nil
+}
+
+func (m *MockLLM) ParseStreamChunk(data []byte) (string, error) {
+	return string(data), nil
 }
 
 func (m *MockLLM) BuildHeaders() map[string]string {
@@ -61,11 +83,11 @@ func (m *MockLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 	return map[string]config.ConfigRequirement{}
 }
 
-func (m *MockLLM) GetUsage(data []byte) (string, error) {
+func (m *MockLLM) GetUsage(data []byte) (*types.Usage, error) {
 	if m.getUsageFunc != nil {
 		return m.getUsageFunc(data)
 	}
-	return "", nil
+	return nil, nil
 }
 
 func (m *MockLLM) ParseResponse(response []byte) (string, error) {
@@ -84,17 +106,36 @@ func TestNewClient(t *testing.T) {
 		{"OpenAI", "openai", &llm.OpenAILLM{}},
 		{"Claude", "claude", &llm.ClaudeLLM{}},
 		{"Default", "", &llm.OpenAILLM{}},
+		{"Ollama", "ollama", &llm.OllamaLLM{}},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			config := &types.ClientConfig{Provider: tt.provider}
-			client := New(config)
+			client, err := New(config)
+			require.NoError(t, err)
 			assert.IsType(t, tt.wantType, client.llm)
 		})
 	}
 }
 
+func TestNewClient_UnknownProvider(t *testing.T) {
+	_, err := New(&types.ClientConfig{Provider: "opneai"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown provider: opneai")
+	assert.Contains(t, err.Error(), "openai")
+}
+
+func TestNewClient_RegisteredProvider(t *testing.T) {
+	require.NoError(t, llm.RegisterProvider("plugin", func(config *types.ClientConfig) llm.LLM {
+		return &MockLLM{name: "plugin"}
+	}))
+
+	client, err := New(&types.ClientConfig{Provider: "plugin"})
+	require.NoError(t, err)
+	assert.Equal(t, "plugin", client.llm.Name())
+}
+
 func TestCreateProxyTransport(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -169,6 +210,7 @@ func TestChatError(t *testing.T) {
 func TestTranslateMessage(t *testing.T) {
 	mockLLM := &MockLLM{
 		makeRequestFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+			assert.Equal(t, "translate to French: hello", message)
 			return "translated message", nil
 		},
 		name: "mock",
@@ -179,7 +221,7 @@ func TestTranslateMessage(t *testing.T) {
 		llm:    mockLLM,
 	}
 
-	translated, err := client.TranslateMessage("translate to %s: %s", "hello", "fr")
+	translated, err := client.TranslateMessage(prompt.Prompt{User: "translate to French: {{ placeholder }}"}, "hello")
 	require.NoError(t, err)
 	assert.Equal(t, "translated message", translated)
 }
@@ -197,7 +239,54 @@ func TestGenerateCommitMessage(t *testing.T) {
 		llm:    mockLLM,
 	}
 
-	msg, err := client.GenerateCommitMessage("diff", "generate commit message for: %s")
+	msg, err := client.GenerateCommitMessage("diff", prompt.Prompt{User: "generate commit message for: %s"})
+	require.NoError(t, err)
+	assert.Equal(t, "commit message", msg)
+}
+
+func TestGenerateCommitMessage_SystemPrompt(t *testing.T) {
+	mockLLM := &MockLLM{
+		makeRequestFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+			assert.Equal(t, "Diff:\ndiff", message)
+			assert.Equal(t, []types.Message{{Role: types.RoleSystem, Content: "be brief"}}, history)
+			return "commit message", nil
+		},
+		name: "mock",
+	}
+
+	client := &Client{
+		config: &types.ClientConfig{Timeout: 10},
+		llm:    mockLLM,
+	}
+
+	msg, err := client.GenerateCommitMessage("diff", prompt.Prompt{System: "be brief", User: "Diff:\n" + prompt.Placeholder})
+	require.NoError(t, err)
+	assert.Equal(t, "commit message", msg)
+}
+
+func TestGenerateCommitMessage_Examples(t *testing.T) {
+	mockLLM := &MockLLM{
+		makeRequestFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+			assert.Equal(t, "Diff:\ndiff", message)
+			assert.Equal(t, []types.Message{
+				{Role: types.RoleSystem, Content: "be brief"},
+				{Role: types.RoleUser, Content: exampleRequest},
+				{Role: types.RoleAssistant, Content: "fix: older"},
+				{Role: types.RoleUser, Content: exampleRequest},
+				{Role: types.RoleAssistant, Content: "feat: newer\n\nWith a body."},
+			}, history)
+			return "commit message", nil
+		},
+		name: "mock",
+	}
+
+	client := &Client{
+		config: &types.ClientConfig{Timeout: 10},
+		llm:    mockLLM,
+	}
+	client.SetExamples([]string{"feat: newer\n\nWith a body.", " ", "fix: older"})
+
+	msg, err := client.GenerateCommitMessage("diff", prompt.Prompt{System: "be brief", User: "Diff:\n" + prompt.Placeholder})
 	require.NoError(t, err)
 	assert.Equal(t, "commit message", msg)
 }
@@ -205,6 +294,7 @@ func TestGenerateCommitMessage(t *testing.T) {
 func TestGenerateCodeExplanation(t *testing.T) {
 	mockLLM := &MockLLM{
 		makeRequestFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+			assert.Equal(t, "Explain this Go code:\n\nfunc main() {}", message)
 			return "code explanation", nil
 		},
 		name: "mock",
@@ -215,7 +305,63 @@ func TestGenerateCodeExplanation(t *testing.T) {
 		llm:    mockLLM,
 	}
 
-	explanation, err := client.GenerateCodeExplanation("code", "go")
+	explanation, err := client.GenerateCodeExplanation("func main() {}", prompt.Prompt{User: "Explain this Go code:\n\n{{ placeholder }}"})
 	require.NoError(t, err)
 	assert.Equal(t, "code explanation", explanation)
 }
+
+func TestStreamCommitMessage(t *testing.T) {
+	mockLLM := &MockLLM{
+		makeStreamFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (string, error) {
+			for _, chunk := range []string{"feat: ", "add ", "streaming"} {
+				onChunk(chunk)
+			}
+			return "feat: add streaming", nil
+		},
+		name: "mock",
+	}
+
+	client := &Client{
+		config: &types.ClientConfig{Timeout: 10},
+		llm:    mockLLM,
+	}
+
+	var chunks []string
+	msg, err := client.StreamCommitMessage("diff", prompt.Prompt{User: "generate commit message for: %s"}, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add streaming", msg)
+	assert.Equal(t, []string{"feat: ", "add ", "streaming"}, chunks)
+}
+
+func TestStreamCommitMessage_NoStreaming(t *testing.T) {
+	require.NoError(t, llm.Register(llm.ProviderInfo{
+		Name: "batch",
+		Constructor: func(config *types.ClientConfig) llm.LLM {
+			return &MockLLM{name: "batch"}
+		},
+	}))
+
+	client := &Client{
+		config: &types.ClientConfig{Provider: "batch", Timeout: 10},
+		llm: &MockLLM{
+			makeRequestFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+				return "feat: add batching", nil
+			},
+			makeStreamFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (string, error) {
+				t.Fatal("no stream request expected for a provider without streaming")
+				return "", nil
+			},
+			name: "batch",
+		},
+	}
+
+	var chunks []string
+	msg, err := client.StreamCommitMessage("diff", prompt.Prompt{User: "generate commit message for: %s"}, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add batching", msg)
+	assert.Equal(t, []string{"feat: add batching"}, chunks)
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"strings"
+
+	"github.com/belingud/go-gptcomet/internal/prompt"
+	"github.com/belingud/go-gptcomet/pkg/types"
+)
+
+// exampleRequest is the user turn answered by each example commit message
+const exampleRequest = "Write a commit message in the style of this repository."
+
+// SetExamples makes the commit message requests show messages, newest
+// first, to the model as earlier answers, so that it follows the style of
+// the repository. Empty messages are left out.
+func (c *Client) SetExamples(messages []string) {
+	c.examples = nil
+	for i := len(messages) - 1; i >= 0; i-- {
+		message := strings.TrimSpace(messages[i])
+		if message == "" {
+			continue
+		}
+		c.examples = append(c.examples,
+			types.Message{Role: types.RoleUser, Content: exampleRequest},
+			types.Message{Role: types.RoleAssistant, Content: message},
+		)
+	}
+}
+
+// newCommitRequest is newRequest with the example commit messages added to
+// the history after the system prompt
+func (c *Client) newCommitRequest(p prompt.Prompt, diff string) (string, []types.Message) {
+	message, history := newRequest(p, diff)
+	if len(c.examples) == 0 {
+		return message, history
+	}
+	return message, append(history, c.examples...)
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/belingud/go-gptcomet/internal/llm"
+	"github.com/belingud/go-gptcomet/pkg/types"
+)
+
+// WithFallbacks adds clients to try in order when this client's provider
+// fails with a timeout, quota error, outage or unparsable response
+func (c *Client) WithFallbacks(fallbacks ...*Client) *Client {
+	c.fallbacks = append(c.fallbacks, fallbacks...)
+	return c
+}
+
+// LastProvider returns the name of the provider that produced the most
+// recent successful response
+func (c *Client) LastProvider() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastProvider
+}
+
+// Provider returns the name of this client's own provider
+func (c *Client) Provider() string {
+	return c.config.Provider
+}
+
+// withFallback runs call against this client and then against every
+// fallback client until one succeeds or an error occurs that another
+// provider would not fix.
+func (c *Client) withFallback(ctx context.Context, call func(*Client) (*types.CompletionResponse, error)) (*types.CompletionResponse, error) {
+	candidates := append([]*Client{c}, c.fallbacks...)
+
+	var errs []error
+	for i, cl := range candidates {
+		if i > 0 {
+			fmt.Fprintf(os.Stderr, "Falling back to provider %s\n", cl.Provider())
+		}
+
+		// The key of a fallback provider is only resolved when it is tried
+		var resp *types.CompletionResponse
+		err := cl.resolveAPIKey()
+		if err == nil {
+			resp, err = call(cl)
+		}
+		if err == nil {
+			resp.Provider = cl.Provider()
+			c.mu.Lock()
+			c.lastProvider = resp.Provider
+			if resp.Usage != nil {
+				c.usage = append(c.usage, RequestUsage{
+					Provider: cl.Provider(),
+					Model:    cl.config.Model,
+					Usage:    *resp.Usage,
+				})
+			}
+			c.mu.Unlock()
+			return resp, nil
+		}
+
+		if len(c.fallbacks) == 0 {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("provider %s: %w", cl.Provider(), err))
+		if i == len(candidates)-1 || !shouldFallback(ctx, err) {
+			break
+		}
+		fmt.Fprintf(os.Stderr, "\nProvider %s failed: %v\n", cl.Provider(), err)
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// resolveAPIKey resolves the api_key of the client's provider on its
+// first use, see types.ClientConfig.ResolveKey
+func (c *Client) resolveAPIKey() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.config.ResolveKey()
+}
+
+// shouldFallback reports whether err means the provider is unavailable or
+// unusable right now, so that another provider may succeed: timeouts and
+// network errors, quota and rate limits, server errors and responses
+// without a parsable answer. Cancellation by the caller never falls back.
+func shouldFallback(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if errors.Is(err, llm.ErrParseResponse) {
+		return true
+	}
+
+	var apiErr *llm.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusRequestTimeout,
+			apiErr.StatusCode == http.StatusPaymentRequired,
+			apiErr.StatusCode == http.StatusTooManyRequests,
+			apiErr.StatusCode >= 500:
+			return true
+		default:
+			return false
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/llm"
+	"github.com/belingud/go-gptcomet/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMockClient returns a client for provider whose requests are answered by fn
+func newMockClient(provider string, fn func() (string, error)) *Client {
+	return &Client{
+		config: &types.ClientConfig{Timeout: 10, Provider: provider},
+		llm: &MockLLM{
+			makeRequestFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+				return fn()
+			},
+			makeStreamFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (string, error) {
+				return fn()
+			},
+			name: provider,
+		},
+	}
+}
+
+func TestChat_Fallback(t *testing.T) {
+	stubSleep(t)
+	tests := []struct {
+		name         string
+		primaryErr   error
+		primaryText  string
+		wantProvider string
+		wantErr      bool
+	}{
+		{
+			name:         "primary succeeds",
+			primaryText:  "feat: primary",
+			wantProvider: "openai",
+		},
+		{
+			name:         "quota error",
+			primaryErr:   apiError(http.StatusTooManyRequests, nil),
+			wantProvider: "claude",
+		},
+		{
+			name:         "timeout",
+			primaryErr:   context.DeadlineExceeded,
+			wantProvider: "claude",
+		},
+		{
+			name:         "parse failure",
+			primaryErr:   llm.ErrParseResponse,
+			wantProvider: "claude",
+		},
+		{
+			name:         "empty answer",
+			primaryText:  "  ",
+			wantProvider: "claude",
+		},
+		{
+			name:       "auth error does not fall back",
+			primaryErr: apiError(http.StatusUnauthorized, nil),
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallbackCalls := 0
+			primary := newMockClient("openai", func() (string, error) {
+				return tt.primaryText, tt.primaryErr
+			})
+			fallback := newMockClient("claude", func() (string, error) {
+				fallbackCalls++
+				return "feat: fallback", nil
+			})
+			primary.WithFallbacks(fallback)
+
+			resp, err := primary.Chat(context.Background(), "test message", nil)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, 0, fallbackCalls)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantProvider, resp.Provider)
+			assert.Equal(t, tt.wantProvider, primary.LastProvider())
+		})
+	}
+}
+
+func TestStream_FallbackChain(t *testing.T) {
+	stubSleep(t)
+	primary := newMockClient("openai", func() (string, error) {
+		return "", apiError(http.StatusServiceUnavailable, nil)
+	})
+	second := newMockClient("gemini", func() (string, error) {
+		return "", &llm.APIError{StatusCode: http.StatusPaymentRequired}
+	})
+	third := newMockClient("ollama", func() (string, error) {
+		return "fix: local model", nil
+	})
+	primary.WithFallbacks(second, third)
+
+	resp, err := primary.Stream(context.Background(), "test message", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "fix: local model", resp.Content)
+	assert.Equal(t, "ollama", primary.LastProvider())
+}
+
+func TestChat_FallbackAllFail(t *testing.T) {
+	stubSleep(t)
+	primary := newMockClient("openai", func() (string, error) {
+		return "", apiError(http.StatusInternalServerError, nil)
+	})
+	fallback := newMockClient("claude", func() (string, error) {
+		return "", apiError(http.StatusBadGateway, nil)
+	})
+	primary.WithFallbacks(fallback)
+
+	_, err := primary.Chat(context.Background(), "test message", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "provider openai")
+	assert.Contains(t, err.Error(), "provider claude")
+
+	var apiErr *llm.APIError
+	assert.True(t, errors.As(err, &apiErr))
+}
+
+func TestChat_FallbackCanceled(t *testing.T) {
+	fallbackCalls := 0
+	primary := newMockClient("openai", func() (string, error) {
+		return "", context.Canceled
+	})
+	fallback := newMockClient("claude", func() (string, error) {
+		fallbackCalls++
+		return "feat: fallback", nil
+	})
+	primary.WithFallbacks(fallback)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := primary.Chat(ctx, "test message", nil)
+	require.Error(t, err)
+	assert.Equal(t, 0, fallbackCalls)
+}
+
+func TestChat_FallbackResolvesKeyLazily(t *testing.T) {
+	stubSleep(t)
+	resolved := 0
+	fallback := newMockClient("claude", func() (string, error) { return "feat: fallback", nil })
+	fallback.config.ResolveAPIKey = func() (string, error) {
+		resolved++
+		return "sk-fallback", nil
+	}
+
+	client := newMockClient("openai", func() (string, error) { return "feat: primary", nil }).WithFallbacks(fallback)
+	_, err := client.Chat(context.Background(), "diff", nil)
+	require.NoError(t, err)
+	assert.Zero(t, resolved, "the key of an unused fallback was resolved")
+
+	client = newMockClient("openai", func() (string, error) {
+		return "", apiError(http.StatusServiceUnavailable, nil)
+	}).WithFallbacks(fallback)
+	for i := 0; i < 2; i++ {
+		resp, err := client.Chat(context.Background(), "diff", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "claude", resp.Provider)
+	}
+	assert.Equal(t, 1, resolved)
+	assert.Equal(t, "sk-fallback", fallback.config.APIKey)
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/belingud/go-gptcomet/internal/debug"
+	"github.com/belingud/go-gptcomet/internal/llm"
+)
+
+var (
+	retryBaseDelay = 1 * time.Second
+	retryMaxDelay  = 30 * time.Second
+	sleepFunc      = sleepContext // replaceable for testing
+	timeNow        = time.Now     // replaceable for testing
+)
+
+// permanentError marks an error that must not be retried regardless of its cause
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// withRetry calls fn until it succeeds, the error is not transient or the
+// configured number of retries is used up. Between attempts it waits with
+// jittered exponential backoff, or as long as the provider asked for in a
+// Retry-After header.
+func (c *Client) withRetry(ctx context.Context, fn func() error) error {
+	retries := c.config.Retries
+	if retries < 0 {
+		retries = 0
+	}
+	attempts := retries + 1
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		debug.Printf("Attempt %d/%d", attempt, attempts)
+		err = fn()
+		if err == nil {
+			return nil
+		}
+		if attempt == attempts || !isRetryable(ctx, err) {
+			return err
+		}
+
+		delay := retryDelay(attempt, err)
+		debug.Printf("Attempt %d/%d failed: %v, retrying in %s", attempt, attempts, err, delay)
+		if sleepErr := sleepFunc(ctx, delay); sleepErr != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// isRetryable reports whether err is a transient failure worth another
+// attempt: network errors and 408, 429 and 5xx responses. Authentication
+// and other client errors are never retried, and neither is anything after
+// the caller's context is done.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var permErr *permanentError
+	if errors.As(err, &permErr) {
+		return false
+	}
+
+	var apiErr *llm.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusRequestTimeout,
+			apiErr.StatusCode == http.StatusTooManyRequests,
+			apiErr.StatusCode >= 500:
+			return true
+		default:
+			return false
+		}
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryDelay returns how long to wait before the next attempt. A Retry-After
+// header on the failed response wins over the computed backoff.
+func retryDelay(attempt int, err error) time.Duration {
+	var apiErr *llm.APIError
+	if errors.As(err, &apiErr) && apiErr.Header != nil {
+		if d, ok := parseRetryAfter(apiErr.Header.Get("Retry-After"), timeNow()); ok {
+			if d > retryMaxDelay {
+				d = retryMaxDelay
+			}
+			return d
+		}
+	}
+
+	backoff := retryBaseDelay << uint(attempt-1)
+	if backoff <= 0 || backoff > retryMaxDelay {
+		backoff = retryMaxDelay
+	}
+	// Full jitter in the upper half keeps concurrent clients apart without
+	// collapsing the delay to zero
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter parses a Retry-After header value, which is either a
+// number of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := t.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
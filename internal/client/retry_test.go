@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/belingud/go-gptcomet/internal/llm"
+	"github.com/belingud/go-gptcomet/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubSleep replaces the retry sleep and records the requested delays
+func stubSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var delays []time.Duration
+	orig := sleepFunc
+	sleepFunc = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	t.Cleanup(func() { sleepFunc = orig })
+	return &delays
+}
+
+func apiError(status int, header http.Header) error {
+	return &llm.APIError{StatusCode: status, Body: http.StatusText(status), Header: header}
+}
+
+func TestChat_Retry(t *testing.T) {
+	tests := []struct {
+		name         string
+		retries      int
+		errs         []error
+		wantErr      bool
+		wantAttempts int
+	}{
+		{
+			name:         "retry on 429 then succeed",
+			retries:      3,
+			errs:         []error{apiError(429, nil), apiError(429, nil)},
+			wantAttempts: 3,
+		},
+		{
+			name:         "retry on 5xx",
+			retries:      3,
+			errs:         []error{apiError(503, nil)},
+			wantAttempts: 2,
+		},
+		{
+			name:         "retry on network error",
+			retries:      1,
+			errs:         []error{&net.OpError{Op: "dial", Err: errors.New("connection refused")}},
+			wantAttempts: 2,
+		},
+		{
+			name:         "never retry auth errors",
+			retries:      3,
+			errs:         []error{apiError(401, nil)},
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "never retry bad requests",
+			retries:      3,
+			errs:         []error{apiError(400, nil)},
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "give up after configured retries",
+			retries:      2,
+			errs:         []error{apiError(500, nil), apiError(500, nil), apiError(500, nil), apiError(500, nil)},
+			wantErr:      true,
+			wantAttempts: 3,
+		},
+		{
+			name:         "no retries configured",
+			retries:      0,
+			errs:         []error{apiError(500, nil)},
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubSleep(t)
+			attempts := 0
+			mockLLM := &MockLLM{
+				makeRequestFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+					attempts++
+					if attempts <= len(tt.errs) {
+						return "", tt.errs[attempts-1]
+					}
+					return "mock response", nil
+				},
+				name: "mock",
+			}
+
+			client := &Client{
+				config: &types.ClientConfig{Timeout: 10, Retries: tt.retries},
+				llm:    mockLLM,
+			}
+
+			resp, err := client.Chat(context.Background(), "test message", nil)
+			assert.Equal(t, tt.wantAttempts, attempts)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "mock response", resp.Content)
+		})
+	}
+}
+
+func TestChat_RetryAfter(t *testing.T) {
+	delays := stubSleep(t)
+	attempts := 0
+	mockLLM := &MockLLM{
+		makeRequestFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+			attempts++
+			if attempts == 1 {
+				return "", apiError(429, http.Header{"Retry-After": []string{"7"}})
+			}
+			return "mock response", nil
+		},
+		name: "mock",
+	}
+
+	client := &Client{
+		config: &types.ClientConfig{Timeout: 10, Retries: 2},
+		llm:    mockLLM,
+	}
+
+	_, err := client.Chat(context.Background(), "test message", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{7 * time.Second}, *delays)
+}
+
+func TestStream_NoRetryAfterOutput(t *testing.T) {
+	stubSleep(t)
+	attempts := 0
+	mockLLM := &MockLLM{
+		makeStreamFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (string, error) {
+			attempts++
+			onChunk("feat")
+			return "", apiError(502, nil)
+		},
+		name: "mock",
+	}
+
+	client := &Client{
+		config: &types.ClientConfig{Timeout: 10, Retries: 3},
+		llm:    mockLLM,
+	}
+
+	_, err := client.Stream(context.Background(), "test message", nil, nil)
+	require.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempt := 1; attempt <= 10; attempt++ {
+		d := retryDelay(attempt, errors.New("boom"))
+		backoff := retryBaseDelay << uint(attempt-1)
+		if backoff > retryMaxDelay {
+			backoff = retryMaxDelay
+		}
+		assert.GreaterOrEqual(t, d, backoff/2)
+		assert.LessOrEqual(t, d, backoff)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"empty", "", 0, false},
+		{"seconds", "120", 120 * time.Second, true},
+		{"negative", "-1", 0, false},
+		{"http date", now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{"past date", now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"garbage", "soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/belingud/go-gptcomet/internal/debug"
+	"github.com/belingud/go-gptcomet/internal/git"
+	"github.com/belingud/go-gptcomet/internal/prompt"
+	"github.com/belingud/go-gptcomet/pkg/config/defaults"
+	"github.com/belingud/go-gptcomet/pkg/types"
+)
+
+const (
+	// bytesPerToken is the rough number of bytes of source code per token
+	bytesPerToken = 4
+	// minChunkTokens keeps chunks useful when the prompt takes most of the budget
+	minChunkTokens = 512
+)
+
+var summaryConcurrency = 4 // replaceable for testing
+
+// EstimateTokens returns a rough token count of text. It errs on the large
+// side for code, which is what diffs mostly contain.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + bytesPerToken - 1) / bytesPerToken
+}
+
+// InputBudget returns the number of prompt tokens the provider accepts
+// before a diff has to be summarized in chunks: max_input_tokens if it is
+// set, else the context window of the model less the tokens reserved for
+// the answer, else the provider default
+func (c *Client) InputBudget() int {
+	if c.config.MaxInputTokens > 0 {
+		return c.config.MaxInputTokens
+	}
+	if limit, ok := defaults.ContextLimit(c.config.Model); ok && limit > c.config.MaxTokens {
+		return limit - c.config.MaxTokens
+	}
+	return types.DefaultMaxInputTokens
+}
+
+// CondenseDiff returns diff unchanged if it fits into the prompt p within
+// the input budget. Otherwise it splits diff per file and hunk, summarizes the
+// chunks in parallel with chunkPrompt and returns combinePrompt filled with
+// the summaries, to be used in place of the diff.
+func (c *Client) CondenseDiff(ctx context.Context, diff string, p, chunkPrompt, combinePrompt prompt.Prompt) (string, error) {
+	budget := c.InputBudget()
+	if EstimateTokens(p.String())+EstimateTokens(diff) <= budget {
+		return diff, nil
+	}
+
+	chunkTokens := budget - EstimateTokens(chunkPrompt.String())
+	if chunkTokens < minChunkTokens {
+		chunkTokens = minChunkTokens
+	}
+	chunks := git.SplitDiff(diff, chunkTokens*bytesPerToken)
+	fmt.Fprintf(os.Stderr, "Diff exceeds the input budget of %d tokens, summarizing it in %d chunks\n", budget, len(chunks))
+
+	summaries, err := c.summarizeChunks(ctx, chunks, chunkPrompt)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for i, summary := range summaries {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		fmt.Fprintf(&sb, "Part %d of %d:\n%s", i+1, len(summaries), summary)
+	}
+	// The summaries stand in for the diff, a system part does not belong there
+	return formatPrompt(combinePrompt.User, sb.String()), nil
+}
+
+// summarizeChunks summarizes every chunk with at most summaryConcurrency
+// requests in flight and returns the summaries in chunk order
+func (c *Client) summarizeChunks(parent context.Context, chunks []string, chunkPrompt prompt.Prompt) ([]string, error) {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		firstErr  error
+		summaries = make([]string, len(chunks))
+		sem       = make(chan struct{}, summaryConcurrency)
+	)
+
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if ctx.Err() != nil {
+				return
+			}
+			debug.Printf("Summarizing chunk %d/%d (%d bytes)", i+1, len(chunks), len(chunk))
+			message, history := newRequest(chunkPrompt, chunk)
+			resp, err := c.Chat(ctx, message, history)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to summarize chunk %d/%d: %w", i+1, len(chunks), err)
+					cancel()
+				}
+				mu.Unlock()
+				return
+			}
+			summaries[i] = strings.TrimSpace(resp.Content)
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := parent.Err(); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// newRequest fills the user part of prompt p with text. The system part of
+// p, if there is one, is returned as the history of the request.
+func newRequest(p prompt.Prompt, text string) (string, []types.Message) {
+	message := formatPrompt(p.User, text)
+	if p.System == "" {
+		return message, nil
+	}
+	return message, []types.Message{{Role: types.RoleSystem, Content: p.System}}
+}
+
+// formatPrompt fills prompt with text, either at its prompt.Placeholder
+// or, for prompts written as format strings, at its first %s. Other %
+// signs are left alone. A prompt with neither gets text appended, so that
+// the model never answers without it.
+func formatPrompt(p, text string) string {
+	switch {
+	case strings.Contains(p, prompt.Placeholder):
+		return strings.ReplaceAll(p, prompt.Placeholder, text)
+	case strings.Contains(p, "%s"):
+		return strings.Replace(p, "%s", text, 1)
+	case strings.TrimSpace(p) == "":
+		return text
+	default:
+		return strings.TrimRight(p, "\n") + "\n\n" + text
+	}
+}
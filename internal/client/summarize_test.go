@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/prompt"
+	"github.com/belingud/go-gptcomet/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// largeDiff returns a diff of n files with one hunk each
+func largeDiff(n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		name := string(rune('a'+i)) + ".go"
+		sb.WriteString("diff --git a/" + name + " b/" + name + "\n--- a/" + name + "\n+++ b/" + name + "\n@@ -1 +1 @@\n")
+		sb.WriteString("+" + strings.Repeat("x", 2000) + "\n")
+	}
+	return sb.String()
+}
+
+func TestInputBudget(t *testing.T) {
+	client := &Client{config: &types.ClientConfig{Model: "gpt-4o", MaxTokens: 1024, MaxInputTokens: 6000}}
+	assert.Equal(t, 6000, client.InputBudget())
+
+	client.config.MaxInputTokens = 0
+	assert.Equal(t, 128000-1024, client.InputBudget())
+
+	client.config.Model = "llama3"
+	assert.Equal(t, types.DefaultMaxInputTokens, client.InputBudget())
+}
+
+func TestCondenseDiff_FitsBudget(t *testing.T) {
+	client := &Client{
+		config: &types.ClientConfig{Timeout: 10, MaxInputTokens: 1000},
+		llm: &MockLLM{
+			makeRequestFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+				t.Fatal("no request expected for a small diff")
+				return "", nil
+			},
+		},
+	}
+
+	got, err := client.CondenseDiff(context.Background(), "small diff", prompt.Prompt{User: "prompt: %s"}, prompt.Prompt{User: "chunk"}, prompt.Prompt{User: "combined"})
+	require.NoError(t, err)
+	assert.Equal(t, "small diff", got)
+}
+
+func TestCondenseDiff_MapReduce(t *testing.T) {
+	var calls int32
+	client := &Client{
+		config: &types.ClientConfig{Timeout: 10, MaxInputTokens: 1000},
+		llm: &MockLLM{
+			makeRequestFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+				atomic.AddInt32(&calls, 1)
+				assert.True(t, strings.HasPrefix(message, "summarize:\ndiff --git a/"))
+				file := strings.Fields(strings.TrimPrefix(message, "summarize:\ndiff --git "))[0]
+				return "changed " + file, nil
+			},
+		},
+	}
+
+	got, err := client.CondenseDiff(context.Background(), largeDiff(4), prompt.Prompt{User: "prompt: %s"},
+		prompt.Prompt{User: "summarize:\n{{ placeholder }}"}, prompt.Prompt{User: "summaries:\n{{ placeholder }}"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+	assert.Equal(t, "summaries:\nPart 1 of 4:\nchanged a/a.go\n\nPart 2 of 4:\nchanged a/b.go\n\n"+
+		"Part 3 of 4:\nchanged a/c.go\n\nPart 4 of 4:\nchanged a/d.go", got)
+}
+
+func TestCondenseDiff_ChunkError(t *testing.T) {
+	client := &Client{
+		config: &types.ClientConfig{Timeout: 10, MaxInputTokens: 1000},
+		llm: &MockLLM{
+			makeRequestFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+				if strings.Contains(message, "c.go") {
+					return "", apiError(http.StatusBadRequest, nil)
+				}
+				return "summary", nil
+			},
+		},
+	}
+
+	_, err := client.CondenseDiff(context.Background(), largeDiff(4), prompt.Prompt{User: "%s"}, prompt.Prompt{User: prompt.Placeholder}, prompt.Prompt{User: prompt.Placeholder})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to summarize chunk 3/4")
+}
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, EstimateTokens(""))
+	assert.Equal(t, 1, EstimateTokens("abc"))
+	assert.Equal(t, 2, EstimateTokens("abcde"))
+}
+
+func TestFormatPrompt(t *testing.T) {
+	assert.Equal(t, "diff:\nx\nend", formatPrompt("diff:\n{{ placeholder }}\nend", "x"))
+	assert.Equal(t, "diff: x", formatPrompt("diff: %s", "x"))
+	assert.Equal(t, "100% of x, not %s", formatPrompt("100% of %s, not %s", "x"))
+	assert.Equal(t, "Write a commit message.\n\nx", formatPrompt("Write a commit message.\n", "x"))
+	assert.Equal(t, "x", formatPrompt("", "x"))
+	assert.Equal(t, "diff 100%: x%d", formatPrompt("diff 100%: {{ placeholder }}", "x%d"))
+}
@@ -0,0 +1,22 @@
+package client
+
+import (
+	"github.com/belingud/go-gptcomet/pkg/types"
+)
+
+// RequestUsage is the token usage of a single successful request
+type RequestUsage struct {
+	Provider string
+	Model    string
+	Usage    types.Usage
+}
+
+// TakeUsage returns the usage of all requests made since the last call and
+// forgets it, so that callers can account for every generation separately
+func (c *Client) TakeUsage() []RequestUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	usage := c.usage
+	c.usage = nil
+	return usage
+}
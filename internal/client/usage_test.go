@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/belingud/go-gptcomet/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeUsage(t *testing.T) {
+	stubSleep(t)
+	primary := newMockClient("openai", func() (string, error) {
+		return "", apiError(http.StatusTooManyRequests, nil)
+	})
+	primary.config.Model = "gpt-4o"
+	fallback := &Client{
+		config: &types.ClientConfig{Timeout: 10, Provider: "claude", Model: "claude-3-5-sonnet-latest"},
+		llm: &MockLLM{
+			makeRequestFunc: func(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+				return "feat: fallback", nil
+			},
+			usage: &types.Usage{InputTokens: 100, OutputTokens: 10, TotalTokens: 110},
+		},
+	}
+	primary.WithFallbacks(fallback)
+
+	_, err := primary.Chat(context.Background(), "first", nil)
+	require.NoError(t, err)
+	_, err = primary.Chat(context.Background(), "second", nil)
+	require.NoError(t, err)
+
+	usage := primary.TakeUsage()
+	require.Len(t, usage, 2)
+	assert.Equal(t, "claude", usage[0].Provider)
+	assert.Equal(t, "claude-3-5-sonnet-latest", usage[0].Model)
+	assert.Equal(t, 100, usage[0].Usage.InputTokens)
+
+	assert.Empty(t, primary.TakeUsage())
+}
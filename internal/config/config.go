@@ -5,22 +5,46 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/belingud/go-gptcomet/internal/lint"
+	"github.com/belingud/go-gptcomet/internal/llm"
+	"github.com/belingud/go-gptcomet/internal/prompt"
 	"github.com/belingud/go-gptcomet/pkg/config/defaults"
 	"github.com/belingud/go-gptcomet/pkg/types"
 
 	"gopkg.in/yaml.v3"
 )
 
-// Manager handles configuration management
+// Manager handles configuration management.
+//
+// The effective configuration is merged from several layers: built-in
+// defaults, the system config, the user config file, the repository config,
+// environment variables and command line overrides. Changes are always
+// saved to the user config file.
 type Manager struct {
-	config     map[string]interface{}
+	config     map[string]interface{} // effective configuration of all layers
+	user       map[string]interface{} // content of the user config file
+	origins    map[string]Origin      // layer that supplied every value of config
 	configPath string
+	options    Options
+	promptVars prompt.Vars // variables of the repository for prompt templates
+
+	profileName string   // name of the prompt profile in use
+	profile     *Profile // prompt profile in use, nil if there is none
+
+	repoWarned bool // whether the ignored keys of the repository config were reported
 }
 
-// New creates a new configuration manager
+// New creates a new configuration manager, looking for the repository
+// config from the working directory
 func New(configPath string) (*Manager, error) {
+	return Load(configPath, Options{})
+}
+
+// Load creates a new configuration manager with the layers described by opts
+func Load(configPath string, opts Options) (*Manager, error) {
 	if configPath == "" {
 		var err error
 		configPath, err = getConfigDir()
@@ -31,8 +55,9 @@ func New(configPath string) (*Manager, error) {
 	}
 
 	manager := &Manager{
-		config:     make(map[string]interface{}),
+		user:       make(map[string]interface{}),
 		configPath: configPath,
+		options:    opts,
 	}
 
 	// Create config directory if it doesn't exist
@@ -45,10 +70,13 @@ func New(configPath string) (*Manager, error) {
 		if err := manager.load(); err != nil {
 			return nil, fmt.Errorf("failed to load config: %w", err)
 		}
+		if err := manager.resolve(); err != nil {
+			return nil, err
+		}
 	} else {
 		// Initialize with default configuration
 		defaultConfig := defaultConfig()
-		manager.config = defaultConfig
+		manager.user = defaultConfig
 		if err := manager.save(); err != nil {
 			return nil, fmt.Errorf("failed to save default config: %w", err)
 		}
@@ -57,32 +85,140 @@ func New(configPath string) (*Manager, error) {
 	return manager, nil
 }
 
-// GetClientConfig retrieves the client configuration
+// GetCustomProviders returns the OpenAI-compatible provider profiles
+// declared under "custom_providers", sorted by name
+func (m *Manager) GetCustomProviders() ([]llm.CustomProfile, error) {
+	entries, ok := m.config["custom_providers"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	profiles := make([]llm.CustomProfile, 0, len(names))
+	for _, name := range names {
+		data, err := yaml.Marshal(entries[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid custom provider %s: %w", name, err)
+		}
+		var profile llm.CustomProfile
+		if err := yaml.Unmarshal(data, &profile); err != nil {
+			return nil, fmt.Errorf("invalid custom provider %s: %w", name, err)
+		}
+		profile.Name = name
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// registerCustomProviders adds the custom provider profiles to the
+// provider registry
+func (m *Manager) registerCustomProviders() error {
+	profiles, err := m.GetCustomProviders()
+	if err != nil {
+		return err
+	}
+	for _, profile := range profiles {
+		if err := llm.RegisterCustomProvider(profile); err != nil {
+			return fmt.Errorf("invalid custom provider %s: %w", profile.Name, err)
+		}
+	}
+	return nil
+}
+
+// GetClientConfig retrieves the client configuration of the current provider
 func (m *Manager) GetClientConfig() (*types.ClientConfig, error) {
 	provider, ok := m.config["provider"].(string)
 	if !ok {
 		return nil, fmt.Errorf("provider not set")
 	}
 
-	providerConfig, ok := m.config[provider].(map[string]interface{})
+	clientConfig, err := m.GetProviderConfig(provider)
+	if err != nil {
+		return nil, err
+	}
+	// Diagnostics go to stderr, stdout may be the output of the command
+	fmt.Fprintf(os.Stderr, "Discovered provider: %s, model: %s\n", provider, clientConfig.Model)
+
+	return clientConfig, nil
+}
+
+// GetFallbackProviders returns the providers to try in order when the
+// current provider fails, as configured under "fallback_providers"
+func (m *Manager) GetFallbackProviders() []string {
+	value, ok := m.Get("fallback_providers")
 	if !ok {
-		return nil, fmt.Errorf("provider config not found: %s", provider)
+		return nil
 	}
 
-	apiKey, ok := providerConfig["api_key"].(string)
-	if !ok || apiKey == "" {
-		return nil, fmt.Errorf("api_key not found for provider: %s", provider)
+	var result []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, p := range v {
+			if str, ok := p.(string); ok && str != "" {
+				result = append(result, str)
+			}
+		}
+	case string:
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// GetProviderConfig retrieves the client configuration of the given provider.
+// Settings can be overridden by GPTCOMET_<PROVIDER>_<KEY> environment
+// variables, and an api_key referencing a secret is resolved here.
+func (m *Manager) GetProviderConfig(provider string) (*types.ClientConfig, error) {
+	clientConfig, err := m.GetFallbackProviderConfig(provider)
+	if err != nil {
+		return nil, err
+	}
+	if err := clientConfig.ResolveKey(); err != nil {
+		return nil, err
 	}
+	return clientConfig, nil
+}
 
-	apiBase := types.DefaultAPIBase
-	if base, ok := providerConfig["api_base"].(string); ok {
-		apiBase = base
+// GetFallbackProviderConfig is GetProviderConfig without resolving an
+// api_key referencing a secret, which is left to the ClientConfig when the
+// provider is first used. A command referenced by the key of a fallback
+// provider only runs if the fallback is tried.
+func (m *Manager) GetFallbackProviderConfig(provider string) (*types.ClientConfig, error) {
+	providerConfig, err := m.providerSettings(provider)
+	if err != nil {
+		return nil, err
 	}
 
-	model := types.DefaultModel
-	if m, ok := providerConfig["model"].(string); ok {
-		model = m
+	apiKey, _ := providerConfig["api_key"].(string)
+	if apiKey == "" && requiresAPIKey(provider) {
+		return nil, fmt.Errorf("api_key not found for provider: %s", provider)
 	}
+	var resolveAPIKey func() (string, error)
+	if isSecretRef(apiKey) {
+		if err := m.checkSecretOrigin(provider + ".api_key"); err != nil {
+			return nil, err
+		}
+		ref := apiKey
+		apiKey = ""
+		resolveAPIKey = func() (string, error) {
+			secret, err := resolveSecret(ref)
+			if err != nil {
+				return "", fmt.Errorf("failed to resolve api_key for provider %s: %w", provider, err)
+			}
+			return secret, nil
+		}
+	}
+
+	// Leave api_base and model empty when not configured, the provider
+	// fills in its own defaults
+	apiBase, _ := providerConfig["api_base"].(string)
+	model, _ := providerConfig["model"].(string)
 
 	proxy := ""
 	if p, ok := providerConfig["proxy"].(string); ok {
@@ -90,26 +226,24 @@ func (m *Manager) GetClientConfig() (*types.ClientConfig, error) {
 	}
 
 	maxTokens := types.DefaultMaxTokens
-	if m, ok := providerConfig["max_tokens"].(float64); ok {
-		maxTokens = int(m)
+	if m, ok := toInt(providerConfig["max_tokens"]); ok {
+		maxTokens = m
 	}
 
 	topP := types.DefaultTopP
-	if m, ok := providerConfig["top_p"].(float64); ok {
+	if m, ok := toFloat(providerConfig["top_p"]); ok {
 		topP = m
 	}
 
 	temperature := types.DefaultTemperature
-	if m, ok := providerConfig["temperature"].(float64); ok {
+	if m, ok := toFloat(providerConfig["temperature"]); ok {
 		temperature = m
 	}
 
 	frequencyPenalty := types.DefaultFrequencyPenalty
-	if m, ok := providerConfig["frequency_penalty"].(float64); ok {
+	if m, ok := toFloat(providerConfig["frequency_penalty"]); ok {
 		frequencyPenalty = m
 	}
-	fmt.Printf("Discovered provider: %s, model: %s\n", provider, model)
-
 	clientConfig := &types.ClientConfig{
 		APIBase:          apiBase,
 		APIKey:           apiKey,
@@ -121,9 +255,14 @@ func (m *Manager) GetClientConfig() (*types.ClientConfig, error) {
 		TopP:             topP,
 		Temperature:      temperature,
 		FrequencyPenalty: frequencyPenalty,
+		ResolveAPIKey:    resolveAPIKey,
 	}
-	if m, ok := providerConfig["retries"].(float64); ok {
-		clientConfig.Retries = int(m)
+	if retries, ok := toInt(providerConfig["retries"]); ok {
+		clientConfig.Retries = retries
+	}
+
+	if maxInputTokens, ok := toInt(providerConfig["max_input_tokens"]); ok && maxInputTokens > 0 {
+		clientConfig.MaxInputTokens = maxInputTokens
 	}
 
 	if answerPath, ok := providerConfig["answer_path"].(string); ok {
@@ -136,21 +275,111 @@ func (m *Manager) GetClientConfig() (*types.ClientConfig, error) {
 	return clientConfig, nil
 }
 
-// SetProvider sets the provider configuration
-func (m *Manager) SetProvider(provider, apiKey, apiBase, model string) error {
-	if apiBase == "" {
-		apiBase = types.DefaultAPIBase
+// providerSettings returns the settings of provider in the effective
+// configuration, which includes the GPTCOMET_<PROVIDER>_<KEY> environment
+// overrides
+func (m *Manager) providerSettings(provider string) (map[string]interface{}, error) {
+	settings, ok := m.config[provider].(map[string]interface{})
+	if !ok {
+		// Custom providers work without settings of their own
+		if _, custom := m.getNestedValue([]string{"custom_providers", provider}); !custom {
+			return nil, fmt.Errorf("provider config not found: %s", provider)
+		}
+		settings = map[string]interface{}{}
 	}
-	if model == "" {
-		model = types.DefaultModel
+	return settings, nil
+}
+
+// GetModelPrice returns the price of model in USD per million tokens as
+// configured under "pricing.<model>", falling back to the built-in prices.
+// It reports false if the price of the model is unknown.
+func (m *Manager) GetModelPrice(model string) (types.ModelPrice, bool) {
+	if pricing, ok := m.config["pricing"].(map[string]interface{}); ok {
+		if entry, ok := pricing[model].(map[string]interface{}); ok {
+			var price types.ModelPrice
+			price.Input, _ = toFloat(entry["input"])
+			price.Output, _ = toFloat(entry["output"])
+			price.Cached, _ = toFloat(entry["cached"])
+			return price, true
+		}
 	}
 
-	m.config[provider] = map[string]interface{}{
+	price, ok := defaults.PriceDefaults[model]
+	return price, ok
+}
+
+// GetUsageLedgerPath returns the path of the usage ledger, which is kept
+// next to the config file
+func (m *Manager) GetUsageLedgerPath() string {
+	return filepath.Join(filepath.Dir(m.configPath), "usage.jsonl")
+}
+
+// requiresAPIKey reports whether provider refuses requests without an
+// api_key. Providers missing from the registry are assumed to need one.
+func requiresAPIKey(provider string) bool {
+	info, ok := llm.GetProviderInfo(provider)
+	return !ok || info.Capabilities.RequiresAPIKey
+}
+
+// toFloat converts a numeric config value to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, false
+		}
+		return f, true
+	default:
+		return 0, false
+	}
+}
+
+// toInt converts a numeric config value to int. YAML decodes integers as
+// int, JSON and interactive input produce float64 and string values.
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, false
+		}
+		return i, true
+	default:
+		return 0, false
+	}
+}
+
+// SetProvider sets the provider configuration. Empty api_base and model
+// values are replaced by the provider's registered defaults.
+func (m *Manager) SetProvider(provider, apiKey, apiBase, model string) error {
+	if info, ok := llm.GetProviderInfo(provider); ok {
+		if apiBase == "" {
+			apiBase = info.Defaults["api_base"]
+		}
+		if model == "" {
+			model = info.Defaults["model"]
+		}
+	}
+
+	m.user[provider] = map[string]interface{}{
 		"api_key":  apiKey,
 		"api_base": apiBase,
 		"model":    model,
 	}
-	m.config["provider"] = provider
+	m.user["provider"] = provider
 
 	return m.save()
 }
@@ -170,8 +399,7 @@ func (m *Manager) Set(key string, value interface{}) error {
 		}
 	}
 
-	keys := strings.Split(key, ".")
-	m.setNestedValue(keys, value)
+	setNestedValue(m.user, strings.Split(key, "."), value)
 	return m.save()
 }
 
@@ -194,11 +422,11 @@ func (m *Manager) Reset(promptOnly bool) error {
 		// Get default prompt config
 		defaultCfg := defaultConfig()
 		if promptConfig, ok := defaultCfg["prompt"].(map[string]interface{}); ok {
-			m.config["prompt"] = promptConfig
+			m.user["prompt"] = promptConfig
 		}
 	} else {
 		// Reset all config
-		m.config = defaultConfig()
+		m.user = defaultConfig()
 	}
 	return m.save()
 }
@@ -209,7 +437,7 @@ func (m *Manager) Remove(key string, value string) error {
 	if value == "" {
 		// If no value is provided, remove the entire key
 		lastKey := keys[len(keys)-1]
-		parent, ok := m.getNestedValue(keys[:len(keys)-1])
+		parent, ok := nestedValue(m.user, keys[:len(keys)-1])
 		if !ok {
 			return nil
 		}
@@ -221,7 +449,7 @@ func (m *Manager) Remove(key string, value string) error {
 	}
 
 	// If value is provided, try to remove it from a list
-	current, ok := m.getNestedValue(keys)
+	current, ok := m.listValue(keys)
 	if !ok {
 		return nil
 	}
@@ -253,7 +481,7 @@ func (m *Manager) GetPath() string {
 // Append appends a value to a list configuration
 func (m *Manager) Append(key string, value interface{}) error {
 	keys := strings.Split(key, ".")
-	current, ok := m.getNestedValue(keys)
+	current, ok := m.listValue(keys)
 	if !ok {
 		// If the key doesn't exist, create a new list
 		return m.Set(key, []interface{}{value})
@@ -270,9 +498,25 @@ func (m *Manager) Append(key string, value interface{}) error {
 	return m.Set(key, list)
 }
 
-// getNestedValue retrieves a nested configuration value
+// getNestedValue retrieves a nested value of the effective configuration
 func (m *Manager) getNestedValue(keys []string) (interface{}, bool) {
-	current := interface{}(m.config)
+	return nestedValue(m.config, keys)
+}
+
+// listValue returns the list at keys to be changed, preferring the user
+// config over the effective configuration so values of other layers are
+// only copied when the user config has none
+func (m *Manager) listValue(keys []string) (interface{}, bool) {
+	if value, ok := nestedValue(m.user, keys); ok {
+		return copyValue(value), true
+	}
+	value, ok := m.getNestedValue(keys)
+	return copyValue(value), ok
+}
+
+// nestedValue retrieves a nested value of data
+func nestedValue(data map[string]interface{}, keys []string) (interface{}, bool) {
+	current := interface{}(data)
 	for _, key := range keys {
 		currentMap, ok := current.(map[string]interface{})
 		if !ok {
@@ -286,9 +530,9 @@ func (m *Manager) getNestedValue(keys []string) (interface{}, bool) {
 	return current, true
 }
 
-// setNestedValue sets a nested configuration value
-func (m *Manager) setNestedValue(keys []string, value interface{}) {
-	current := m.config
+// setNestedValue sets a nested value of data
+func setNestedValue(data map[string]interface{}, keys []string, value interface{}) {
+	current := data
 	for _, key := range keys[:len(keys)-1] {
 		next, ok := current[key]
 		if !ok {
@@ -313,16 +557,17 @@ func (m *Manager) load() error {
 		return fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	if err := yaml.Unmarshal(data, &m.config); err != nil {
+	if err := yaml.Unmarshal(data, &m.user); err != nil {
 		return fmt.Errorf("failed to parse config file: %w", err)
 	}
 
 	return nil
 }
 
-// save writes the configuration to file
+// save writes the user configuration to file and updates the effective
+// configuration
 func (m *Manager) save() error {
-	data, err := yaml.Marshal(m.config)
+	data, err := yaml.Marshal(m.user)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -331,7 +576,7 @@ func (m *Manager) save() error {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
-	return nil
+	return m.resolve()
 }
 
 // getConfigDir returns the configuration directory path
@@ -403,10 +648,25 @@ func defaultConfig() map[string]interface{} {
 			"completion_path":   "/v1/messages",
 			"answer_path":       "content.0.text",
 		},
-		"prompt": defaults.PromptDefaults,
+		"prompt": defaultPrompts(),
 	}
 }
 
+// defaultPrompts returns the default prompt section, the prompts and the
+// built-in profiles
+func defaultPrompts() map[string]interface{} {
+	prompts := make(map[string]interface{}, len(defaults.PromptDefaults)+1)
+	for key, value := range defaults.PromptDefaults {
+		prompts[key] = value
+	}
+	profiles := make(map[string]interface{}, len(defaults.ProfileDefaults))
+	for name, profile := range defaults.ProfileDefaults {
+		profiles[name] = profile
+	}
+	prompts["profiles"] = profiles
+	return prompts
+}
+
 // OutputLanguageMap maps language codes to their names
 var OutputLanguageMap = map[string]string{
 	"en":    "English",
@@ -475,7 +735,9 @@ func (m *Manager) GetSupportedKeys() []string {
 
 	// Root level keys
 	keys["provider"] = true
+	keys["fallback_providers"] = true
 	keys["file_ignore"] = true
+	keys["pricing"] = true
 
 	// Output keys
 	outputKeys := []string{
@@ -494,6 +756,26 @@ func (m *Manager) GetSupportedKeys() []string {
 		keys["console."+key] = true
 	}
 
+	// Lint keys
+	for _, key := range lintKeys {
+		keys["lint."+key] = true
+	}
+
+	// Scope keys
+	for _, key := range scopeKeys {
+		keys["scope."+key] = true
+	}
+
+	// History keys
+	for _, key := range historyKeys {
+		keys["history."+key] = true
+	}
+
+	// Issue keys
+	for _, key := range issueKeys {
+		keys["issue."+key] = true
+	}
+
 	// Provider keys
 	providerKeys := []string{
 		"api_base",
@@ -502,6 +784,7 @@ func (m *Manager) GetSupportedKeys() []string {
 		"retries",
 		"proxy",
 		"max_tokens",
+		"max_input_tokens",
 		"top_p",
 		"temperature",
 		"frequency_penalty",
@@ -513,15 +796,46 @@ func (m *Manager) GetSupportedKeys() []string {
 		keys["<provider>."+key] = true
 	}
 
+	// Custom provider profile keys
+	customProviderKeys := []string{
+		"display_name",
+		"api_base",
+		"completion_path",
+		"model",
+		"auth",
+		"auth_header",
+		"auth_param",
+		"answer_path",
+		"stream_path",
+		"usage_paths",
+		"fields",
+	}
+	for _, key := range customProviderKeys {
+		keys["custom_providers.<name>."+key] = true
+	}
+
 	// Prompt keys
 	promptKeys := []string{
 		"brief_commit_message",
 		"rich_commit_message",
 		"translation",
+		"summarize_chunk",
+		"combine_summaries",
+		"repair_commit_message",
+		"split_commits",
+		"pull_request",
+		"changelog",
+		"review",
+		"explain_commit",
+		"explain_file",
 	}
 	for _, key := range promptKeys {
 		keys["prompt."+key] = true
 	}
+	keys["prompt.profile"] = true
+	for _, key := range profileKeys {
+		keys["prompt.profiles.<name>."+key] = true
+	}
 
 	// Convert map to sorted slice
 	result := make([]string, 0, len(keys))
@@ -536,48 +850,171 @@ func (m *Manager) GetSupportedKeys() []string {
 	return result
 }
 
-// GetPrompt retrieves the prompt configuration
-func (m *Manager) GetPrompt(isRich bool) string {
-	promptConfig, ok := m.config["prompt"].(map[string]interface{})
-	if !ok {
-		// return default prompt if not set in config
-		if isRich {
-			return defaults.PromptDefaults["rich_commit_message"]
-		} else {
-			return defaults.PromptDefaults["brief_commit_message"]
+// SetPromptVars sets the variables of the repository the prompt templates
+// are rendered with. The output language and the rich template are taken
+// from the configuration.
+func (m *Manager) SetPromptVars(vars prompt.Vars) {
+	m.promptVars = vars
+}
+
+// GetPrompt retrieves the prompt configuration. The prompt profile in use
+// may replace it, choose the rich prompt and replace its system part. The types
+// and scopes allowed by the lint rules are added to it when they are not
+// the defaults, and the ticket key when the branch name has one.
+func (m *Manager) GetPrompt(isRich bool) (prompt.Prompt, error) {
+	profile := m.profile
+	if profile != nil && profile.Rich() {
+		isRich = true
+	}
+	key := "brief_commit_message"
+	if isRich {
+		key = "rich_commit_message"
+	}
+
+	var p prompt.Prompt
+	var err error
+	if profile != nil && profile.Template != "" {
+		p, err = m.render("prompt.profiles."+profile.Name+".template", profile.Template)
+	} else {
+		p, err = m.renderPrompt(key)
+	}
+	if err != nil {
+		return prompt.Prompt{}, err
+	}
+	if profile != nil && profile.System != "" {
+		system, err := m.render("prompt.profiles."+profile.Name+".system", profile.System)
+		if err != nil {
+			return prompt.Prompt{}, err
 		}
+		p.System = strings.TrimSpace(system.User)
+		p.User = strings.TrimLeft(p.User, "\n")
+	}
+	p, err = m.withIssueInstructions(p)
+	if err != nil {
+		return prompt.Prompt{}, err
 	}
+	return m.withLintInstructions(p), nil
+}
 
-	if isRich {
-		if rich, ok := promptConfig["rich_commit_message"].(string); ok {
-			return rich
+// withLintInstructions adds the lint instructions to p
+func (m *Manager) withLintInstructions(p prompt.Prompt) prompt.Prompt {
+	rules, err := m.GetLintRules()
+	if err != nil || !rules.Enabled {
+		return p
+	}
+	if !rules.RequireScope && len(rules.Scopes) == 0 && equalStrings(rules.Types, lint.DefaultTypes) {
+		return p
+	}
+
+	return lint.InsertInstructions(p, lint.Instructions(rules))
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
 		}
-	} else {
-		if brief, ok := promptConfig["brief_commit_message"].(string); ok {
-			return brief
+	}
+	return true
+}
+
+// GetTranslationPrompt retrieves the translation prompt configuration
+func (m *Manager) GetTranslationPrompt() (prompt.Prompt, error) {
+	return m.renderPrompt("translation")
+}
+
+// GetSummarizeChunkPrompt retrieves the prompt used to summarize one chunk
+// of a diff that is too large for a single request
+func (m *Manager) GetSummarizeChunkPrompt() (prompt.Prompt, error) {
+	return m.renderPrompt("summarize_chunk")
+}
+
+// GetCombineSummariesPrompt retrieves the prompt that presents the chunk
+// summaries in place of the diff
+func (m *Manager) GetCombineSummariesPrompt() (prompt.Prompt, error) {
+	return m.renderPrompt("combine_summaries")
+}
+
+// GetRepairPrompt retrieves the prompt asking the model to fix the lint
+// violations of a generated commit message
+func (m *Manager) GetRepairPrompt() (prompt.Prompt, error) {
+	return m.renderPrompt("repair_commit_message")
+}
+
+// GetSplitPrompt retrieves the prompt asking the model to group the staged
+// hunks into commits
+func (m *Manager) GetSplitPrompt() (prompt.Prompt, error) {
+	return m.renderPrompt("split_commits")
+}
+
+// GetPullRequestPrompt retrieves the prompt for pull request titles and
+// descriptions
+func (m *Manager) GetPullRequestPrompt() (prompt.Prompt, error) {
+	return m.renderPrompt("pull_request")
+}
+
+// GetReviewPrompt retrieves the prompt for reviewing the staged diff
+func (m *Manager) GetReviewPrompt() (prompt.Prompt, error) {
+	return m.renderPrompt("review")
+}
+
+// GetChangelogPrompt retrieves the prompt for release notes
+func (m *Manager) GetChangelogPrompt() (prompt.Prompt, error) {
+	return m.renderPrompt("changelog")
+}
+
+// GetExplainPrompt retrieves the prompt explaining commits, or a file if
+// file is true
+func (m *Manager) GetExplainPrompt(file bool) (prompt.Prompt, error) {
+	if file {
+		return m.renderPrompt("explain_file")
+	}
+	return m.renderPrompt("explain_commit")
+}
+
+// renderPrompt renders the prompt template prompt.<key>
+func (m *Manager) renderPrompt(key string) (prompt.Prompt, error) {
+	return m.render("prompt."+key, m.getPromptOrDefault(key))
+}
+
+// render renders the prompt template tmpl configured under key with the
+// prompt variables, the output language and the rich template
+func (m *Manager) render(key, tmpl string) (prompt.Prompt, error) {
+	vars := m.promptVars
+	vars.Lang = OutputLanguageMap["en"]
+	if lang, ok := m.Get("output.lang"); ok {
+		vars.Lang = fmt.Sprint(lang)
+		if name, ok := OutputLanguageMap[vars.Lang]; ok {
+			vars.Lang = name
 		}
 	}
+	if richTemplate, ok := m.Get("output.rich_template"); ok {
+		vars.RichTemplate = fmt.Sprint(richTemplate)
+	}
 
-	// return default prompt if not set in config
-	if isRich {
-		return defaults.PromptDefaults["rich_commit_message"]
-	} else {
-		return defaults.PromptDefaults["brief_commit_message"]
+	rendered, err := prompt.Render(tmpl, vars)
+	if err != nil {
+		return prompt.Prompt{}, fmt.Errorf("%s: %w", key, err)
 	}
+	return rendered, nil
 }
 
-// GetTranslationPrompt retrieves the translation prompt
-func (m *Manager) GetTranslationPrompt() string {
+// getPromptOrDefault returns the prompt configured under prompt.<key>, or
+// the built-in default when it is not set
+func (m *Manager) getPromptOrDefault(key string) string {
 	promptConfig, ok := m.config["prompt"].(map[string]interface{})
 	if !ok {
 		// return default prompt if not set in config
-		return defaults.PromptDefaults["translation"]
+		return defaults.PromptDefaults[key]
 	}
-	if translation, ok := promptConfig["translation"].(string); ok {
-		return translation
+	if prompt, ok := promptConfig[key].(string); ok {
+		return prompt
 	}
 	// return default prompt if not set in config
-	return defaults.PromptDefaults["translation"]
+	return defaults.PromptDefaults[key]
 }
 
 // MaskAPIKey masks an API key by showing only the first few characters and replacing the rest with asterisks
@@ -602,12 +1039,13 @@ func MaskAPIKey(apiKey string, showFirst int) string {
 	return apiKey[:showFirst] + strings.Repeat("*", len(apiKey)-showFirst)
 }
 
-// MaskConfigAPIKeys recursively masks all API keys in a map
+// MaskConfigAPIKeys recursively masks all API keys in a map. References to
+// secrets (env:, file:, cmd:) are not secret themselves and left as is.
 func MaskConfigAPIKeys(data map[string]interface{}) {
 	for key, value := range data {
 		switch v := value.(type) {
 		case string:
-			if key == "api_key" {
+			if key == "api_key" && !isSecretRef(v) {
 				data[key] = MaskAPIKey(v, 3)
 			}
 		case map[string]interface{}:
@@ -616,6 +1054,16 @@ func MaskConfigAPIKeys(data map[string]interface{}) {
 	}
 }
 
+// MaskValue masks the value of the dotted key like MaskConfigAPIKeys: the
+// value itself if key is an api_key, or the API keys below it if it is
+// a section
+func MaskValue(key string, value interface{}) interface{} {
+	name := key[strings.LastIndex(key, ".")+1:]
+	wrapped := map[string]interface{}{name: value}
+	MaskConfigAPIKeys(wrapped)
+	return wrapped[name]
+}
+
 // List returns the configuration as a string with masked API keys
 func (m *Manager) List() (string, error) {
 	// Get config without prompt section
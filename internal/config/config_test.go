@@ -3,7 +3,10 @@ package config
 import (
 	"testing"
 
+	"github.com/belingud/go-gptcomet/internal/llm"
+	"github.com/belingud/go-gptcomet/internal/prompt"
 	"github.com/belingud/go-gptcomet/internal/testutils"
+	"github.com/belingud/go-gptcomet/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -113,3 +116,306 @@ func TestConfig_Set(t *testing.T) {
 		})
 	}
 }
+
+func TestGetClientConfig_Retries(t *testing.T) {
+	tests := []struct {
+		name       string
+		configData string
+		want       int
+	}{
+		{
+			name: "yaml integer",
+			configData: `
+provider: openai
+openai:
+  api_key: test-key
+  retries: 5
+`,
+			want: 5,
+		},
+		{
+			name: "string value",
+			configData: `
+provider: openai
+openai:
+  api_key: test-key
+  retries: "1"
+`,
+			want: 1,
+		},
+		{
+			name: "default",
+			configData: `
+provider: openai
+openai:
+  api_key: test-key
+`,
+			want: types.DefaultRetries,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile, cleanup := testutils.TestConfig(t, tt.configData)
+			defer cleanup()
+
+			cfg, err := New(configFile)
+			require.NoError(t, err)
+
+			clientConfig, err := cfg.GetClientConfig()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, clientConfig.Retries)
+		})
+	}
+}
+
+func TestGetFallbackProviders(t *testing.T) {
+	tests := []struct {
+		name       string
+		configData string
+		want       []string
+	}{
+		{
+			name: "list",
+			configData: `
+provider: openai
+fallback_providers:
+  - claude
+  - ollama
+`,
+			want: []string{"claude", "ollama"},
+		},
+		{
+			name: "single string",
+			configData: `
+provider: openai
+fallback_providers: claude
+`,
+			want: []string{"claude"},
+		},
+		{
+			name: "not configured",
+			configData: `
+provider: openai
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile, cleanup := testutils.TestConfig(t, tt.configData)
+			defer cleanup()
+
+			cfg, err := New(configFile)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.GetFallbackProviders())
+		})
+	}
+}
+
+func TestGetProviderConfig(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+openai:
+  api_key: test-key
+claude:
+  model: claude-3-5-sonnet
+deepseek:
+  api_key: test-key
+ollama:
+  api_base: http://localhost:11434/api
+  model: llama3
+`)
+	defer cleanup()
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+
+	_, err = cfg.GetProviderConfig("claude")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "api_key not found")
+
+	_, err = cfg.GetProviderConfig("missing")
+	require.Error(t, err)
+
+	ollama, err := cfg.GetProviderConfig("ollama")
+	require.NoError(t, err)
+	assert.Equal(t, "ollama", ollama.Provider)
+	assert.Equal(t, "llama3", ollama.Model)
+
+	// Unset settings are left for the provider to default, not OpenAI's
+	deepseek, err := cfg.GetProviderConfig("deepseek")
+	require.NoError(t, err)
+	assert.Empty(t, deepseek.APIBase)
+	assert.Empty(t, deepseek.Model)
+}
+
+func TestGetClientConfig_MaxInputTokens(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: ollama
+ollama:
+  model: llama3
+  max_input_tokens: 6000
+openai:
+  api_key: test-key
+`)
+	defer cleanup()
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+
+	ollama, err := cfg.GetProviderConfig("ollama")
+	require.NoError(t, err)
+	assert.Equal(t, 6000, ollama.MaxInputTokens)
+
+	openai, err := cfg.GetProviderConfig("openai")
+	require.NoError(t, err)
+	assert.Zero(t, openai.MaxInputTokens, "left to the model defaults")
+}
+
+func TestGetModelPrice(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+pricing:
+  gpt-4o:
+    input: 2
+    output: 8
+  my-model:
+    input: "0.5"
+    output: 1.5
+    cached: 0.1
+`)
+	defer cleanup()
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+
+	price, ok := cfg.GetModelPrice("gpt-4o")
+	require.True(t, ok)
+	assert.Equal(t, types.ModelPrice{Input: 2, Output: 8}, price)
+
+	price, ok = cfg.GetModelPrice("my-model")
+	require.True(t, ok)
+	assert.Equal(t, types.ModelPrice{Input: 0.5, Output: 1.5, Cached: 0.1}, price)
+
+	// Built-in prices are used for models without an entry
+	_, ok = cfg.GetModelPrice("gpt-4o-mini")
+	assert.True(t, ok)
+
+	_, ok = cfg.GetModelPrice("unknown-model")
+	assert.False(t, ok)
+}
+
+func TestPromptsWithOutputLanguage(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+output:
+  lang: de
+  rich_template: "<type>: <subject>"
+`)
+	defer cleanup()
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+
+	p, err := cfg.GetChangelogPrompt()
+	require.NoError(t, err)
+	assert.Contains(t, p.String(), "release notes in German")
+	assert.Contains(t, p.String(), prompt.Placeholder)
+	assert.NotContains(t, p.String(), "{{ output.lang }}")
+
+	p, err = cfg.GetExplainPrompt(false)
+	require.NoError(t, err)
+	assert.Contains(t, p.String(), "what the commits below did")
+	assert.Contains(t, p.String(), "explanation in German")
+	p, err = cfg.GetExplainPrompt(true)
+	require.NoError(t, err)
+	assert.Contains(t, p.String(), "what the file below is for")
+	assert.Contains(t, p.String(), "explanation in German")
+
+	p, err = cfg.GetPrompt(true)
+	require.NoError(t, err)
+	assert.Contains(t, p.String(), "The commit message template is <type>: <subject>.")
+	p, err = cfg.GetTranslationPrompt()
+	require.NoError(t, err)
+	assert.Contains(t, p.String(), "Translate the following message into German.")
+}
+
+func TestPromptVars(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+prompt:
+  brief_commit_message: "Commit to {{ .Repo }} on {{ .Branch }} touching {{ len .Files }} files in {{ .Lang }}:\n{{ .Diff }}"
+  translation: "Translate to {{ .Language }}: {{ placeholder }}"
+`)
+	defer cleanup()
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+	cfg.SetPromptVars(prompt.Vars{Repo: "gptcomet", Branch: "main", Files: []string{"a.go", "b.go"}})
+
+	p, err := cfg.GetPrompt(false)
+	require.NoError(t, err)
+	assert.Equal(t, prompt.Prompt{User: "Commit to gptcomet on main touching 2 files in English:\n" + prompt.Placeholder}, p)
+
+	_, err = cfg.GetTranslationPrompt()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "prompt.translation: unknown variable .Language")
+}
+
+func TestCustomProviders(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: vllm
+custom_providers:
+  vllm:
+    api_base: http://localhost:8000/v1
+    model: qwen2.5-coder
+    auth: none
+  openrouter:
+    api_base: https://openrouter.ai/api/v1
+    auth: header
+    auth_header: X-Key
+    fields:
+      max_tokens: max_completion_tokens
+openrouter:
+  api_key: test-key
+  model: deepseek/deepseek-chat
+`)
+	defer cleanup()
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+
+	profiles, err := cfg.GetCustomProviders()
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	assert.Equal(t, "openrouter", profiles[0].Name)
+	assert.Equal(t, "X-Key", profiles[0].AuthHeader)
+	assert.Equal(t, map[string]string{"max_tokens": "max_completion_tokens"}, profiles[0].Fields)
+	assert.Contains(t, llm.GetProviders(), "vllm")
+
+	// Keyless profiles work without a settings block of their own
+	vllm, err := cfg.GetClientConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "vllm", vllm.Provider)
+	assert.Empty(t, vllm.APIBase)
+
+	openrouter, err := cfg.GetProviderConfig("openrouter")
+	require.NoError(t, err)
+	assert.Equal(t, "test-key", openrouter.APIKey)
+	assert.Equal(t, "deepseek/deepseek-chat", openrouter.Model)
+}
+
+func TestCustomProviders_Invalid(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `
+custom_providers:
+  broken:
+    api_base: http://gw
+    auth: query
+`)
+	defer cleanup()
+
+	_, err := New(configFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid custom provider broken")
+}
@@ -0,0 +1,40 @@
+package config
+
+import "fmt"
+
+// historyKeys are the settings of the "history" section
+var historyKeys = []string{
+	"count",
+	"touched_paths",
+}
+
+// HistorySettings controls which recent commits are shown to the model as
+// examples of the commit message style of the repository
+type HistorySettings struct {
+	// Count is the number of commits, 0 disables the examples
+	Count int
+	// TouchedPaths keeps only the commits that changed one of the files
+	// of the commit being written
+	TouchedPaths bool
+}
+
+// GetHistorySettings returns the settings of the "history" section
+func (m *Manager) GetHistorySettings() (HistorySettings, error) {
+	var settings HistorySettings
+	section, ok := m.config["history"].(map[string]interface{})
+	if !ok {
+		return settings, nil
+	}
+
+	if value, ok := section["count"]; ok {
+		count, ok := toInt(value)
+		if !ok || count < 0 {
+			return settings, fmt.Errorf("invalid history.count %v, expected a number of commits", value)
+		}
+		settings.Count = count
+	}
+	if touchedPaths, ok := toBool(section["touched_paths"]); ok {
+		settings.TouchedPaths = touchedPaths
+	}
+	return settings, nil
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHistorySettings(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+history:
+  count: 5
+  touched_paths: true
+`)
+	defer cleanup()
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+	settings, err := cfg.GetHistorySettings()
+	require.NoError(t, err)
+	assert.Equal(t, HistorySettings{Count: 5, TouchedPaths: true}, settings)
+
+	cfg, err = Load(configFile, Options{
+		RepoPath:  t.TempDir(),
+		Overrides: map[string]string{"history.count": "2", "history.touched_paths": "false"},
+	})
+	require.NoError(t, err)
+	settings, err = cfg.GetHistorySettings()
+	require.NoError(t, err)
+	assert.Equal(t, HistorySettings{Count: 2}, settings)
+
+	cfg, err = New(configFile)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Set("history.count", -1))
+	_, err = cfg.GetHistorySettings()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid history.count")
+}
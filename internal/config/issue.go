@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/belingud/go-gptcomet/internal/issue"
+	"github.com/belingud/go-gptcomet/internal/lint"
+	"github.com/belingud/go-gptcomet/internal/prompt"
+)
+
+// issueKeys are the settings of the "issue" section
+var issueKeys = []string{
+	"pattern",
+	"placement",
+	"trailer",
+}
+
+// GetIssueSettings returns how the ticket key is found in the branch name
+// and placed in the commit message, configured by the "issue" section.
+// Without "issue.pattern" no key is looked for.
+func (m *Manager) GetIssueSettings() (issue.Settings, error) {
+	settings := issue.DefaultSettings()
+	section, ok := m.config["issue"].(map[string]interface{})
+	if !ok {
+		return settings, nil
+	}
+
+	if value, ok := section["pattern"]; ok && value != nil {
+		if pattern := fmt.Sprint(value); pattern != "" {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return settings, fmt.Errorf("invalid issue.pattern: %w", err)
+			}
+			settings.Pattern = re
+		}
+	}
+	if value, ok := section["placement"]; ok && value != nil {
+		placement := strings.TrimSpace(fmt.Sprint(value))
+		if !issue.ValidPlacement(placement) {
+			return settings, fmt.Errorf("invalid issue.placement %q, expected one of %s", placement, strings.Join(issue.Placements, ", "))
+		}
+		settings.Placement = placement
+	}
+	if value, ok := section["trailer"]; ok && value != nil {
+		if trailer := strings.TrimSpace(fmt.Sprint(value)); trailer != "" {
+			settings.Trailer = trailer
+		}
+	}
+	return settings, nil
+}
+
+// withIssueInstructions asks the model to reference the ticket key of the
+// prompt variables in p
+func (m *Manager) withIssueInstructions(p prompt.Prompt) (prompt.Prompt, error) {
+	if m.promptVars.IssueKey == "" {
+		return p, nil
+	}
+	settings, err := m.GetIssueSettings()
+	if err != nil {
+		return prompt.Prompt{}, err
+	}
+	return lint.InsertInstructions(p, settings.Instructions(m.promptVars.IssueKey)), nil
+}
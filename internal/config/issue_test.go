@@ -0,0 +1,50 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/issue"
+	"github.com/belingud/go-gptcomet/internal/prompt"
+	"github.com/belingud/go-gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetIssueSettings(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+issue:
+  pattern: '[A-Z]+-\d+'
+  placement: footer
+prompt:
+  brief_commit_message: "Write a message.\n\n{{ .Diff }}"
+`)
+	defer cleanup()
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+	settings, err := cfg.GetIssueSettings()
+	require.NoError(t, err)
+	assert.Equal(t, issue.PlacementFooter, settings.Placement)
+	assert.Equal(t, "PROJ-1234", settings.Find("feature/PROJ-1234-add-cache"))
+
+	cfg.SetPromptVars(prompt.Vars{IssueKey: "PROJ-1234"})
+	p, err := cfg.GetPrompt(false)
+	require.NoError(t, err)
+	assert.Equal(t, prompt.Prompt{User: "Write a message.\n\n" + settings.Instructions("PROJ-1234") + "\n\n" + prompt.Placeholder}, p)
+	rules, err := cfg.GetLintRules()
+	require.NoError(t, err)
+	assert.Equal(t, "PROJ-1234", rules.IssueKey)
+	assert.Equal(t, "Refs", rules.Issue.Token())
+
+	require.NoError(t, cfg.Set("issue.placement", "suffix"))
+	_, err = cfg.GetIssueSettings()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid issue.placement")
+
+	require.NoError(t, cfg.Set("issue.placement", "prefix"))
+	require.NoError(t, cfg.Set("issue.pattern", "("))
+	_, err = cfg.GetIssueSettings()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid issue.pattern")
+}
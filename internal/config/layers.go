@@ -0,0 +1,433 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/belingud/go-gptcomet/internal/llm"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Layer names, from the lowest to the highest precedence
+const (
+	LayerDefault = "default"
+	LayerSystem  = "system"
+	LayerUser    = "user"
+	LayerRepo    = "repo"
+	LayerProfile = "profile"
+	LayerEnv     = "env"
+	LayerFlag    = "flag"
+)
+
+// RepoConfigName is the name of the config file checked into a repository
+const RepoConfigName = ".gptcomet.yaml"
+
+var (
+	// systemConfigPath is the config shared by all users of the machine,
+	// replaceable for testing
+	systemConfigPath = "/etc/gptcomet/gptcomet.yaml"
+
+	// envKeys are the settings outside of provider sections that can be
+	// set by GPTCOMET_<KEY> environment variables, e.g. GPTCOMET_OUTPUT_LANG
+	envKeys = []string{
+		"provider",
+		"fallback_providers",
+		"file_ignore",
+		"output.lang",
+		"output.rich_template",
+		"console.verbose",
+		"lint.enabled",
+	}
+
+	// listKeys hold lists, given as comma separated values by env and flags
+	listKeys = map[string]bool{
+		"fallback_providers": true,
+		"file_ignore":        true,
+	}
+
+	// sectionKeys are root keys that are not provider sections
+	sectionKeys = map[string]bool{
+		"output":           true,
+		"console":          true,
+		"prompt":           true,
+		"pricing":          true,
+		"custom_providers": true,
+		"lint":             true,
+		"scope":            true,
+		"history":          true,
+		"issue":            true,
+	}
+
+	// repoSections are the root keys a repository config may set. The
+	// provider, its credentials and its endpoint must come from the
+	// user's own configuration, never from a cloned repository.
+	repoSections = map[string]bool{
+		"prompt":      true,
+		"file_ignore": true,
+		"output":      true,
+		"lint":        true,
+		"scope":       true,
+		"issue":       true,
+		"history":     true,
+	}
+
+	// repoForbiddenKeys are dropped from a repository config in any section
+	repoForbiddenKeys = map[string]bool{
+		"api_key":  true,
+		"api_base": true,
+	}
+)
+
+// Options controls the layers loaded on top of the user config file
+type Options struct {
+	// RepoPath is where the search for the repository config starts,
+	// the working directory if empty
+	RepoPath string
+	// Overrides are values given on the command line, keyed by their
+	// dotted config key
+	Overrides map[string]string
+}
+
+// Origin tells which layer supplied a configuration value
+type Origin struct {
+	Key    string
+	Layer  string // one of the Layer names
+	Source string // file path, environment variable or flag, empty for defaults
+}
+
+// String formats the origin like "repo:/src/app/.gptcomet.yaml"
+func (o Origin) String() string {
+	if o.Source == "" {
+		return o.Layer
+	}
+	return o.Layer + ":" + o.Source
+}
+
+// layer is one source of configuration values
+type layer struct {
+	name    string
+	source  string                 // file path of file layers
+	data    map[string]interface{} // values of the layer
+	sources map[string]string      // per key sources of env and flag layers
+}
+
+// origin returns the origin of key within the layer
+func (l layer) origin(key string) Origin {
+	source := l.source
+	if s, ok := l.sources[key]; ok {
+		source = s
+	}
+	return Origin{Key: key, Layer: l.name, Source: source}
+}
+
+// resolve merges all layers into the effective configuration. It runs
+// whenever the user config changed.
+func (m *Manager) resolve() error {
+	defaultLayer, err := normalize(defaultConfig())
+	if err != nil {
+		return fmt.Errorf("failed to load default config: %w", err)
+	}
+	// The provider sections of the default config are templates for a new
+	// user config, the providers have defaults of their own
+	for key, value := range defaultLayer {
+		if _, ok := value.(map[string]interface{}); ok && !sectionKeys[key] {
+			delete(defaultLayer, key)
+		}
+	}
+	layers := []layer{{name: LayerDefault, data: defaultLayer}}
+
+	system, err := loadLayer(LayerSystem, systemConfigPath)
+	if err != nil {
+		return err
+	}
+	if system != nil {
+		layers = append(layers, *system)
+	}
+
+	user, err := normalize(m.user)
+	if err != nil {
+		return fmt.Errorf("failed to load user config: %w", err)
+	}
+	layers = append(layers, layer{name: LayerUser, source: m.configPath, data: user})
+
+	if path := findRepoConfig(m.repoPath()); path != "" {
+		repo, err := loadLayer(LayerRepo, path)
+		if err != nil {
+			return err
+		}
+		if dropped := restrictRepoLayer(repo.data, ""); len(dropped) > 0 && !m.repoWarned {
+			sort.Strings(dropped)
+			fmt.Fprintf(os.Stderr, "Warning: ignoring %s in %s, only the user config can set them\n", strings.Join(dropped, ", "), path)
+			m.repoWarned = true
+		}
+		layers = append(layers, *repo)
+	}
+
+	m.config = make(map[string]interface{})
+	m.origins = make(map[string]Origin)
+	for _, l := range layers {
+		m.merge(l)
+	}
+
+	// Custom providers must be known to match their environment variables
+	if err := m.registerCustomProviders(); err != nil {
+		return err
+	}
+
+	profile, err := m.profileLayer()
+	if err != nil {
+		return err
+	}
+	for _, l := range []layer{profile, m.envLayer(), overridesLayer(m.options.Overrides)} {
+		if len(l.data) > 0 {
+			m.merge(l)
+		}
+	}
+	return nil
+}
+
+// restrictRepoLayer removes the keys of a repository config outside of
+// repoSections and the repoForbiddenKeys from data, and returns their
+// dotted names
+func restrictRepoLayer(data map[string]interface{}, prefix string) []string {
+	var dropped []string
+	for k, v := range data {
+		key := prefix + k
+		if (prefix == "" && !repoSections[k]) || repoForbiddenKeys[k] {
+			delete(data, k)
+			dropped = append(dropped, key)
+			continue
+		}
+		if section, ok := v.(map[string]interface{}); ok {
+			dropped = append(dropped, restrictRepoLayer(section, key+".")...)
+		}
+	}
+	return dropped
+}
+
+// merge applies the values of l on top of the effective configuration
+func (m *Manager) merge(l layer) {
+	mergeInto(m.config, l.data, "", func(key string) {
+		// A value replaces everything below its key
+		for k := range m.origins {
+			if strings.HasPrefix(k, key+".") {
+				delete(m.origins, k)
+			}
+		}
+		m.origins[key] = l.origin(key)
+	})
+}
+
+// mergeInto deep merges src into dst. Maps are merged key by key, other
+// values, lists included, replace the value in dst. set is called with the
+// dotted key of every replaced value.
+func mergeInto(dst, src map[string]interface{}, prefix string, set func(key string)) {
+	for k, v := range src {
+		key := prefix + k
+		if srcMap, ok := v.(map[string]interface{}); ok {
+			dstMap, ok := dst[k].(map[string]interface{})
+			if !ok {
+				dstMap = make(map[string]interface{})
+				dst[k] = dstMap
+			}
+			mergeInto(dstMap, srcMap, key+".", set)
+			continue
+		}
+		dst[k] = copyValue(v)
+		set(key)
+	}
+}
+
+// copyValue deep copies maps and lists, so the effective configuration
+// never shares them with a layer
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			result[k] = copyValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = copyValue(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// normalize converts data to the types produced by decoding YAML
+func normalize(data map[string]interface{}) (map[string]interface{}, error) {
+	raw, err := yaml.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{})
+	if err := yaml.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// loadLayer reads the config file at path. It returns nil if the file
+// does not exist.
+func loadLayer(name, path string) (*layer, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s config %s: %w", name, path, err)
+	}
+
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s config %s: %w", name, path, err)
+	}
+	return &layer{name: name, source: path, data: values}, nil
+}
+
+// findRepoConfig looks for RepoConfigName in dir and its parents, up to
+// the root of the repository dir is in. It returns an empty string if
+// there is none.
+func findRepoConfig(dir string) string {
+	return findInRepo(dir, RepoConfigName)
+}
+
+// findInRepo looks for the first of names that exists in dir and its
+// parents, up to the root of the repository dir is in
+func findInRepo(dir string, names ...string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// repoPath returns the directory the repository files are looked for from
+func (m *Manager) repoPath() string {
+	if m.options.RepoPath != "" {
+		return m.options.RepoPath
+	}
+	dir, _ := os.Getwd()
+	return dir
+}
+
+// envLayer collects the settings given by GPTCOMET_<KEY> environment
+// variables for the envKeys and GPTCOMET_<PROVIDER>_<KEY> ones for
+// provider settings
+func (m *Manager) envLayer() layer {
+	l := layer{name: LayerEnv, data: make(map[string]interface{}), sources: make(map[string]string)}
+
+	keys := make(map[string]string, len(envKeys))
+	for _, key := range envKeys {
+		keys[envPrefix+envName(strings.ReplaceAll(key, ".", "_"))] = key
+	}
+
+	// Match longer provider names first, so GPTCOMET_OPENAI_X does not
+	// belong to a provider named "open"
+	providers := m.providerNames()
+	sort.Slice(providers, func(i, j int) bool {
+		return len(providers[i]) > len(providers[j])
+	})
+
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, envPrefix) {
+			continue
+		}
+
+		key, ok := keys[name]
+		if !ok {
+			for _, provider := range providers {
+				prefix := envPrefix + envName(provider) + "_"
+				if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+					key = provider + "." + strings.ToLower(strings.TrimPrefix(name, prefix))
+					break
+				}
+			}
+		}
+		if key == "" {
+			continue
+		}
+		setValue(l.data, key, value)
+		l.sources[key] = name
+	}
+	return l
+}
+
+// overridesLayer holds the values given on the command line
+func overridesLayer(overrides map[string]string) layer {
+	l := layer{name: LayerFlag, data: make(map[string]interface{}), sources: make(map[string]string)}
+	for key, value := range overrides {
+		setValue(l.data, key, value)
+		l.sources[key] = "--set " + key
+	}
+	return l
+}
+
+// setValue sets the dotted key in data, splitting list values on commas
+func setValue(data map[string]interface{}, key, value string) {
+	var v interface{} = value
+	if listKeys[key] {
+		var items []interface{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v = items
+	}
+	setNestedValue(data, strings.Split(key, "."), v)
+}
+
+// providerNames returns the registered providers and the providers
+// configured in the effective configuration
+func (m *Manager) providerNames() []string {
+	names := llm.GetProviders()
+	for key, value := range m.config {
+		if _, ok := value.(map[string]interface{}); ok && !sectionKeys[key] {
+			if _, registered := llm.GetProviderInfo(key); !registered {
+				names = append(names, key)
+			}
+		}
+	}
+	return names
+}
+
+// GetOrigins returns the origin of the value of key, or of every value
+// below key if it is a section, sorted by key
+func (m *Manager) GetOrigins(key string) []Origin {
+	var origins []Origin
+	for k, origin := range m.origins {
+		if k == key || strings.HasPrefix(k, key+".") {
+			origins = append(origins, origin)
+		}
+	}
+	sort.Slice(origins, func(i, j int) bool {
+		return origins[i].Key < origins[j].Key
+	})
+	return origins
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setSystemConfig points the system layer at a file with content for the
+// duration of the test
+func setSystemConfig(t *testing.T, content string) {
+	t.Helper()
+	saved := systemConfigPath
+	t.Cleanup(func() { systemConfigPath = saved })
+
+	systemConfigPath = filepath.Join(t.TempDir(), "gptcomet.yaml")
+	if content != "" {
+		require.NoError(t, os.WriteFile(systemConfigPath, []byte(content), 0644))
+	}
+}
+
+// newRepo creates a repository with a .gptcomet.yaml in its root and
+// returns the path of a directory nested in it
+func newRepo(t *testing.T, content string) string {
+	t.Helper()
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, RepoConfigName), []byte(content), 0644))
+
+	nested := filepath.Join(root, "services", "api")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	return nested
+}
+
+func TestLoad_Layers(t *testing.T) {
+	setSystemConfig(t, `
+output:
+  lang: fr
+  rich_template: "system: <title>"
+console:
+  verbose: false
+`)
+	repoPath := newRepo(t, `
+output:
+  lang: de
+file_ignore:
+  - vendor/*
+`)
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+openai:
+  api_key: sk-user
+  model: gpt-4o
+output:
+  lang: ja
+  rich_template: "user: <title>"
+`)
+	defer cleanup()
+	t.Setenv("GPTCOMET_CONSOLE_VERBOSE", "true")
+	t.Setenv("GPTCOMET_OPENAI_MODEL", "gpt-4o-mini")
+
+	cfg, err := Load(configFile, Options{
+		RepoPath:  repoPath,
+		Overrides: map[string]string{"openai.max_tokens": "256"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		key       string
+		want      interface{}
+		wantLayer string
+	}{
+		{key: "output.lang", want: "de", wantLayer: LayerRepo},
+		{key: "output.rich_template", want: "user: <title>", wantLayer: LayerUser},
+		{key: "console.verbose", want: "true", wantLayer: LayerEnv},
+		{key: "openai.model", want: "gpt-4o-mini", wantLayer: LayerEnv},
+		{key: "openai.api_key", want: "sk-user", wantLayer: LayerUser},
+		{key: "openai.max_tokens", want: "256", wantLayer: LayerFlag},
+		{key: "file_ignore", want: []interface{}{"vendor/*"}, wantLayer: LayerRepo},
+		{key: "prompt.translation", wantLayer: LayerDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			value, ok := cfg.Get(tt.key)
+			require.True(t, ok)
+			if tt.want != nil {
+				assert.Equal(t, tt.want, value)
+			}
+			origins := cfg.GetOrigins(tt.key)
+			require.Len(t, origins, 1)
+			assert.Equal(t, tt.wantLayer, origins[0].Layer)
+		})
+	}
+
+	origins := cfg.GetOrigins("output")
+	require.Len(t, origins, 2)
+	assert.Equal(t, "repo:"+filepath.Join(filepath.Dir(filepath.Dir(repoPath)), RepoConfigName), origins[0].String())
+	assert.Equal(t, "user:"+configFile, origins[1].String())
+	assert.Equal(t, "env:GPTCOMET_OPENAI_MODEL", cfg.GetOrigins("openai.model")[0].String())
+
+	clientConfig, err := cfg.GetClientConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o-mini", clientConfig.Model)
+	assert.Equal(t, 256, clientConfig.MaxTokens)
+}
+
+func TestLoad_SetWritesUserConfig(t *testing.T) {
+	setSystemConfig(t, "")
+	repoPath := newRepo(t, `
+output:
+  lang: de
+`)
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+openai:
+  api_key: sk-user
+`)
+	defer cleanup()
+
+	cfg, err := Load(configFile, Options{RepoPath: repoPath})
+	require.NoError(t, err)
+
+	require.NoError(t, cfg.Set("output.lang", "ja"))
+	require.NoError(t, cfg.Append("file_ignore", "dist/*"))
+
+	// The repository still wins over the user config
+	value, _ := cfg.Get("output.lang")
+	assert.Equal(t, "de", value)
+
+	data, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "lang: ja")
+	assert.Contains(t, string(data), "dist/*")
+	assert.NotContains(t, string(data), "lang: de")
+}
+
+func TestFindRepoConfig(t *testing.T) {
+	nested := newRepo(t, "provider: openai\n")
+	root := filepath.Dir(filepath.Dir(nested))
+	assert.Equal(t, filepath.Join(root, RepoConfigName), findRepoConfig(nested))
+
+	// The search stops at the repository root
+	outer := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outer, RepoConfigName), []byte("provider: openai\n"), 0644))
+	inner := filepath.Join(outer, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(inner, ".git"), 0755))
+	assert.Empty(t, findRepoConfig(inner))
+}
+
+func TestLoad_InvalidRepoConfig(t *testing.T) {
+	setSystemConfig(t, "")
+	repoPath := newRepo(t, "output: [\n")
+	configFile, cleanup := testutils.TestConfig(t, "provider: openai\n")
+	defer cleanup()
+
+	_, err := Load(configFile, Options{RepoPath: repoPath})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse repo config")
+}
+
+func TestLoad_RepoConfigRestricted(t *testing.T) {
+	setSystemConfig(t, "")
+	marker := filepath.Join(t.TempDir(), "pwned")
+	repoPath := newRepo(t, `
+provider: evil
+fallback_providers: [evil]
+custom_providers:
+  evil:
+    api_base: https://attacker.example.com
+openai:
+  api_key: "cmd:touch `+marker+`"
+  api_base: https://attacker.example.com
+output:
+  lang: de
+  api_key: "cmd:touch `+marker+`"
+`)
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+openai:
+  api_key: sk-user
+  api_base: https://api.openai.com/v1
+`)
+	defer cleanup()
+
+	cfg, err := Load(configFile, Options{RepoPath: repoPath})
+	require.NoError(t, err)
+
+	clientConfig, err := cfg.GetClientConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "openai", clientConfig.Provider)
+	assert.Equal(t, "sk-user", clientConfig.APIKey)
+	assert.Equal(t, "https://api.openai.com/v1", clientConfig.APIBase)
+	_, err = os.Stat(marker)
+	assert.True(t, os.IsNotExist(err), "the command of the repository config ran")
+
+	value, _ := cfg.Get("output.lang")
+	assert.Equal(t, "de", value)
+	_, ok := cfg.Get("output.api_key")
+	assert.False(t, ok)
+	_, ok = cfg.Get("custom_providers")
+	assert.False(t, ok)
+	assert.Empty(t, cfg.GetOrigins("fallback_providers"))
+}
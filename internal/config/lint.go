@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/belingud/go-gptcomet/internal/lint"
+	"gopkg.in/yaml.v3"
+)
+
+// lintKeys are the settings of the "lint" section
+var lintKeys = []string{
+	"enabled",
+	"types",
+	"scopes",
+	"require_scope",
+	"max_header_length",
+	"max_body_line_length",
+	"max_attempts",
+	"commitlint",
+	"rules",
+}
+
+// GetLintRules returns the rules generated commit messages are checked
+// against. The defaults are overridden in turn by the commitlint config
+// of the repository, the commitlint rules under "lint.rules" and the
+// other settings of the "lint" section. The ticket key of the prompt
+// variables must be referenced as configured by the "issue" section.
+func (m *Manager) GetLintRules() (lint.Rules, error) {
+	rules := lint.DefaultRules()
+	section, _ := m.config["lint"].(map[string]interface{})
+
+	if key := m.promptVars.IssueKey; key != "" {
+		settings, err := m.GetIssueSettings()
+		if err != nil {
+			return rules, err
+		}
+		rules.IssueKey = key
+		rules.Issue = settings
+	}
+
+	if useCommitlint, ok := toBool(section["commitlint"]); !ok || useCommitlint {
+		if path := m.FindCommitlintConfig(); path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return rules, fmt.Errorf("failed to read commitlint config: %w", err)
+			}
+			commitlint, err := lint.ParseCommitlint(path, data)
+			if err != nil {
+				return rules, err
+			}
+			if err := commitlint.Apply(&rules); err != nil {
+				return rules, fmt.Errorf("invalid commitlint config %s: %w", path, err)
+			}
+		}
+	}
+
+	if section == nil {
+		return rules, nil
+	}
+	if value, ok := section["rules"]; ok {
+		// The rules have the commitlint format, decode them the same way
+		data, err := yaml.Marshal(map[string]interface{}{"rules": value})
+		if err != nil {
+			return rules, fmt.Errorf("invalid lint.rules: %w", err)
+		}
+		commitlint, err := lint.ParseCommitlint("lint.rules", data)
+		if err != nil {
+			return rules, err
+		}
+		if err := commitlint.Apply(&rules); err != nil {
+			return rules, fmt.Errorf("invalid lint.rules: %w", err)
+		}
+	}
+
+	if enabled, ok := toBool(section["enabled"]); ok {
+		rules.Enabled = enabled
+	}
+	if types, ok := toStrings(section["types"]); ok {
+		rules.Types = types
+	}
+	if scopes, ok := toStrings(section["scopes"]); ok {
+		rules.Scopes = scopes
+	}
+	if requireScope, ok := toBool(section["require_scope"]); ok {
+		rules.RequireScope = requireScope
+	}
+	if n, ok := toInt(section["max_header_length"]); ok {
+		rules.MaxHeaderLength = n
+	}
+	if n, ok := toInt(section["max_body_line_length"]); ok {
+		rules.MaxBodyLineLength = n
+	}
+	if n, ok := toInt(section["max_attempts"]); ok {
+		rules.MaxAttempts = n
+	}
+	return rules, nil
+}
+
+// FindCommitlintConfig returns the path of the commitlint config of the
+// repository, or an empty string if there is none
+func (m *Manager) FindCommitlintConfig() string {
+	return findInRepo(m.repoPath(), lint.CommitlintFiles...)
+}
+
+// toBool converts a boolean config value, which env and flags give as a
+// string
+func toBool(value interface{}) (bool, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return false, false
+		}
+		return b, true
+	default:
+		return false, false
+	}
+}
+
+// toStrings converts a list config value to a string slice, a string is
+// taken as a comma separated list
+func toStrings(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok && str != "" {
+				result = append(result, str)
+			}
+		}
+		return result, true
+	case []string:
+		return v, true
+	case string:
+		var result []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+		return result, true
+	default:
+		return nil, false
+	}
+}
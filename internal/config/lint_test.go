@@ -0,0 +1,103 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/lint"
+	"github.com/belingud/go-gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLintRules(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, "provider: openai\n")
+	defer cleanup()
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+	rules, err := cfg.GetLintRules()
+	require.NoError(t, err)
+	assert.Equal(t, lint.DefaultRules(), rules)
+
+	configFile, cleanup = testutils.TestConfig(t, `
+provider: openai
+lint:
+  types: [feat, fix]
+  scopes: api, cli
+  require_scope: true
+  max_header_length: "50"
+  max_attempts: 0
+`)
+	defer cleanup()
+
+	cfg, err = New(configFile)
+	require.NoError(t, err)
+	rules, err = cfg.GetLintRules()
+	require.NoError(t, err)
+	assert.True(t, rules.Enabled)
+	assert.Equal(t, []string{"feat", "fix"}, rules.Types)
+	assert.Equal(t, []string{"api", "cli"}, rules.Scopes)
+	assert.True(t, rules.RequireScope)
+	assert.Equal(t, 50, rules.MaxHeaderLength)
+	assert.Equal(t, 100, rules.MaxBodyLineLength)
+	assert.Equal(t, 0, rules.MaxAttempts)
+
+	t.Setenv("GPTCOMET_LINT_ENABLED", "false")
+	cfg, err = New(configFile)
+	require.NoError(t, err)
+	rules, err = cfg.GetLintRules()
+	require.NoError(t, err)
+	assert.False(t, rules.Enabled)
+}
+
+func TestGetLintRules_Commitlint(t *testing.T) {
+	setSystemConfig(t, "")
+	repoPath := newRepo(t, "")
+	root := filepath.Dir(filepath.Dir(repoPath))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "commitlint.config.js"), []byte(`
+module.exports = {
+  extends: ['@commitlint/config-conventional'],
+  rules: {
+    'type-enum': [2, 'always', ['feat', 'fix', 'chore']],
+    'scope-enum': [2, 'always', ['api', 'cli']],
+  },
+};
+`), 0644))
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+lint:
+  rules:
+    header-max-length: [2, always, 60]
+    subject-full-stop: [0]
+`)
+	defer cleanup()
+
+	cfg, err := Load(configFile, Options{RepoPath: repoPath})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "commitlint.config.js"), cfg.FindCommitlintConfig())
+
+	rules, err := cfg.GetLintRules()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feat", "fix", "chore"}, rules.Types)
+	assert.Equal(t, []string{"api", "cli"}, rules.Scopes)
+	assert.Equal(t, 60, rules.MaxHeaderLength)
+	assert.Equal(t, 100, rules.MaxBodyLineLength)
+	assert.Equal(t, []string{lint.RuleSubjectFullStop}, rules.Ignore)
+
+	// The allowed types and scopes are added before the diff
+	p, err := cfg.GetPrompt(false)
+	require.NoError(t, err)
+	assert.Contains(t, p.User, "- the type must be one of: feat, fix, chore.\n- the scope must be one of: api, cli.")
+	assert.Less(t, strings.Index(p.User, "the scope must be"), strings.Index(p.User, "Generate commit message by below git diff"))
+
+	require.NoError(t, cfg.Set("lint.commitlint", false))
+	rules, err = cfg.GetLintRules()
+	require.NoError(t, err)
+	assert.Equal(t, lint.DefaultTypes, rules.Types)
+	p, err = cfg.GetPrompt(false)
+	require.NoError(t, err)
+	assert.NotContains(t, p.User, "must be one of")
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/belingud/go-gptcomet/internal/prompt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profile formats
+const (
+	FormatBrief = "brief"
+	FormatRich  = "rich"
+)
+
+// profileKeys are the settings of a profile under "prompt.profiles.<name>"
+var profileKeys = []string{
+	"system",
+	"template",
+	"format",
+	"lang",
+	"lint",
+}
+
+// Profile is a named set of prompt settings declared under
+// "prompt.profiles.<name>"
+type Profile struct {
+	Name string `yaml:"-"`
+	// System replaces the system part of the commit message prompt
+	System string `yaml:"system,omitempty"`
+	// Template replaces the brief or rich commit message prompt
+	Template string `yaml:"template,omitempty"`
+	// Format is brief or rich, brief by default
+	Format string `yaml:"format,omitempty"`
+	// Lang overrides output.lang
+	Lang string `yaml:"lang,omitempty"`
+	// Lint overrides lint.enabled
+	Lint *bool `yaml:"lint,omitempty"`
+}
+
+// Rich reports whether the profile generates rich commit messages
+func (p Profile) Rich() bool {
+	return p.Format == FormatRich
+}
+
+// Validate checks the format and the language of the profile
+func (p Profile) Validate() error {
+	if p.Format != "" && p.Format != FormatBrief && p.Format != FormatRich {
+		return fmt.Errorf("invalid format %q, expected %s or %s", p.Format, FormatBrief, FormatRich)
+	}
+	if p.Lang != "" && !IsValidLanguage(p.Lang) {
+		return fmt.Errorf("invalid language %q", p.Lang)
+	}
+	return nil
+}
+
+// SetProfile checks the profile and saves it to the user config, replacing
+// the profile of the same name
+func (m *Manager) SetProfile(profile Profile) error {
+	if profile.Name == "" || strings.ContainsAny(profile.Name, ". \t") {
+		return fmt.Errorf("invalid prompt profile name %q", profile.Name)
+	}
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+	for key, tmpl := range map[string]string{"system": profile.System, "template": profile.Template} {
+		if _, err := prompt.Render(tmpl, prompt.Vars{}); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	data, err := yaml.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("invalid prompt profile %s: %w", profile.Name, err)
+	}
+	value := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("invalid prompt profile %s: %w", profile.Name, err)
+	}
+	return m.Set("prompt.profiles."+profile.Name, value)
+}
+
+// GetProfiles returns the prompt profiles declared under
+// "prompt.profiles", sorted by name
+func (m *Manager) GetProfiles() ([]Profile, error) {
+	promptConfig, _ := m.config["prompt"].(map[string]interface{})
+	entries, ok := promptConfig["profiles"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	profiles := make([]Profile, 0, len(names))
+	for _, name := range names {
+		data, err := yaml.Marshal(entries[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt profile %s: %w", name, err)
+		}
+		var profile Profile
+		if err := yaml.Unmarshal(data, &profile); err != nil {
+			return nil, fmt.Errorf("invalid prompt profile %s: %w", name, err)
+		}
+		if err := profile.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid prompt profile %s: %w", name, err)
+		}
+		profile.Name = name
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// GetProfile returns the prompt profile called name
+func (m *Manager) GetProfile(name string) (Profile, error) {
+	profiles, err := m.GetProfiles()
+	if err != nil {
+		return Profile{}, err
+	}
+	names := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+		names = append(names, profile.Name)
+	}
+	if len(names) == 0 {
+		return Profile{}, fmt.Errorf("unknown prompt profile %s, no profiles are configured", name)
+	}
+	return Profile{}, fmt.Errorf("unknown prompt profile %s, available profiles are %s", name, strings.Join(names, ", "))
+}
+
+// GetDefaultProfile returns the name of the profile configured with
+// "prompt.profile", empty if there is none
+func (m *Manager) GetDefaultProfile() string {
+	if name, ok := m.Get("prompt.profile"); ok && name != nil {
+		return strings.TrimSpace(fmt.Sprint(name))
+	}
+	return ""
+}
+
+// UseProfile makes the commit message prompts use the profile name, or the
+// default profile when name is empty. The language and lint settings of the
+// profile take precedence over the config files, but not over environment
+// variables and --set.
+func (m *Manager) UseProfile(name string) error {
+	if name == "" {
+		name = m.GetDefaultProfile()
+	}
+	m.profileName = name
+	if err := m.resolve(); err != nil {
+		m.profileName = ""
+		if resetErr := m.resolve(); resetErr != nil {
+			return resetErr
+		}
+		return err
+	}
+	return nil
+}
+
+// ActiveProfile returns the profile in use, nil if there is none
+func (m *Manager) ActiveProfile() *Profile {
+	return m.profile
+}
+
+// profileLayer returns the layer of the settings of the profile in use
+func (m *Manager) profileLayer() (layer, error) {
+	l := layer{name: LayerProfile, data: make(map[string]interface{})}
+	m.profile = nil
+	if m.profileName == "" {
+		return l, nil
+	}
+	profile, err := m.GetProfile(m.profileName)
+	if err != nil {
+		return l, err
+	}
+	m.profile = &profile
+	l.source = profile.Name
+
+	if profile.Lang != "" {
+		setNestedValue(l.data, []string{"output", "lang"}, profile.Lang)
+	}
+	if profile.Lint != nil {
+		setNestedValue(l.data, []string{"lint", "enabled"}, *profile.Lint)
+	}
+	return l, nil
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/prompt"
+	"github.com/belingud/go-gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProfiles(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+prompt:
+  profiles:
+    team:
+      system: "You write commits for {{ .Repo }}."
+      template: "Summarize {{ .Diff }}"
+      lang: de
+`)
+	defer cleanup()
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+
+	profiles, err := cfg.GetProfiles()
+	require.NoError(t, err)
+	var names []string
+	for _, profile := range profiles {
+		names = append(names, profile.Name)
+	}
+	assert.Equal(t, []string{"gitmoji", "jira", "kernel-style", "one-line", "team"}, names)
+
+	profile, err := cfg.GetProfile("kernel-style")
+	require.NoError(t, err)
+	assert.True(t, profile.Rich())
+	require.NotNil(t, profile.Lint)
+	assert.False(t, *profile.Lint)
+
+	_, err = cfg.GetProfile("missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "available profiles are gitmoji, jira, kernel-style, one-line, team")
+}
+
+func TestUseProfile(t *testing.T) {
+	repoPath := newRepo(t, `
+prompt:
+  profile: team
+`)
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+output:
+  lang: ja
+prompt:
+  brief_commit_message: "Brief {{ .Diff }}"
+  rich_commit_message: "Rich {{ .Diff }}"
+  profiles:
+    team:
+      system: "You write commits for {{ .Repo }}."
+      template: "Summarize {{ .Diff }}"
+      lang: de
+      lint: false
+    detailed:
+      format: rich
+`)
+	defer cleanup()
+
+	t.Run("default profile of the repository", func(t *testing.T) {
+		cfg, err := Load(configFile, Options{RepoPath: repoPath})
+		require.NoError(t, err)
+		require.NoError(t, cfg.UseProfile(""))
+		cfg.SetPromptVars(prompt.Vars{Repo: "gptcomet"})
+
+		p, err := cfg.GetPrompt(false)
+		require.NoError(t, err)
+		assert.Equal(t, prompt.Prompt{System: "You write commits for gptcomet.", User: "Summarize " + prompt.Placeholder}, p)
+
+		lang, _ := cfg.Get("output.lang")
+		assert.Equal(t, "de", lang)
+		origins := cfg.GetOrigins("output.lang")
+		require.Len(t, origins, 1)
+		assert.Equal(t, "profile:team", origins[0].String())
+
+		rules, err := cfg.GetLintRules()
+		require.NoError(t, err)
+		assert.False(t, rules.Enabled)
+	})
+
+	t.Run("flag overrides the profile", func(t *testing.T) {
+		cfg, err := Load(configFile, Options{
+			RepoPath:  repoPath,
+			Overrides: map[string]string{"output.lang": "fr"},
+		})
+		require.NoError(t, err)
+		require.NoError(t, cfg.UseProfile("team"))
+
+		lang, _ := cfg.Get("output.lang")
+		assert.Equal(t, "fr", lang)
+	})
+
+	t.Run("rich format without template", func(t *testing.T) {
+		cfg, err := Load(configFile, Options{RepoPath: repoPath})
+		require.NoError(t, err)
+		require.NoError(t, cfg.UseProfile("detailed"))
+
+		p, err := cfg.GetPrompt(false)
+		require.NoError(t, err)
+		assert.Equal(t, prompt.Prompt{User: "Rich " + prompt.Placeholder}, p)
+		lang, _ := cfg.Get("output.lang")
+		assert.Equal(t, "ja", lang)
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		cfg, err := Load(configFile, Options{RepoPath: repoPath})
+		require.NoError(t, err)
+		require.Error(t, cfg.UseProfile("missing"))
+
+		assert.Nil(t, cfg.ActiveProfile())
+		p, err := cfg.GetPrompt(false)
+		require.NoError(t, err)
+		assert.Equal(t, prompt.Prompt{User: "Brief " + prompt.Placeholder}, p)
+	})
+}
+
+func TestSetProfile(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+`)
+	defer cleanup()
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+
+	lint := true
+	require.NoError(t, cfg.SetProfile(Profile{Name: "short", Template: "One line for {{ .Diff }}", Lang: "fr", Lint: &lint}))
+	profile, err := cfg.GetProfile("short")
+	require.NoError(t, err)
+	assert.Equal(t, "One line for {{ .Diff }}", profile.Template)
+	assert.Equal(t, "fr", profile.Lang)
+
+	reloaded, err := New(configFile)
+	require.NoError(t, err)
+	_, err = reloaded.GetProfile("short")
+	require.NoError(t, err)
+
+	assert.Error(t, cfg.SetProfile(Profile{Name: "bad", Format: "long"}))
+	assert.Error(t, cfg.SetProfile(Profile{Name: "bad", Lang: "xx"}))
+	assert.Error(t, cfg.SetProfile(Profile{Name: "bad", Template: "{{ .Ticket }}"}))
+	assert.Error(t, cfg.SetProfile(Profile{Name: "a.b"}))
+}
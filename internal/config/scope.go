@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/belingud/go-gptcomet/internal/scope"
+)
+
+// scopeKeys are the settings of the "scope" section
+var scopeKeys = []string{
+	"enabled",
+	"paths",
+	"monorepo",
+}
+
+// GetScopeSettings returns how the scope of a commit is inferred from the
+// changed files, configured by the "scope" section. The mappings are given
+// under "scope.paths" as "glob -> scope".
+func (m *Manager) GetScopeSettings() (scope.Settings, error) {
+	settings := scope.DefaultSettings()
+	section, ok := m.config["scope"].(map[string]interface{})
+	if !ok {
+		return settings, nil
+	}
+
+	if enabled, ok := toBool(section["enabled"]); ok {
+		settings.Enabled = enabled
+	}
+	if monorepo, ok := toBool(section["monorepo"]); ok {
+		settings.Monorepo = monorepo
+	}
+	if paths, ok := toStrings(section["paths"]); ok {
+		for _, p := range paths {
+			mapping, err := scope.ParseMapping(p)
+			if err != nil {
+				return settings, fmt.Errorf("invalid scope.paths: %w", err)
+			}
+			settings.Mappings = append(settings.Mappings, mapping)
+		}
+	}
+	return settings, nil
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/scope"
+	"github.com/belingud/go-gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetScopeSettings(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+scope:
+  monorepo: false
+  paths:
+    - internal/llm/** -> llm
+    - cmd/** -> cli
+`)
+	defer cleanup()
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+	settings, err := cfg.GetScopeSettings()
+	require.NoError(t, err)
+	assert.True(t, settings.Enabled)
+	assert.False(t, settings.Monorepo)
+	assert.Equal(t, []scope.Mapping{
+		{Pattern: "internal/llm/**", Scope: "llm"},
+		{Pattern: "cmd/**", Scope: "cli"},
+	}, settings.Mappings)
+
+	require.NoError(t, cfg.Append("scope.paths", "cmd"))
+	_, err = cfg.GetScopeSettings()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid scope.paths")
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Prefixes of api_key values that reference a secret instead of holding it
+const (
+	secretEnvPrefix  = "env:"
+	secretFilePrefix = "file:"
+	secretCmdPrefix  = "cmd:"
+)
+
+// envPrefix starts the environment variables overriding settings
+const envPrefix = "GPTCOMET_"
+
+// secretLayers are the layers a secret reference is resolved from. Others,
+// such as the config checked into a repository, could run commands.
+var secretLayers = map[string]bool{
+	LayerSystem: true,
+	LayerUser:   true,
+	LayerEnv:    true,
+	LayerFlag:   true,
+}
+
+// checkSecretOrigin refuses a secret reference in key unless it comes
+// from one of the secretLayers
+func (m *Manager) checkSecretOrigin(key string) error {
+	if origin, ok := m.origins[key]; ok && !secretLayers[origin.Layer] {
+		return fmt.Errorf("%s references a secret in %s, only the user and system configs, the environment and --set may", key, origin)
+	}
+	return nil
+}
+
+// isSecretRef reports whether value references a secret
+func isSecretRef(value string) bool {
+	return strings.HasPrefix(value, secretEnvPrefix) ||
+		strings.HasPrefix(value, secretFilePrefix) ||
+		strings.HasPrefix(value, secretCmdPrefix)
+}
+
+// resolveSecret returns the secret referenced by value, which is one of
+//   - env:NAME, the value of the environment variable NAME
+//   - file:PATH, the trimmed content of the file at PATH
+//   - cmd:COMMAND, the trimmed output of COMMAND run by the shell
+//
+// Any other value is returned as is.
+func resolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, secretEnvPrefix):
+		name := strings.TrimPrefix(value, secretEnvPrefix)
+		secret, ok := os.LookupEnv(name)
+		if !ok || secret == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+
+	case strings.HasPrefix(value, secretFilePrefix):
+		path := expandHome(strings.TrimPrefix(value, secretFilePrefix))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+
+	case strings.HasPrefix(value, secretCmdPrefix):
+		command := strings.TrimPrefix(value, secretCmdPrefix)
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd", "/C", command)
+		} else {
+			cmd = exec.Command("sh", "-c", command)
+		}
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("secret command %q failed: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimSpace(string(out)), nil
+
+	default:
+		return value, nil
+	}
+}
+
+// expandHome replaces a leading ~ in path with the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// envName converts a provider name to its environment variable form
+func envName(provider string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, provider)
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSecret(t *testing.T) {
+	t.Setenv("GPTCOMET_TEST_SECRET", "from-env")
+
+	secretFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(secretFile, []byte("from-file\n"), 0600))
+
+	tests := []struct {
+		name        string
+		value       string
+		want        string
+		errContains string
+	}{
+		{name: "literal", value: "sk-literal", want: "sk-literal"},
+		{name: "env", value: "env:GPTCOMET_TEST_SECRET", want: "from-env"},
+		{name: "unset env", value: "env:GPTCOMET_TEST_UNSET", errContains: "GPTCOMET_TEST_UNSET is not set"},
+		{name: "file", value: "file:" + secretFile, want: "from-file"},
+		{name: "missing file", value: "file:" + secretFile + ".missing", errContains: "failed to read secret file"},
+		{name: "cmd", value: "cmd:echo from-cmd", want: "from-cmd"},
+		{name: "failing cmd", value: "cmd:exit 3", errContains: "secret command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if runtime.GOOS == "windows" && strings.HasPrefix(tt.value, secretCmdPrefix) {
+				t.Skip("secret commands are run by sh in this test")
+			}
+			got, err := resolveSecret(tt.value)
+			if tt.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "OPENAI", envName("openai"))
+	assert.Equal(t, "MY_GATEWAY", envName("my-gateway"))
+}
+
+func TestGetProviderConfig_SecretRef(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+openai:
+  api_key: env:GPTCOMET_TEST_OPENAI_KEY
+  model: gpt-4o
+`)
+	defer cleanup()
+	t.Setenv("GPTCOMET_TEST_OPENAI_KEY", "sk-resolved")
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+
+	clientConfig, err := cfg.GetClientConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "sk-resolved", clientConfig.APIKey)
+
+	// The reference, not the secret, is written back
+	require.NoError(t, cfg.Set("output.lang", "en"))
+	data, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "env:GPTCOMET_TEST_OPENAI_KEY")
+	assert.NotContains(t, string(data), "sk-resolved")
+
+	t.Setenv("GPTCOMET_TEST_OPENAI_KEY", "")
+	_, err = cfg.GetClientConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to resolve api_key")
+}
+
+func TestGetProviderConfig_EnvOverrides(t *testing.T) {
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+openai:
+  api_key: sk-file
+  model: gpt-4o
+`)
+	defer cleanup()
+	t.Setenv("GPTCOMET_OPENAI_API_KEY", "sk-env")
+	t.Setenv("GPTCOMET_OPENAI_MAX_TOKENS", "2048")
+	t.Setenv("GPTCOMET_DEEPSEEK_API_KEY", "sk-deepseek")
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+
+	openai, err := cfg.GetProviderConfig("openai")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-env", openai.APIKey)
+	assert.Equal(t, 2048, openai.MaxTokens)
+	assert.Equal(t, "gpt-4o", openai.Model)
+
+	// Environment variables alone are enough to configure a provider
+	deepseek, err := cfg.GetProviderConfig("deepseek")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-deepseek", deepseek.APIKey)
+
+	// Overrides are never written to the config file
+	require.NoError(t, cfg.Set("output.lang", "en"))
+	data, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "sk-file")
+	assert.NotContains(t, string(data), "sk-env")
+}
+
+func TestMaskConfigAPIKeys_SecretRef(t *testing.T) {
+	data := map[string]interface{}{
+		"openai": map[string]interface{}{"api_key": "env:OPENAI_API_KEY"},
+		"claude": map[string]interface{}{"api_key": "sk-ant-secret"},
+	}
+	MaskConfigAPIKeys(data)
+	assert.Equal(t, "env:OPENAI_API_KEY", data["openai"].(map[string]interface{})["api_key"])
+	assert.NotEqual(t, "sk-ant-secret", data["claude"].(map[string]interface{})["api_key"])
+}
+
+func TestGetProviderConfig_SecretRefOrigin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("secret commands are run by sh in this test")
+	}
+	setSystemConfig(t, "")
+	marker := filepath.Join(t.TempDir(), "ran")
+	configFile, cleanup := testutils.TestConfig(t, `
+provider: openai
+fallback_providers: [claude]
+openai:
+  api_key: sk-user
+claude:
+  api_key: "cmd:touch `+marker+` && echo sk-claude"
+`)
+	defer cleanup()
+
+	cfg, err := New(configFile)
+	require.NoError(t, err)
+
+	// The command of a fallback only runs when its key is needed
+	claude, err := cfg.GetFallbackProviderConfig("claude")
+	require.NoError(t, err)
+	assert.Empty(t, claude.APIKey)
+	_, err = os.Stat(marker)
+	assert.True(t, os.IsNotExist(err))
+	require.NoError(t, claude.ResolveKey())
+	assert.Equal(t, "sk-claude", claude.APIKey)
+
+	// References are refused from the layers that are not the user's own
+	cfg.origins["openai.api_key"] = Origin{Key: "openai.api_key", Layer: LayerRepo, Source: ".gptcomet.yaml"}
+	setNestedValue(cfg.config, []string{"openai", "api_key"}, "cmd:touch "+marker+".repo")
+	_, err = cfg.GetProviderConfig("openai")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "references a secret in repo:.gptcomet.yaml")
+	_, err = os.Stat(marker + ".repo")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMaskValue(t *testing.T) {
+	assert.Equal(t, "cmd:pass show openai", MaskValue("openai.api_key", "cmd:pass show openai"))
+	assert.Equal(t, "sk-abc****", MaskValue("openai.api_key", "sk-abcdefg"))
+	assert.Equal(t, "gpt-4o", MaskValue("openai.model", "gpt-4o"))
+	section := MaskValue("openai", map[string]interface{}{"api_key": "sk-abcdefg"})
+	assert.Equal(t, "sk-abc****", section.(map[string]interface{})["api_key"])
+}
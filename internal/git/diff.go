@@ -0,0 +1,189 @@
+package git
+
+import (
+	"strings"
+
+	"github.com/belingud/go-gptcomet/internal/debug"
+)
+
+// FileDiff is the part of a unified diff that belongs to a single file
+type FileDiff struct {
+	Path   string
+	Header string   // everything before the first hunk ("diff --git", index, ---/+++ lines)
+	Hunks  []string // every hunk starting with its "@@" line
+}
+
+// String returns the file diff as it appeared in the original diff
+func (f FileDiff) String() string {
+	return f.Header + strings.Join(f.Hunks, "")
+}
+
+// ParseDiff splits a git or SVN unified diff into per-file parts. Text
+// before the first file header is dropped.
+func ParseDiff(diff string) []FileDiff {
+	var (
+		files   []FileDiff
+		current *FileDiff
+		hunk    strings.Builder
+	)
+
+	flushHunk := func() {
+		if current != nil && hunk.Len() > 0 {
+			current.Hunks = append(current.Hunks, hunk.String())
+		}
+		hunk.Reset()
+	}
+	flushFile := func() {
+		flushHunk()
+		if current != nil {
+			files = append(files, *current)
+		}
+		current = nil
+	}
+
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "diff --git "), strings.HasPrefix(line, "Index: "):
+			flushFile()
+			current = &FileDiff{Path: diffPath(line), Header: line}
+		case current == nil:
+			continue
+		case strings.HasPrefix(line, "@@"):
+			flushHunk()
+			hunk.WriteString(line)
+		case hunk.Len() > 0:
+			hunk.WriteString(line)
+		default:
+			current.Header += line
+		}
+	}
+	flushFile()
+
+	return files
+}
+
+// FilterDiff removes the files matching ignorePatterns from diff
+func FilterDiff(diff string, ignorePatterns []string) string {
+	if len(ignorePatterns) == 0 {
+		return diff
+	}
+	var filtered strings.Builder
+	for _, file := range ParseDiff(diff) {
+		if ShouldIgnoreFile(file.Path, ignorePatterns) {
+			debug.Printf("Ignoring %s", file.Path)
+			continue
+		}
+		filtered.WriteString(file.String())
+	}
+	return filtered.String()
+}
+
+// diffPath extracts the file path from a "diff --git a/x b/x" or
+// "Index: x" header line
+func diffPath(line string) string {
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "Index: ") {
+		return strings.TrimPrefix(line, "Index: ")
+	}
+	fields := strings.Fields(strings.TrimPrefix(line, "diff --git "))
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(fields[len(fields)-1], "b/")
+}
+
+// SplitDiff splits diff into chunks of at most maxSize bytes. Whole files
+// are packed together while they fit, files that are too large on their
+// own are split per hunk with the file header repeated in every chunk,
+// and hunks that are still too large are cut at line boundaries with the
+// file and hunk headers repeated in every part. Text
+// that is not a diff, such as the content of a file, is cut at line
+// boundaries too.
+func SplitDiff(diff string, maxSize int) []string {
+	if maxSize <= 0 || len(diff) <= maxSize {
+		return []string{diff}
+	}
+	files := ParseDiff(diff)
+	if len(files) == 0 {
+		return splitLines(diff, maxSize)
+	}
+
+	var (
+		chunks  []string
+		current strings.Builder
+	)
+	add := func(part string) {
+		if current.Len() > 0 && current.Len()+len(part) > maxSize {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		current.WriteString(part)
+	}
+
+	for _, file := range files {
+		if text := file.String(); len(text) <= maxSize {
+			add(text)
+			continue
+		}
+		for _, hunk := range file.Hunks {
+			for _, part := range splitHunk(hunk, maxSize-len(file.Header)) {
+				add(file.Header + part)
+			}
+		}
+		if len(file.Hunks) == 0 {
+			for _, part := range splitLines(file.Header, maxSize) {
+				add(part)
+			}
+		}
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+
+	return chunks
+}
+
+// splitHunk cuts hunk into parts of at most maxSize bytes at line
+// boundaries, each starting with the "@@" line of the hunk
+func splitHunk(hunk string, maxSize int) []string {
+	if maxSize <= 0 || len(hunk) <= maxSize {
+		return []string{hunk}
+	}
+	end := strings.Index(hunk, "\n") + 1
+	if end == 0 {
+		return []string{hunk}
+	}
+	header, body := hunk[:end], hunk[end:]
+	parts := splitLines(body, maxSize-len(header))
+	for i := range parts {
+		parts[i] = header + parts[i]
+	}
+	return parts
+}
+
+// splitLines cuts text into parts of at most maxSize bytes at line
+// boundaries. A single line longer than maxSize becomes its own part.
+func splitLines(text string, maxSize int) []string {
+	if maxSize <= 0 || len(text) <= maxSize {
+		return []string{text}
+	}
+
+	var (
+		parts   []string
+		current strings.Builder
+	)
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if current.Len() > 0 && current.Len()+len(line) > maxSize {
+			parts = append(parts, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+	return parts
+}
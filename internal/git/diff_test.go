@@ -0,0 +1,109 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDiff = `diff --git a/internal/a.go b/internal/a.go
+index 1111111..2222222 100644
+--- a/internal/a.go
++++ b/internal/a.go
+@@ -1,2 +1,2 @@
+-old a
++new a
+@@ -10,2 +10,3 @@ func a() {
+ context
++added a
+diff --git a/README.md b/README.md
+index 3333333..4444444 100644
+--- a/README.md
++++ b/README.md
+@@ -1 +1 @@
+-old readme
++new readme
+`
+
+func TestParseDiff(t *testing.T) {
+	files := ParseDiff(testDiff)
+	require.Len(t, files, 2)
+
+	assert.Equal(t, "internal/a.go", files[0].Path)
+	assert.Len(t, files[0].Hunks, 2)
+	assert.True(t, strings.HasPrefix(files[0].Hunks[1], "@@ -10,2 +10,3 @@"))
+	assert.Contains(t, files[0].Header, "+++ b/internal/a.go")
+
+	assert.Equal(t, "README.md", files[1].Path)
+	assert.Len(t, files[1].Hunks, 1)
+
+	// Nothing is lost when the parts are joined again
+	assert.Equal(t, testDiff, files[0].String()+files[1].String())
+}
+
+func TestParseDiff_SVN(t *testing.T) {
+	diff := "Index: trunk/main.c\n===================================================================\n--- trunk/main.c\t(revision 1)\n+++ trunk/main.c\t(working copy)\n@@ -1 +1 @@\n-a\n+b\n"
+	files := ParseDiff(diff)
+	require.Len(t, files, 1)
+	assert.Equal(t, "trunk/main.c", files[0].Path)
+	assert.Len(t, files[0].Hunks, 1)
+}
+
+func TestSplitDiff(t *testing.T) {
+	t.Run("fits", func(t *testing.T) {
+		assert.Equal(t, []string{testDiff}, SplitDiff(testDiff, len(testDiff)))
+	})
+
+	t.Run("per file", func(t *testing.T) {
+		files := ParseDiff(testDiff)
+		chunks := SplitDiff(testDiff, len(files[0].String()))
+		require.Len(t, chunks, 2)
+		assert.Equal(t, files[0].String(), chunks[0])
+		assert.Equal(t, files[1].String(), chunks[1])
+	})
+
+	t.Run("per hunk with repeated header", func(t *testing.T) {
+		files := ParseDiff(testDiff)
+		chunks := SplitDiff(testDiff, len(files[0].Header)+len(files[0].Hunks[1]))
+		require.Len(t, chunks, 3)
+		for _, chunk := range chunks[:2] {
+			assert.True(t, strings.HasPrefix(chunk, "diff --git a/internal/a.go"))
+		}
+		assert.Contains(t, chunks[0], "+new a")
+		assert.Contains(t, chunks[1], "+added a")
+	})
+
+	t.Run("large hunk split by lines", func(t *testing.T) {
+		var sb strings.Builder
+		sb.WriteString("diff --git a/big.txt b/big.txt\n--- a/big.txt\n+++ b/big.txt\n@@ -0,0 +1,200 @@\n")
+		for i := 0; i < 200; i++ {
+			sb.WriteString("+some added line of text\n")
+		}
+		chunks := SplitDiff(sb.String(), 500)
+		assert.Greater(t, len(chunks), 1)
+		for _, chunk := range chunks {
+			assert.LessOrEqual(t, len(chunk), 500)
+			assert.True(t, strings.HasPrefix(chunk, "diff --git a/big.txt b/big.txt\n--- a/big.txt\n+++ b/big.txt\n@@ -0,0 +1,200 @@\n+some"))
+		}
+	})
+
+	t.Run("plain text split by lines", func(t *testing.T) {
+		text := strings.Repeat("package main\n", 100)
+		chunks := SplitDiff(text, 500)
+		assert.Greater(t, len(chunks), 1)
+		for _, chunk := range chunks {
+			assert.LessOrEqual(t, len(chunk), 500)
+		}
+		assert.Equal(t, text, strings.Join(chunks, ""))
+	})
+}
+
+func TestFilterDiff(t *testing.T) {
+	assert.Equal(t, testDiff, FilterDiff(testDiff, nil))
+
+	filtered := FilterDiff(testDiff, []string{"*.md"})
+	assert.Equal(t, ParseDiff(testDiff)[0].String(), filtered)
+	assert.Empty(t, FilterDiff(testDiff, []string{"*.md", "internal/*"}))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/belingud/go-gptcomet/internal/config"
@@ -287,6 +288,242 @@ func (g *GitVCS) CreateCommit(repoPath string, message string) error {
 	return err
 }
 
+// GetRangeLog returns the messages of the commits on HEAD that are not on
+// base, oldest first, leaving out merges
+//
+// Parameters:
+//   - repoPath: The file system path to the git repository
+//   - base: The branch or commit HEAD is compared with
+//
+// Returns:
+//   - string: Every commit as a "commit <hash>" line followed by its message
+//   - error: An error if the git command fails or if base does not exist
+func (g *GitVCS) GetRangeLog(repoPath, base string) (string, error) {
+	cmd := exec.Command("git", "log", "--reverse", "--no-merges", "--format=commit %h%n%B", base+"..HEAD")
+	return g.runCommand(cmd, repoPath)
+}
+
+// GetRangeDiff returns the changes on HEAD since it forked from base,
+// excluding the files that match the patterns of the "file_ignore" key
+//
+// Parameters:
+//   - repoPath: The file system path to the git repository
+//   - base: The branch or commit HEAD is compared with
+//   - cfgManager: The config manager to use for retrieving ignore patterns
+//
+// Returns:
+//   - string: The filtered diff of base...HEAD
+//   - error: An error if the git command fails or if base does not exist
+func (g *GitVCS) GetRangeDiff(repoPath, base string, cfgManager *config.Manager) (string, error) {
+	cmd := exec.Command("git", "diff", "-U2", "--no-color", "--no-ext-diff", base+"...HEAD")
+	diff, err := g.runCommand(cmd, repoPath)
+	if err != nil {
+		return "", err
+	}
+	return FilterDiff(diff, cfgManager.GetFileIgnore()), nil
+}
+
+// Commit is a commit as listed by GetCommits and GetRecentCommits
+type Commit struct {
+	Hash    string
+	Subject string
+	Body    string
+}
+
+// Message returns the subject and the body of the commit
+func (c Commit) Message() string {
+	if c.Body == "" {
+		return c.Subject
+	}
+	return c.Subject + "\n\n" + c.Body
+}
+
+// GetCommits returns the commits reachable from to but not from from,
+// newest first, leaving out merges
+//
+// Parameters:
+//   - repoPath: The file system path to the git repository
+//   - from: The tag or commit to start after, or empty for the whole history
+//   - to: The tag or commit to end at
+//
+// Returns:
+//   - []Commit: The commits with their abbreviated hash, subject and body
+//   - error: An error if the git command fails or if a revision does not exist
+func (g *GitVCS) GetCommits(repoPath, from, to string) ([]Commit, error) {
+	revs := to
+	if from != "" {
+		revs = from + ".." + to
+	}
+	cmd := exec.Command("git", "log", "--no-merges", "--format="+commitFormat, revs)
+	output, err := g.runCommand(cmd, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	return parseCommits(output), nil
+}
+
+// GetRecentCommits returns the latest n commits reachable from from,
+// newest first, leaving out merges
+//
+// Parameters:
+//   - repoPath: The file system path to the git repository
+//   - from: The revision to start at, such as HEAD
+//   - n: The number of commits
+//   - paths: Paths relative to the repository root, only commits touching
+//     one of them are returned if given
+//
+// Returns:
+//   - []Commit: The commits, none if from does not exist, such as HEAD
+//     before the first commit or the parent of a root commit
+//   - error: An error if the git command fails
+func (g *GitVCS) GetRecentCommits(repoPath, from string, n int, paths []string) ([]Commit, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	hash, err := gitOutput(repoPath, "rev-parse", "--verify", "-q", from+"^{commit}")
+	if err != nil {
+		return nil, nil
+	}
+
+	args := []string{"log", "--no-merges", "-n", strconv.Itoa(n), "--format=" + commitFormat, hash}
+	if len(paths) > 0 {
+		args = append(args, "--")
+		for _, path := range paths {
+			args = append(args, ":(top,literal)"+path)
+		}
+	}
+	output, err := g.runCommand(exec.Command("git", args...), repoPath)
+	if err != nil {
+		return nil, err
+	}
+	return parseCommits(output), nil
+}
+
+// commitFormat is the log format read by parseCommits
+const commitFormat = "%h%x00%s%x00%b%x1e"
+
+// parseCommits reads the commits of a log in commitFormat
+func parseCommits(output string) []Commit {
+	var commits []Commit
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.SplitN(strings.TrimSpace(record), "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Subject: fields[1],
+			Body:    strings.TrimSpace(fields[2]),
+		})
+	}
+	return commits
+}
+
+// GetPreviousTag returns the newest tag reachable from the parent of rev,
+// the tag a release at rev follows
+//
+// Parameters:
+//   - repoPath: The file system path to the git repository
+//   - rev: The tag or commit of the release
+//
+// Returns:
+//   - string: The tag, empty if there is none
+//   - error: An error if the git command fails or if rev does not exist
+func (g *GitVCS) GetPreviousTag(repoPath, rev string) (string, error) {
+	if _, err := gitOutput(repoPath, "rev-parse", "--verify", "-q", rev+"^"); err != nil {
+		// rev is the first commit
+		return "", nil
+	}
+	tag, err := gitOutput(repoPath, "describe", "--tags", "--abbrev=0", rev+"^")
+	if err != nil {
+		// No tag before rev
+		debug.Printf("No tag found before %s: %v", rev, err)
+		return "", nil
+	}
+	return tag, nil
+}
+
+// GetCommitDate returns the committer date of rev as YYYY-MM-DD
+//
+// Parameters:
+//   - repoPath: The file system path to the git repository
+//   - rev: The tag or commit
+//
+// Returns:
+//   - string: The date
+//   - error: An error if the git command fails or if rev does not exist
+func (g *GitVCS) GetCommitDate(repoPath, rev string) (string, error) {
+	return gitOutput(repoPath, "log", "-1", "--format=%cs", rev)
+}
+
+// IsRange reports whether rev is a range such as "v1..v2" or "main...HEAD"
+func IsRange(rev string) bool {
+	return strings.Contains(rev, "..")
+}
+
+// IsCommit reports whether rev names a commit
+//
+// Parameters:
+//   - repoPath: The file system path to the git repository
+//   - rev: The revision, such as a hash, a branch or a tag
+//
+// Returns:
+//   - bool: True if rev resolves to a commit
+func (g *GitVCS) IsCommit(repoPath, rev string) bool {
+	_, err := gitOutput(repoPath, "rev-parse", "--verify", "-q", rev+"^{commit}")
+	return err == nil
+}
+
+// GetRevisionLog returns the message of a commit, or of every commit in a
+// range, oldest first
+//
+// Parameters:
+//   - repoPath: The file system path to the git repository
+//   - rev: A commit, or a range as accepted by IsRange
+//
+// Returns:
+//   - string: Every commit as a "commit <hash>" line, its author and date
+//     and its message
+//   - error: An error if the git command fails or if rev does not exist
+func (g *GitVCS) GetRevisionLog(repoPath, rev string) (string, error) {
+	args := []string{"log", "--format=commit %h%nAuthor: %an%nDate: %as%n%n%B"}
+	if IsRange(rev) {
+		args = append(args, "--reverse", "--no-merges")
+	} else {
+		args = append(args, "-1")
+	}
+	cmd := exec.Command("git", append(args, rev)...)
+	return g.runCommand(cmd, repoPath)
+}
+
+// GetRevisionDiff returns the changes of a commit, or of a range as a
+// whole, excluding the files that match the patterns of the "file_ignore"
+// key
+//
+// Parameters:
+//   - repoPath: The file system path to the git repository
+//   - rev: A commit, or a range as accepted by IsRange
+//   - cfgManager: The config manager to use for retrieving ignore patterns
+//
+// Returns:
+//   - string: The filtered diff, against the first parent for merges
+//   - error: An error if the git command fails or if rev does not exist
+func (g *GitVCS) GetRevisionDiff(repoPath, rev string, cfgManager *config.Manager) (string, error) {
+	var (
+		diff string
+		err  error
+	)
+	if IsRange(rev) {
+		diff, err = g.runCommand(exec.Command("git", "diff", "-U2", "--no-color", "--no-ext-diff", rev), repoPath)
+	} else {
+		diff, err = GetCommitDiff(repoPath, rev)
+	}
+	if err != nil {
+		return "", err
+	}
+	return FilterDiff(diff, cfgManager.GetFileIgnore()), nil
+}
+
 // runCommand 执行命令并返回输出
 func (g *GitVCS) runCommand(cmd *exec.Cmd, repoPath string) (string, error) {
 	debug.Printf("Running command: %v", cmd.Args)
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/belingud/go-gptcomet/internal/config"
 	"github.com/belingud/go-gptcomet/internal/testutils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -154,3 +155,139 @@ func TestNewVCS(t *testing.T) {
 		})
 	}
 }
+
+func TestGitVCS_Range(t *testing.T) {
+	dir := newHistoryRepo(t)
+	cfgManager, err := config.Load(filepath.Join(t.TempDir(), "gptcomet.yaml"), config.Options{
+		RepoPath:  dir,
+		Overrides: map[string]string{"file_ignore": "three.txt"},
+	})
+	require.NoError(t, err)
+	vcs := &GitVCS{}
+
+	log, err := vcs.GetRangeLog(dir, "HEAD~2")
+	require.NoError(t, err)
+	assert.Regexp(t, `^commit \w+\nadd two\n\ncommit \w+\nadd three\n`, log)
+
+	diff, err := vcs.GetRangeDiff(dir, "HEAD~2", cfgManager)
+	require.NoError(t, err)
+	files := ParseDiff(diff)
+	require.Len(t, files, 1)
+	assert.Equal(t, "two.txt", files[0].Path)
+
+	_, err = vcs.GetRangeLog(dir, "no-such-branch")
+	assert.Error(t, err)
+}
+
+func TestNextRevision(t *testing.T) {
+	next, err := nextRevision("r41")
+	require.NoError(t, err)
+	assert.Equal(t, "42", next)
+	next, err = nextRevision("7")
+	require.NoError(t, err)
+	assert.Equal(t, "8", next)
+	_, err = nextRevision("trunk")
+	assert.Error(t, err)
+}
+
+func TestGitVCS_Commits(t *testing.T) {
+	dir := newHistoryRepo(t)
+	require.NoError(t, testutils.RunGitCommand(t, dir, "tag", "v0.1.0", "HEAD~2"))
+	require.NoError(t, testutils.RunGitCommand(t, dir, "commit", "--allow-empty", "-m", "fix: handle empty diffs\n\nReturn early.\n\nSecond paragraph."))
+	vcs := &GitVCS{}
+
+	tag, err := vcs.GetPreviousTag(dir, "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "v0.1.0", tag)
+	tag, err = vcs.GetPreviousTag(dir, "v0.1.0")
+	require.NoError(t, err)
+	assert.Empty(t, tag)
+
+	commits, err := vcs.GetCommits(dir, tag, "HEAD")
+	require.NoError(t, err)
+	require.Len(t, commits, 4)
+	commits, err = vcs.GetCommits(dir, "v0.1.0", "HEAD")
+	require.NoError(t, err)
+	require.Len(t, commits, 3)
+	assert.Equal(t, "fix: handle empty diffs", commits[0].Subject)
+	assert.Equal(t, "Return early.\n\nSecond paragraph.", commits[0].Body)
+	assert.Equal(t, "add two", commits[2].Subject)
+	assert.Empty(t, commits[2].Body)
+	assert.NotEmpty(t, commits[2].Hash)
+
+	date, err := vcs.GetCommitDate(dir, "v0.1.0")
+	require.NoError(t, err)
+	assert.Regexp(t, `^\d{4}-\d{2}-\d{2}$`, date)
+}
+
+func TestGitVCS_RecentCommits(t *testing.T) {
+	dir := newHistoryRepo(t)
+	require.NoError(t, testutils.RunGitCommand(t, dir, "commit", "--allow-empty", "-m", "fix: handle empty diffs\n\nReturn early."))
+	vcs := &GitVCS{}
+
+	commits, err := vcs.GetRecentCommits(dir, "HEAD", 2, nil)
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, "fix: handle empty diffs\n\nReturn early.", commits[0].Message())
+	assert.Equal(t, "add three", commits[1].Message())
+
+	commits, err = vcs.GetRecentCommits(dir, "HEAD", 5, []string{"one.txt", "two.txt"})
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, "add two", commits[0].Subject)
+	assert.Equal(t, "add one", commits[1].Subject)
+
+	commits, err = vcs.GetRecentCommits(dir, "HEAD~2", 5, nil)
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, "add two", commits[0].Subject)
+
+	commits, err = vcs.GetRecentCommits(dir, "HEAD~3^", 5, nil)
+	require.NoError(t, err)
+	assert.Empty(t, commits)
+
+	commits, err = vcs.GetRecentCommits(dir, "HEAD", 0, nil)
+	require.NoError(t, err)
+	assert.Empty(t, commits)
+
+	commits, err = vcs.GetRecentCommits(newHookRepo(t), "HEAD", 3, nil)
+	require.NoError(t, err)
+	assert.Empty(t, commits)
+}
+
+func TestGitVCS_Revision(t *testing.T) {
+	dir := newHistoryRepo(t)
+	cfgManager, err := config.Load(filepath.Join(t.TempDir(), "gptcomet.yaml"), config.Options{
+		RepoPath:  dir,
+		Overrides: map[string]string{"file_ignore": "three.txt"},
+	})
+	require.NoError(t, err)
+	vcs := &GitVCS{}
+
+	assert.True(t, vcs.IsCommit(dir, "HEAD~1"))
+	assert.False(t, vcs.IsCommit(dir, "one.txt"))
+	assert.True(t, IsRange("HEAD~2..HEAD"))
+	assert.False(t, IsRange("HEAD~2"))
+
+	log, err := vcs.GetRevisionLog(dir, "HEAD~1")
+	require.NoError(t, err)
+	assert.Regexp(t, `^commit \w+\nAuthor: Other\nDate: \d{4}-\d{2}-\d{2}\n\nadd two\n+$`, log)
+	log, err = vcs.GetRevisionLog(dir, "HEAD~2..HEAD")
+	require.NoError(t, err)
+	assert.Regexp(t, `(?s)^commit \w+\n.*add two\n.*commit \w+\n.*add three\n+$`, log)
+
+	diff, err := vcs.GetRevisionDiff(dir, "HEAD~1", cfgManager)
+	require.NoError(t, err)
+	files := ParseDiff(diff)
+	require.Len(t, files, 1)
+	assert.Equal(t, "two.txt", files[0].Path)
+
+	diff, err = vcs.GetRevisionDiff(dir, "HEAD~2..HEAD", cfgManager)
+	require.NoError(t, err)
+	files = ParseDiff(diff)
+	require.Len(t, files, 1)
+	assert.Equal(t, "two.txt", files[0].Path)
+
+	_, err = vcs.GetRevisionLog(dir, "no-such-branch")
+	assert.Error(t, err)
+}
@@ -0,0 +1,236 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// HookName is the git hook gptcomet installs itself as
+	HookName = "prepare-commit-msg"
+	// chainedHookSuffix is appended to the name of a hook that existed
+	// before gptcomet was installed, it is run before gptcomet
+	chainedHookSuffix = ".gptcomet-chained"
+	// hookMarker identifies a hook script written by gptcomet
+	hookMarker = "# gptcomet prepare-commit-msg hook"
+)
+
+// HookState describes the prepare-commit-msg hook of a repository
+type HookState struct {
+	Path      string // path of the hook script
+	Installed bool   // the hook is the gptcomet hook
+	Foreign   bool   // a hook not written by gptcomet exists
+	Chained   string // path of the previous hook run by the gptcomet hook, if any
+}
+
+// HooksDir returns the directory git runs the hooks of repoPath from,
+// honoring core.hooksPath
+func HooksDir(repoPath string) (string, error) {
+	cmd := exec.Command("git", "config", "--get", "core.hooksPath")
+	cmd.Dir = repoPath
+	if out, err := cmd.Output(); err == nil {
+		dir := strings.TrimSpace(string(out))
+		if dir != "" {
+			dir = expandHome(dir)
+			if !filepath.IsAbs(dir) {
+				// Relative hook paths are relative to the working tree root
+				top, err := gitOutput(repoPath, "rev-parse", "--show-toplevel")
+				if err != nil {
+					return "", err
+				}
+				dir = filepath.Join(top, dir)
+			}
+			return dir, nil
+		}
+	}
+
+	dir, err := gitOutput(repoPath, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoPath, dir)
+	}
+	return dir, nil
+}
+
+// GetHookState inspects the prepare-commit-msg hook of repoPath
+func GetHookState(repoPath string) (*HookState, error) {
+	dir, err := HooksDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &HookState{Path: filepath.Join(dir, HookName)}
+	data, err := os.ReadFile(state.Path)
+	switch {
+	case os.IsNotExist(err):
+		return state, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read hook: %w", err)
+	}
+
+	state.Installed = bytes.Contains(data, []byte(hookMarker))
+	state.Foreign = !state.Installed
+	if _, err := os.Stat(state.Path + chainedHookSuffix); err == nil {
+		state.Chained = state.Path + chainedHookSuffix
+	}
+	return state, nil
+}
+
+// InstallHook installs the gptcomet prepare-commit-msg hook in repoPath,
+// running executable to generate the message. An existing hook is kept
+// and run before gptcomet.
+func InstallHook(repoPath, executable string) (*HookState, error) {
+	state, err := GetHookState(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(state.Path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	if state.Foreign {
+		chained := state.Path + chainedHookSuffix
+		if state.Chained != "" {
+			return nil, fmt.Errorf("cannot chain %s, %s already exists", state.Path, chained)
+		}
+		if err := os.Rename(state.Path, chained); err != nil {
+			return nil, fmt.Errorf("failed to keep existing hook: %w", err)
+		}
+		state.Chained = chained
+		state.Foreign = false
+	}
+
+	if err := os.WriteFile(state.Path, []byte(hookScript(executable)), 0755); err != nil {
+		return nil, fmt.Errorf("failed to write hook: %w", err)
+	}
+	state.Installed = true
+	return state, nil
+}
+
+// UninstallHook removes the gptcomet hook from repoPath and restores the
+// hook it chained. A hook not written by gptcomet is left alone.
+func UninstallHook(repoPath string) (*HookState, error) {
+	state, err := GetHookState(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	if state.Foreign {
+		return nil, fmt.Errorf("%s was not installed by gptcomet", state.Path)
+	}
+	if !state.Installed {
+		return state, nil
+	}
+
+	if err := os.Remove(state.Path); err != nil {
+		return nil, fmt.Errorf("failed to remove hook: %w", err)
+	}
+	state.Installed = false
+
+	if state.Chained != "" {
+		if err := os.Rename(state.Chained, state.Path); err != nil {
+			return nil, fmt.Errorf("failed to restore previous hook: %w", err)
+		}
+		state.Chained = ""
+		state.Foreign = true
+	}
+	return state, nil
+}
+
+// hookScript returns the hook running the chained hook, if any, and then
+// "gptcomet hook run" with the hook's arguments
+func hookScript(executable string) string {
+	return fmt.Sprintf(`#!/bin/sh
+%s
+# Installed by "gptcomet hook install", remove with "gptcomet hook uninstall".
+chained="$0%s"
+if [ -x "$chained" ]; then
+	"$chained" "$@" || exit $?
+fi
+exec %s hook run "$@"
+`, hookMarker, chainedHookSuffix, shellQuote(executable))
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ShouldGenerateForSource reports whether the hook should generate a
+// message for a commit with the given prepare-commit-msg source. Merges,
+// squashes, amends (and -c/-C) and messages given with -m or -F keep
+// their message.
+func ShouldGenerateForSource(source string) bool {
+	switch source {
+	case "merge", "squash", "commit", "message":
+		return false
+	default:
+		return true
+	}
+}
+
+// TopLevel returns the root of the working tree repoPath is in, which
+// the paths of GetStagedFiles are relative to
+func TopLevel(repoPath string) (string, error) {
+	return gitOutput(repoPath, "rev-parse", "--show-toplevel")
+}
+
+// gitOutput runs git with args in repoPath and returns its trimmed output
+func gitOutput(repoPath string, args ...string) (string, error) {
+	out, err := runGit(repoPath, "", args...)
+	return strings.TrimSpace(out), err
+}
+
+// runGit runs git with args in repoPath, with stdin as its input, and
+// returns its output as is
+func runGit(repoPath, stdin string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w\nGit output: %s", strings.Join(args, " "), err, stderr.String())
+	}
+	return string(out), nil
+}
+
+// expandHome replaces a leading ~ in path with the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// PrefillMessage writes message at the top of the commit message file
+// msgFile, keeping what git already put there, such as the status comments
+func PrefillMessage(msgFile, message string) error {
+	existing, err := os.ReadFile(msgFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read commit message file: %w", err)
+	}
+
+	content := strings.TrimRight(message, "\n") + "\n"
+	if len(existing) > 0 {
+		content += "\n" + string(existing)
+	}
+	if err := os.WriteFile(msgFile, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write commit message file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHookRepo creates an empty git repository
+func newHookRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, testutils.RunGitCommand(t, dir, "init"))
+	return dir
+}
+
+// writeScript writes an executable shell script
+func writeScript(t *testing.T, path, body string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755))
+}
+
+func TestHooksDir(t *testing.T) {
+	dir := newHookRepo(t)
+
+	got, err := HooksDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ".git", "hooks"), got)
+
+	require.NoError(t, testutils.RunGitCommand(t, dir, "config", "core.hooksPath", ".githooks"))
+	got, err = HooksDir(dir)
+	require.NoError(t, err)
+	// git reports the working tree with symlinks resolved
+	root, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, ".githooks"), got)
+}
+
+func TestInstallHook(t *testing.T) {
+	dir := newHookRepo(t)
+
+	state, err := GetHookState(dir)
+	require.NoError(t, err)
+	assert.False(t, state.Installed)
+	assert.False(t, state.Foreign)
+
+	state, err = InstallHook(dir, "/usr/local/bin/gptcomet")
+	require.NoError(t, err)
+	assert.True(t, state.Installed)
+	assert.Empty(t, state.Chained)
+
+	data, err := os.ReadFile(state.Path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "'/usr/local/bin/gptcomet' hook run \"$@\"")
+
+	// Installing twice does not chain the gptcomet hook to itself
+	state, err = InstallHook(dir, "/usr/local/bin/gptcomet")
+	require.NoError(t, err)
+	assert.Empty(t, state.Chained)
+
+	state, err = UninstallHook(dir)
+	require.NoError(t, err)
+	assert.False(t, state.Installed)
+	assert.NoFileExists(t, state.Path)
+}
+
+func TestInstallHook_ChainsExistingHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are run by sh")
+	}
+	dir := newHookRepo(t)
+	hooksDir, err := HooksDir(dir)
+	require.NoError(t, err)
+
+	// The existing hook and the fake gptcomet record their arguments
+	log := filepath.Join(dir, "calls.log")
+	existing := filepath.Join(hooksDir, HookName)
+	writeScript(t, existing, "echo \"existing $*\" >> "+log+"\n")
+	fake := filepath.Join(dir, "fake-gptcomet")
+	writeScript(t, fake, "echo \"gptcomet $*\" >> "+log+"\n")
+
+	state, err := InstallHook(dir, fake)
+	require.NoError(t, err)
+	assert.Equal(t, existing+chainedHookSuffix, state.Chained)
+
+	status, err := GetHookState(dir)
+	require.NoError(t, err)
+	assert.True(t, status.Installed)
+	assert.Equal(t, state.Chained, status.Chained)
+
+	out, err := exec.Command(state.Path, "MSG", "template").CombinedOutput()
+	require.NoError(t, err, string(out))
+	calls, err := os.ReadFile(log)
+	require.NoError(t, err)
+	assert.Equal(t, "existing MSG template\ngptcomet hook run MSG template\n", string(calls))
+
+	state, err = UninstallHook(dir)
+	require.NoError(t, err)
+	assert.True(t, state.Foreign)
+	data, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "existing $*")
+	assert.NoFileExists(t, existing+chainedHookSuffix)
+}
+
+func TestUninstallHook_ForeignHook(t *testing.T) {
+	dir := newHookRepo(t)
+	hooksDir, err := HooksDir(dir)
+	require.NoError(t, err)
+	writeScript(t, filepath.Join(hooksDir, HookName), "exit 0\n")
+
+	_, err = UninstallHook(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "was not installed by gptcomet")
+}
+
+func TestShouldGenerateForSource(t *testing.T) {
+	for source, want := range map[string]bool{
+		"":         true,
+		"template": true,
+		"message":  false,
+		"merge":    false,
+		"squash":   false,
+		"commit":   false,
+	} {
+		assert.Equal(t, want, ShouldGenerateForSource(source), source)
+	}
+}
+
+func TestPrefillMessage(t *testing.T) {
+	msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	require.NoError(t, os.WriteFile(msgFile, []byte("# Please enter the commit message\n"), 0644))
+
+	require.NoError(t, PrefillMessage(msgFile, "feat: add hook mode\n"))
+	data, err := os.ReadFile(msgFile)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add hook mode\n\n# Please enter the commit message\n", string(data))
+}
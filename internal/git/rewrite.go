@@ -0,0 +1,143 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/belingud/go-gptcomet/internal/debug"
+)
+
+// GetCommitDiff returns the changes of commit rev, against its first parent
+// for merges
+func GetCommitDiff(repoPath, rev string) (string, error) {
+	return runGit(repoPath, "", "show", "--format=", "-U2", "--no-color", "--no-ext-diff", "--diff-merges=first-parent", rev)
+}
+
+// GetAmendDiff returns the changes of HEAD together with the staged
+// changes, the diff an amended HEAD would have
+func GetAmendDiff(repoPath string) (string, error) {
+	base, err := gitOutput(repoPath, "rev-parse", "--verify", "-q", "HEAD^")
+	if err != nil {
+		// HEAD is the first commit, compare with the empty tree
+		if base, err = gitOutput(repoPath, "mktree"); err != nil {
+			return "", err
+		}
+	}
+	return runGit(repoPath, "", "diff", "--staged", "-U2", "--no-color", "--no-ext-diff", base)
+}
+
+// GetCommitMessage returns the message of commit rev
+func GetCommitMessage(repoPath, rev string) (string, error) {
+	return gitOutput(repoPath, "log", "-1", "--format=%B", rev)
+}
+
+// AmendCommit folds the staged changes into HEAD and replaces its message
+func AmendCommit(repoPath, message string) error {
+	_, err := gitOutput(repoPath, "commit", "-q", "--amend", "-m", message)
+	return err
+}
+
+// RewordCommit replaces the message of commit rev. HEAD is amended without
+// the staged changes. For an older commit, the commit and those after it
+// are rewritten with the same trees, authors and dates, like a rebase
+// that rewords it, and the branch is moved to the new HEAD. The index and
+// the working tree are left alone.
+//
+// The copies are written with plumbing commands rather than by a rebase:
+// no hooks run, the move of the branch is a single reflog entry and
+// ORIG_HEAD is not set. Signed commits are refused, the copies could not
+// keep their signatures, and so are merge commits, which a rebase would
+// not keep either.
+func RewordCommit(repoPath, rev, message string) error {
+	hash, err := gitOutput(repoPath, "rev-parse", "--verify", rev+"^{commit}")
+	if err != nil {
+		return fmt.Errorf("unknown commit %s: %w", rev, err)
+	}
+	head, err := gitOutput(repoPath, "rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	if hash == head {
+		_, err := gitOutput(repoPath, "commit", "-q", "--amend", "--only", "-m", message)
+		return err
+	}
+
+	if _, err := gitOutput(repoPath, "merge-base", "--is-ancestor", hash, head); err != nil {
+		return fmt.Errorf("commit %s is not an ancestor of HEAD", rev)
+	}
+	later, err := gitOutput(repoPath, "rev-list", "--reverse", "--topo-order", hash+"..HEAD")
+	if err != nil {
+		return err
+	}
+
+	commits := append([]string{hash}, strings.Fields(later)...)
+	for _, commit := range commits {
+		raw, err := runGit(repoPath, "", "cat-file", "commit", commit)
+		if err != nil {
+			return err
+		}
+		if isSigned(raw) {
+			return fmt.Errorf("commit %s is signed and rewording %s would drop its signature, use git rebase -i to reword and sign it again", commit[:12], rev)
+		}
+		if isMerge(raw) {
+			return fmt.Errorf("commit %s is a merge and rewording %s would rewrite it, use git rebase -i --rebase-merges instead", commit[:12], rev)
+		}
+	}
+
+	rewritten := make(map[string]string)
+	if rewritten[hash], err = rewriteCommit(repoPath, hash, message, rewritten); err != nil {
+		return fmt.Errorf("failed to reword %s: %w", rev, err)
+	}
+	for _, commit := range commits[1:] {
+		if rewritten[commit], err = rewriteCommit(repoPath, commit, "", rewritten); err != nil {
+			return fmt.Errorf("failed to rewrite %s: %w", commit, err)
+		}
+	}
+	debug.Printf("Moving HEAD from %s to %s", head, rewritten[head])
+	_, err = gitOutput(repoPath, "update-ref", "-m", "gptcomet reword "+rev, "HEAD", rewritten[head], head)
+	return err
+}
+
+// isSigned reports whether the raw commit object has a signature
+func isSigned(raw string) bool {
+	header, _, _ := strings.Cut(raw, "\n\n")
+	for _, line := range strings.Split(header, "\n") {
+		if strings.HasPrefix(line, "gpgsig ") || strings.HasPrefix(line, "gpgsig-sha256 ") {
+			return true
+		}
+	}
+	return false
+}
+
+// isMerge reports whether the raw commit object has more than one parent
+func isMerge(raw string) bool {
+	header, _, _ := strings.Cut(raw, "\n\n")
+	return strings.Count("\n"+header, "\nparent ") > 1
+}
+
+// rewriteCommit writes a copy of commit with the parents replaced as in
+// rewritten and, unless it is empty, its message replaced by message, and
+// returns the hash of the copy. The commit must not be signed.
+func rewriteCommit(repoPath, commit, message string, rewritten map[string]string) (string, error) {
+	raw, err := runGit(repoPath, "", "cat-file", "commit", commit)
+	if err != nil {
+		return "", err
+	}
+	header, body, _ := strings.Cut(raw, "\n\n")
+	if message != "" {
+		body = strings.TrimRight(message, "\n") + "\n"
+	}
+
+	var lines []string
+	for _, line := range strings.Split(header, "\n") {
+		if parent, ok := strings.CutPrefix(line, "parent "); ok {
+			if to, ok := rewritten[parent]; ok {
+				line = "parent " + to
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	hash, err := runGit(repoPath, strings.Join(lines, "\n")+"\n\n"+body, "hash-object", "-t", "commit", "-w", "--stdin")
+	return strings.TrimSpace(hash), err
+}
@@ -0,0 +1,141 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHistoryRepo creates a repository with three commits, each adding a
+// file, the second by another author
+func newHistoryRepo(t *testing.T) string {
+	t.Helper()
+	dir := newHookRepo(t)
+	require.NoError(t, testutils.RunGitCommand(t, dir, "config", "user.email", "test@example.com"))
+	require.NoError(t, testutils.RunGitCommand(t, dir, "config", "user.name", "Test User"))
+
+	for _, name := range []string{"one", "two", "three"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name+".txt"), []byte(name+"\n"), 0644))
+		require.NoError(t, testutils.RunGitCommand(t, dir, "add", name+".txt"))
+		args := []string{"commit", "-m", "add " + name}
+		if name == "two" {
+			args = append(args, "--author", "Other <other@example.com>")
+		}
+		require.NoError(t, testutils.RunGitCommand(t, dir, args...))
+	}
+	return dir
+}
+
+func TestGetCommitDiff(t *testing.T) {
+	dir := newHistoryRepo(t)
+
+	diff, err := GetCommitDiff(dir, "HEAD~1")
+	require.NoError(t, err)
+	files := ParseDiff(diff)
+	require.Len(t, files, 1)
+	assert.Equal(t, "two.txt", files[0].Path)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "four.txt"), []byte("four\n"), 0644))
+	require.NoError(t, testutils.RunGitCommand(t, dir, "add", "four.txt"))
+	diff, err = GetAmendDiff(dir)
+	require.NoError(t, err)
+	var paths []string
+	for _, file := range ParseDiff(diff) {
+		paths = append(paths, file.Path)
+	}
+	assert.Equal(t, []string{"four.txt", "three.txt"}, paths)
+}
+
+func TestRewordCommit(t *testing.T) {
+	dir := newHistoryRepo(t)
+	tree, err := gitOutput(dir, "rev-parse", "HEAD^{tree}")
+	require.NoError(t, err)
+	dates, err := gitOutput(dir, "log", "--format=%ad %cd")
+	require.NoError(t, err)
+
+	// Rewording leaves the staged changes staged
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "four.txt"), []byte("four\n"), 0644))
+	require.NoError(t, testutils.RunGitCommand(t, dir, "add", "four.txt"))
+
+	require.NoError(t, RewordCommit(dir, "HEAD~1", "feat: add the second file"))
+	log, err := gitOutput(dir, "log", "--format=%s <%ae>")
+	require.NoError(t, err)
+	assert.Equal(t, "add three <test@example.com>\nfeat: add the second file <other@example.com>\nadd one <test@example.com>", log)
+	got, err := gitOutput(dir, "rev-parse", "HEAD^{tree}")
+	require.NoError(t, err)
+	assert.Equal(t, tree, got)
+	got, err = gitOutput(dir, "log", "--format=%ad %cd")
+	require.NoError(t, err)
+	assert.Equal(t, dates, got)
+
+	require.NoError(t, RewordCommit(dir, "HEAD", "feat: add the third file"))
+	subject, err := gitOutput(dir, "log", "-1", "--format=%s")
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add the third file", subject)
+	staged, err := gitOutput(dir, "diff", "--staged", "--name-only")
+	require.NoError(t, err)
+	assert.Equal(t, "four.txt", staged)
+
+	require.NoError(t, AmendCommit(dir, "feat: add the third and fourth files"))
+	files, err := gitOutput(dir, "show", "--format=%s", "--name-only", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add the third and fourth files\n\nfour.txt\nthree.txt", files)
+
+	msg, err := GetCommitMessage(dir, "HEAD~1")
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add the second file", msg)
+	assert.Error(t, RewordCommit(dir, "no-such-commit", "fix: x"))
+}
+
+func TestRewordCommit_RefusesSigned(t *testing.T) {
+	dir := newHistoryRepo(t)
+
+	// Replace HEAD with a copy carrying a signature
+	raw, err := runGit(dir, "", "cat-file", "commit", "HEAD")
+	require.NoError(t, err)
+	header, body, _ := strings.Cut(raw, "\n\n")
+	signed := header + "\ngpgsig -----BEGIN PGP SIGNATURE-----\n \n -----END PGP SIGNATURE-----\n\n" + body
+	hash, err := runGit(dir, signed, "hash-object", "-t", "commit", "-w", "--stdin")
+	require.NoError(t, err)
+	require.NoError(t, testutils.RunGitCommand(t, dir, "update-ref", "HEAD", strings.TrimSpace(hash)))
+
+	err = RewordCommit(dir, "HEAD~1", "feat: add the second file")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is signed")
+	head, err := gitOutput(dir, "rev-parse", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimSpace(hash), head)
+	subject, err := gitOutput(dir, "log", "-1", "--format=%s", "HEAD~1")
+	require.NoError(t, err)
+	assert.Equal(t, "add two", subject)
+}
+
+func TestRewordCommit_RefusesMerges(t *testing.T) {
+	dir := newHistoryRepo(t)
+	require.NoError(t, testutils.RunGitCommand(t, dir, "checkout", "-q", "-b", "side", "HEAD~1"))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "side.txt"), []byte("side\n"), 0644))
+	require.NoError(t, testutils.RunGitCommand(t, dir, "add", "side.txt"))
+	require.NoError(t, testutils.RunGitCommand(t, dir, "commit", "-q", "-m", "add side"))
+	require.NoError(t, testutils.RunGitCommand(t, dir, "checkout", "-q", "-"))
+	require.NoError(t, testutils.RunGitCommand(t, dir, "merge", "-q", "--no-ff", "-m", "merge side", "side"))
+	head, err := gitOutput(dir, "rev-parse", "HEAD")
+	require.NoError(t, err)
+
+	err = RewordCommit(dir, "HEAD~2", "feat: add the second file")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is a merge")
+	got, err := gitOutput(dir, "rev-parse", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, head, got)
+
+	// The merge itself is HEAD, which is amended
+	require.NoError(t, RewordCommit(dir, "HEAD", "chore: merge the side branch"))
+	subject, err := gitOutput(dir, "log", "-1", "--format=%s")
+	require.NoError(t, err)
+	assert.Equal(t, "chore: merge the side branch", subject)
+}
@@ -0,0 +1,89 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/belingud/go-gptcomet/internal/debug"
+)
+
+// PatchCommit is a commit to create from a part of the staged changes
+type PatchCommit struct {
+	Message string
+	Patch   string // applies to HEAD, or to the previous commits of a split
+}
+
+// GetStagedPatch returns the staged changes as a patch git apply accepts,
+// with binary files and without the file_ignore filter
+func GetStagedPatch(repoPath string) (string, error) {
+	return runGit(repoPath, "", "diff", "--staged", "--binary", "--no-color", "--no-ext-diff")
+}
+
+// CommitPatches resets the index to HEAD and creates one commit per patch,
+// staging each with git apply --cached. The commits must add up to the
+// staged changes. On failure, the commits already created are undone and
+// the staged changes are restored, the working tree is never touched.
+func CommitPatches(repoPath string, commits []PatchCommit) (err error) {
+	staged, err := gitOutput(repoPath, "write-tree")
+	if err != nil {
+		return fmt.Errorf("failed to save the index: %w", err)
+	}
+	// There is no HEAD before the first commit of a repository
+	head, headErr := gitOutput(repoPath, "rev-parse", "--verify", "-q", "HEAD")
+
+	defer func() {
+		if err == nil {
+			return
+		}
+		if restoreErr := restoreIndex(repoPath, head, staged); restoreErr != nil {
+			err = errors.Join(err, restoreErr)
+		}
+	}()
+
+	if headErr != nil {
+		head = ""
+		_, err = gitOutput(repoPath, "read-tree", "--empty")
+	} else {
+		_, err = gitOutput(repoPath, "read-tree", head)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reset the index: %w", err)
+	}
+
+	for i, commit := range commits {
+		debug.Printf("Creating split commit %d of %d", i+1, len(commits))
+		if _, err = runGit(repoPath, commit.Patch, "apply", "--cached", "--binary", "-"); err != nil {
+			return fmt.Errorf("failed to stage commit %d: %w", i+1, err)
+		}
+		if _, err = gitOutput(repoPath, "commit", "-q", "-m", commit.Message); err != nil {
+			return fmt.Errorf("failed to create commit %d: %w", i+1, err)
+		}
+	}
+
+	tree, err := gitOutput(repoPath, "write-tree")
+	if err != nil {
+		return fmt.Errorf("failed to check the index: %w", err)
+	}
+	if tree != staged {
+		return errors.New("the commits do not add up to the staged changes")
+	}
+	return nil
+}
+
+// restoreIndex moves the branch back to head, or unborn if head is empty,
+// and restores the index to the tree staged
+func restoreIndex(repoPath, head, staged string) error {
+	var err error
+	if head == "" {
+		_, err = gitOutput(repoPath, "update-ref", "-d", "HEAD")
+	} else {
+		_, err = gitOutput(repoPath, "reset", "-q", "--soft", head)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to undo the commits: %w", err)
+	}
+	if _, err := gitOutput(repoPath, "read-tree", staged); err != nil {
+		return fmt.Errorf("failed to restore the index: %w", err)
+	}
+	return nil
+}
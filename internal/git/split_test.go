@@ -0,0 +1,103 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSplitRepo creates a repository with a committed file of 20 lines and
+// staged changes to its first and last line and a new file
+func newSplitRepo(t *testing.T) string {
+	t.Helper()
+	dir := newHookRepo(t)
+	require.NoError(t, testutils.RunGitCommand(t, dir, "config", "user.email", "test@example.com"))
+	require.NoError(t, testutils.RunGitCommand(t, dir, "config", "user.name", "Test User"))
+
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	file := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	require.NoError(t, testutils.RunGitCommand(t, dir, "add", "a.txt"))
+	require.NoError(t, testutils.RunGitCommand(t, dir, "commit", "-m", "initial"))
+
+	lines[0], lines[19] = "first", "last"
+	require.NoError(t, os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("new\n"), 0644))
+	require.NoError(t, testutils.RunGitCommand(t, dir, "add", "."))
+	return dir
+}
+
+func TestCommitPatches(t *testing.T) {
+	dir := newSplitRepo(t)
+	patch, err := GetStagedPatch(dir)
+	require.NoError(t, err)
+
+	files := ParseDiff(patch)
+	require.Len(t, files, 2)
+	require.Len(t, files[0].Hunks, 2)
+	commits := []PatchCommit{
+		{Message: "fix: change the last line", Patch: files[0].Header + files[0].Hunks[1]},
+		{Message: "feat: change the first line", Patch: files[0].Header + files[0].Hunks[0] + files[1].String()},
+	}
+	require.NoError(t, CommitPatches(dir, commits))
+
+	log, err := gitOutput(dir, "log", "--format=%s")
+	require.NoError(t, err)
+	assert.Equal(t, "feat: change the first line\nfix: change the last line\ninitial", log)
+	staged, err := gitOutput(dir, "diff", "--staged")
+	require.NoError(t, err)
+	assert.Empty(t, staged)
+	show, err := gitOutput(dir, "show", "HEAD~1:a.txt")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(show, "line 1\n"))
+	assert.True(t, strings.HasSuffix(show, "\nlast"))
+}
+
+func TestCommitPatches_Restore(t *testing.T) {
+	dir := newSplitRepo(t)
+	patch, err := GetStagedPatch(dir)
+	require.NoError(t, err)
+	head, err := gitOutput(dir, "rev-parse", "HEAD")
+	require.NoError(t, err)
+
+	files := ParseDiff(patch)
+	tests := []struct {
+		name    string
+		commits []PatchCommit
+	}{
+		{
+			name: "invalid patch",
+			commits: []PatchCommit{
+				{Message: "fix: change the last line", Patch: files[0].Header + files[0].Hunks[1]},
+				{Message: "feat: broken", Patch: "not a patch"},
+			},
+		},
+		{
+			name: "missing changes",
+			commits: []PatchCommit{
+				{Message: "fix: change the last line", Patch: files[0].Header + files[0].Hunks[1]},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, CommitPatches(dir, tt.commits))
+
+			got, err := gitOutput(dir, "rev-parse", "HEAD")
+			require.NoError(t, err)
+			assert.Equal(t, head, got)
+			restored, err := GetStagedPatch(dir)
+			require.NoError(t, err)
+			assert.Equal(t, patch, restored)
+		})
+	}
+}
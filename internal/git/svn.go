@@ -2,8 +2,10 @@ package git
 
 import (
 	"bytes"
+	"encoding/xml"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/belingud/go-gptcomet/internal/config"
@@ -111,6 +113,83 @@ func (s *SVNVCS) CreateCommit(repoPath, message string) error {
 	return err
 }
 
+// GetRangeLog returns the log of the revisions after base, a revision
+// number such as 1234 or r1234. base itself is left out.
+func (s *SVNVCS) GetRangeLog(repoPath, base string) (string, error) {
+	next, err := nextRevision(base)
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.Command("svn", "log", "-r", next+":HEAD")
+	return s.runCommand(cmd, repoPath)
+}
+
+// GetRangeDiff returns the changes of the revisions after base, excluding
+// the files that match the patterns of the "file_ignore" key. svn diff
+// compares base with HEAD, so the changes of base itself are left out.
+func (s *SVNVCS) GetRangeDiff(repoPath, base string, cfgManager *config.Manager) (string, error) {
+	if _, err := nextRevision(base); err != nil {
+		return "", err
+	}
+	cmd := exec.Command("svn", "diff", "-r", strings.TrimPrefix(base, "r")+":HEAD")
+	diff, err := s.runCommand(cmd, repoPath)
+	if err != nil {
+		return "", err
+	}
+	return FilterDiff(diff, cfgManager.GetFileIgnore()), nil
+}
+
+// GetRecentCommits returns the latest n revisions up to from, newest
+// first. If paths are given, only revisions touching them are returned.
+func (s *SVNVCS) GetRecentCommits(repoPath, from string, n int, paths []string) ([]Commit, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	args := []string{"log", "--xml", "-l", strconv.Itoa(n)}
+	if from != "" && from != "HEAD" {
+		args = append(args, "-r", from+":1")
+	}
+	args = append(args, paths...)
+	output, err := s.runCommand(exec.Command("svn", args...), repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var log struct {
+		Entries []struct {
+			Revision string `xml:"revision,attr"`
+			Msg      string `xml:"msg"`
+		} `xml:"logentry"`
+	}
+	if err := xml.Unmarshal([]byte(output), &log); err != nil {
+		return nil, fmt.Errorf("failed to parse svn log: %w", err)
+	}
+
+	var commits []Commit
+	for _, entry := range log.Entries {
+		subject, body, _ := strings.Cut(strings.TrimSpace(entry.Msg), "\n")
+		if subject == "" {
+			continue
+		}
+		commits = append(commits, Commit{
+			Hash:    "r" + entry.Revision,
+			Subject: subject,
+			Body:    strings.TrimSpace(body),
+		})
+	}
+	return commits, nil
+}
+
+// nextRevision returns the number of the revision after base, given as
+// 1234 or r1234
+func nextRevision(base string) (string, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(base, "r"))
+	if err != nil || n < 0 {
+		return "", fmt.Errorf("invalid revision %q, expected a revision number", base)
+	}
+	return strconv.Itoa(n + 1), nil
+}
+
 // runCommand 执行命令并返回输出
 func (s *SVNVCS) runCommand(cmd *exec.Cmd, repoPath string) (string, error) {
 	cmd.Dir = repoPath
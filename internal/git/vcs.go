@@ -20,6 +20,9 @@ type VCS interface {
 	GetCommitInfo(repoPath, commitHash string) (string, error)
 	GetLastCommitHash(repoPath string) (string, error)
 	CreateCommit(repoPath, message string) error
+	GetRangeLog(repoPath, base string) (string, error)
+	GetRangeDiff(repoPath, base string, cfgManager *config.Manager) (string, error)
+	GetRecentCommits(repoPath, from string, n int, paths []string) ([]Commit, error)
 }
 
 // NewVCS creates a new VCS instance based on the type
@@ -0,0 +1,87 @@
+// Package issue finds the key of the ticket a change belongs to in the
+// branch name, such as PROJ-1234 in feature/PROJ-1234-add-cache, and
+// describes where commit messages reference it.
+package issue
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Placements of the key in the commit message
+const (
+	PlacementPrefix  = "prefix"  // at the start of the subject
+	PlacementFooter  = "footer"  // in a "Refs: KEY" footer
+	PlacementTrailer = "trailer" // in a "<Trailer>: KEY" trailer
+)
+
+// FooterToken is the token of the footer referencing the key
+const FooterToken = "Refs"
+
+// DefaultTrailer is the token of the trailer referencing the key
+const DefaultTrailer = "Issue"
+
+// Placements lists the valid placements
+var Placements = []string{PlacementPrefix, PlacementFooter, PlacementTrailer}
+
+// Settings configures the extraction and the placement of the key
+type Settings struct {
+	Pattern   *regexp.Regexp // finds the key in the branch name, nil disables the extraction
+	Placement string
+	Trailer   string // token of the trailer with PlacementTrailer
+}
+
+// DefaultSettings returns the settings used when none are configured
+func DefaultSettings() Settings {
+	return Settings{Placement: PlacementPrefix, Trailer: DefaultTrailer}
+}
+
+// ValidPlacement reports whether placement is one of Placements
+func ValidPlacement(placement string) bool {
+	for _, p := range Placements {
+		if p == placement {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the key in branch, the first group of the pattern if it
+// has one or else the whole match. It is empty if there is none.
+func (s Settings) Find(branch string) string {
+	if s.Pattern == nil {
+		return ""
+	}
+	m := s.Pattern.FindStringSubmatch(branch)
+	switch {
+	case m == nil:
+		return ""
+	case len(m) > 1:
+		return m[1]
+	default:
+		return m[0]
+	}
+}
+
+// Token returns the token of the footer or trailer referencing the key,
+// empty with PlacementPrefix
+func (s Settings) Token() string {
+	switch s.Placement {
+	case PlacementFooter:
+		return FooterToken
+	case PlacementTrailer:
+		if s.Trailer == "" {
+			return DefaultTrailer
+		}
+		return s.Trailer
+	}
+	return ""
+}
+
+// Instructions asks the model to reference key where s places it
+func (s Settings) Instructions(key string) string {
+	if s.Placement == PlacementPrefix {
+		return fmt.Sprintf("The change belongs to the ticket %s, start the subject with it, as in type(scope): %s subject.", key, key)
+	}
+	return fmt.Sprintf("The change belongs to the ticket %s, end the message with the line \"%s: %s\" after a blank line.", key, s.Token(), key)
+}
@@ -0,0 +1,35 @@
+package issue
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFind(t *testing.T) {
+	s := DefaultSettings()
+	assert.Empty(t, s.Find("feature/PROJ-1234-add-cache"))
+
+	s.Pattern = regexp.MustCompile(`[A-Z][A-Z0-9]+-\d+`)
+	assert.Equal(t, "PROJ-1234", s.Find("feature/PROJ-1234-add-cache"))
+	assert.Empty(t, s.Find("main"))
+
+	s.Pattern = regexp.MustCompile(`^\w+/(\d+)-`)
+	assert.Equal(t, "42", s.Find("fix/42-crash"))
+}
+
+func TestToken(t *testing.T) {
+	s := DefaultSettings()
+	assert.Empty(t, s.Token())
+	s.Placement = PlacementFooter
+	assert.Equal(t, "Refs", s.Token())
+	s.Placement = PlacementTrailer
+	assert.Equal(t, "Issue", s.Token())
+	s.Trailer = "Jira"
+	assert.Equal(t, "Jira", s.Token())
+	assert.Contains(t, s.Instructions("PROJ-1"), `"Jira: PROJ-1"`)
+
+	assert.True(t, ValidPlacement(PlacementFooter))
+	assert.False(t, ValidPlacement("suffix"))
+}
@@ -0,0 +1,255 @@
+package lint
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommitlintFiles are the commitlint config files looked for in a
+// repository, in order of precedence
+var CommitlintFiles = []string{
+	".commitlintrc",
+	".commitlintrc.json",
+	".commitlintrc.yaml",
+	".commitlintrc.yml",
+	".commitlintrc.js",
+	".commitlintrc.cjs",
+	".commitlintrc.mjs",
+	"commitlint.config.js",
+	"commitlint.config.cjs",
+	"commitlint.config.mjs",
+}
+
+// conventionalTypes are the types of @commitlint/config-conventional
+var conventionalTypes = []string{"build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor", "revert", "style", "test"}
+
+// Commitlint is a commitlint configuration. Every rule is given as
+// [level, applicable, value], level 0 disables the rule and level 1 only
+// warns about violations.
+type Commitlint struct {
+	Extends []string                 `yaml:"extends"`
+	Rules   map[string][]interface{} `yaml:"rules"`
+}
+
+// ParseCommitlint parses the commitlint config file name with content
+// data. JSON and YAML files are supported, and JavaScript files exporting
+// a plain object literal.
+func ParseCommitlint(name string, data []byte) (*Commitlint, error) {
+	switch filepath.Ext(name) {
+	case ".js", ".cjs", ".mjs":
+		object, err := exportedObject(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		data = []byte(object)
+	}
+
+	var config Commitlint
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return &config, nil
+}
+
+// Apply overrides rules with the rules of the config, and the rules of
+// @commitlint/config-conventional if it extends that
+func (c *Commitlint) Apply(rules *Rules) error {
+	for _, base := range c.Extends {
+		if strings.Contains(base, "config-conventional") {
+			rules.Types = conventionalTypes
+			rules.MaxHeaderLength = 100
+			rules.MaxBodyLineLength = 100
+		}
+	}
+
+	for name, rule := range c.Rules {
+		if len(rule) == 0 {
+			continue
+		}
+		level, ok := ruleInt(rule[0])
+		if !ok {
+			return fmt.Errorf("invalid level of commitlint rule %s: %v", name, rule[0])
+		}
+		if level == 0 {
+			disable(rules, name)
+			continue
+		}
+		rules.Warn = remove(rules.Warn, name)
+		if level == 1 {
+			rules.Warn = append(rules.Warn, name)
+		}
+		applicable := "always"
+		if len(rule) > 1 {
+			applicable, _ = rule[1].(string)
+		}
+		var value interface{}
+		if len(rule) > 2 {
+			value = rule[2]
+		}
+
+		switch name {
+		case RuleTypeEnum:
+			if applicable == "always" {
+				rules.Types = ruleStrings(value)
+			}
+		case RuleScopeEnum:
+			if applicable == "always" {
+				rules.Scopes = ruleStrings(value)
+			}
+		case RuleScopeEmpty:
+			rules.RequireScope = applicable == "never"
+		case RuleHeaderMaxLength:
+			if n, ok := ruleInt(value); ok {
+				rules.MaxHeaderLength = n
+			}
+		case RuleBodyMaxLineLength:
+			if n, ok := ruleInt(value); ok {
+				rules.MaxBodyLineLength = n
+			}
+		}
+	}
+	return nil
+}
+
+// disable turns off the rule name
+func disable(rules *Rules, name string) {
+	switch name {
+	case RuleTypeEnum:
+		rules.Types = nil
+	case RuleScopeEnum:
+		rules.Scopes = nil
+	case RuleScopeEmpty:
+		rules.RequireScope = false
+	case RuleHeaderMaxLength:
+		rules.MaxHeaderLength = 0
+	case RuleBodyMaxLineLength:
+		rules.MaxBodyLineLength = 0
+	default:
+		rules.Ignore = append(rules.Ignore, name)
+	}
+}
+
+// Instructions describes the types, scopes and header length allowed by
+// rules for the prompt
+func Instructions(rules Rules) string {
+	var lines []string
+	if len(rules.Types) > 0 {
+		lines = append(lines, "- the type must be one of: "+strings.Join(rules.Types, ", ")+".")
+	}
+	if len(rules.Scopes) > 0 {
+		lines = append(lines, "- the scope must be one of: "+strings.Join(rules.Scopes, ", ")+".")
+	}
+	if rules.RequireScope {
+		lines = append(lines, "- the title must have a scope, as in type(scope): subject.")
+	}
+	if rules.MaxHeaderLength > 0 {
+		lines = append(lines, fmt.Sprintf("- the title must not be longer than %d characters.", rules.MaxHeaderLength))
+	}
+	return "The repository only accepts commit messages following these rules:\n" + strings.Join(lines, "\n")
+}
+
+// remove returns list without name
+func remove(list []string, name string) []string {
+	result := list[:0:0]
+	for _, item := range list {
+		if item != name {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func ruleInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(v)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func ruleStrings(value interface{}) []string {
+	list, _ := value.([]interface{})
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		if str, ok := item.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
+
+var (
+	// exportPattern matches the start of the exported config
+	exportPattern = regexp.MustCompile(`(?:module\.exports\s*=|export\s+default)\s*`)
+	// commentPattern matches JavaScript comments on their own or at the
+	// end of a line
+	commentPattern = regexp.MustCompile(`(?m)^[ \t]*//.*$|[ \t]+//[^'"\n]*$|/\*[\s\S]*?\*/`)
+	// trailingCommaPattern matches a comma before a closing bracket
+	trailingCommaPattern = regexp.MustCompile(`,(\s*[}\]])`)
+	// keyPattern matches an unquoted object key
+	keyPattern = regexp.MustCompile(`([{,]\s*)([A-Za-z_$][\w$-]*)\s*:`)
+	// severityPattern matches RuleConfigSeverity constants
+	severityPattern = regexp.MustCompile(`RuleConfigSeverity\.(Disabled|Warning|Error)`)
+)
+
+// exportedObject extracts the object literal exported by a JavaScript
+// config and rewrites it as YAML flow syntax. Configs computing their
+// rules are not supported.
+func exportedObject(source string) (string, error) {
+	loc := exportPattern.FindStringIndex(source)
+	if loc == nil {
+		return "", fmt.Errorf("no module.exports or export default found")
+	}
+	source = commentPattern.ReplaceAllString(source[loc[1]:], "")
+	if !strings.HasPrefix(source, "{") {
+		return "", fmt.Errorf("the exported config is not an object literal")
+	}
+
+	depth := 0
+	var quote rune
+	end := -1
+	for i, r := range source {
+		switch {
+		case quote != 0:
+			if r == quote && source[i-1] != '\\' {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '{' || r == '[':
+			depth++
+		case r == '}' || r == ']':
+			depth--
+		}
+		if depth == 0 {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return "", fmt.Errorf("unterminated object literal")
+	}
+
+	object := strings.ReplaceAll(source[:end+1], "`", "'")
+	object = severityPattern.ReplaceAllStringFunc(object, func(s string) string {
+		return map[string]string{
+			"RuleConfigSeverity.Disabled": "0",
+			"RuleConfigSeverity.Warning":  "1",
+			"RuleConfigSeverity.Error":    "2",
+		}[s]
+	})
+	object = trailingCommaPattern.ReplaceAllString(object, "$1")
+	object = keyPattern.ReplaceAllString(object, "$1\"$2\":")
+	return object, nil
+}
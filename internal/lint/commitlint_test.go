@@ -0,0 +1,105 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommitlint(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{
+			name: "json",
+			file: ".commitlintrc.json",
+			data: `{"extends": ["@commitlint/config-conventional"], "rules": {"type-enum": [2, "always", ["feat", "fix"]], "header-max-length": [2, "always", 80], "subject-full-stop": [0]}}`,
+		},
+		{
+			name: "yaml",
+			file: ".commitlintrc.yml",
+			data: "extends: ['@commitlint/config-conventional']\nrules:\n  type-enum: [2, always, [feat, fix]]\n  header-max-length: [2, always, 80]\n  subject-full-stop: [0]\n",
+		},
+		{
+			name: "javascript",
+			file: "commitlint.config.js",
+			data: `// Checked by CI
+const { RuleConfigSeverity } = require('@commitlint/types');
+
+module.exports = {
+  extends: ['@commitlint/config-conventional'], // https://commitlint.js.org
+  /* Only the types we use */
+  rules: {
+    'type-enum': [RuleConfigSeverity.Error, 'always', ['feat', 'fix']],
+    "header-max-length": [2, "always", 80],
+    'subject-full-stop': [0],
+  },
+};
+`,
+		},
+		{
+			name: "esm",
+			file: "commitlint.config.mjs",
+			data: "export default {extends: ['@commitlint/config-conventional'], rules: {'type-enum': [2, `always`, ['feat', 'fix']], 'header-max-length': [2, 'always', 80], 'subject-full-stop': [0]}}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseCommitlint(tt.file, []byte(tt.data))
+			require.NoError(t, err)
+
+			rules := DefaultRules()
+			require.NoError(t, config.Apply(&rules))
+			assert.Equal(t, []string{"feat", "fix"}, rules.Types)
+			assert.Equal(t, 80, rules.MaxHeaderLength)
+			assert.Equal(t, 100, rules.MaxBodyLineLength)
+			assert.Equal(t, []string{RuleSubjectFullStop}, rules.Ignore)
+			assert.Empty(t, Lint("fix: bump deps.", rules))
+		})
+	}
+}
+
+func TestParseCommitlint_Invalid(t *testing.T) {
+	_, err := ParseCommitlint("commitlint.config.js", []byte("module.exports = require('./base');\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not an object literal")
+
+	config, err := ParseCommitlint(".commitlintrc.json", []byte(`{"rules": {"type-enum": ["error", "always", []]}}`))
+	require.NoError(t, err)
+	rules := DefaultRules()
+	assert.Error(t, config.Apply(&rules))
+}
+
+func TestApply_Scope(t *testing.T) {
+	config := &Commitlint{Rules: map[string][]interface{}{
+		RuleScopeEnum:  {2, "always", []interface{}{"api"}},
+		RuleScopeEmpty: {2, "never"},
+	}}
+	rules := DefaultRules()
+	require.NoError(t, config.Apply(&rules))
+	assert.Equal(t, []string{"api"}, rules.Scopes)
+	assert.True(t, rules.RequireScope)
+	assert.Equal(t, []string{RuleScopeEmpty}, ruleNames(Lint("fix: x", rules)))
+}
+
+func TestApply_WarningLevel(t *testing.T) {
+	config := &Commitlint{Rules: map[string][]interface{}{
+		RuleHeaderMaxLength: {1, "always", 20},
+		RuleScopeEmpty:      {2, "never"},
+	}}
+	rules := DefaultRules()
+	require.NoError(t, config.Apply(&rules))
+	assert.Equal(t, []string{RuleHeaderMaxLength}, rules.Warn)
+
+	violations := Lint("fix: handle empty diffs early", rules)
+	assert.ElementsMatch(t, []string{RuleHeaderMaxLength, RuleScopeEmpty}, ruleNames(violations))
+	assert.Equal(t, []string{RuleScopeEmpty}, ruleNames(Errors(violations)))
+	assert.Empty(t, Errors(Lint("fix(api): handle empty diffs", rules)))
+
+	override := &Commitlint{Rules: map[string][]interface{}{RuleHeaderMaxLength: {2, "always", 20}}}
+	require.NoError(t, override.Apply(&rules))
+	assert.Empty(t, rules.Warn)
+}
@@ -0,0 +1,443 @@
+// Package lint checks generated commit messages against the Conventional
+// Commits format and repairs the trivial violations.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/belingud/go-gptcomet/internal/issue"
+	"github.com/belingud/go-gptcomet/internal/prompt"
+)
+
+// Rule names, following the commitlint rule names
+const (
+	RuleHeaderFormat      = "header-format"
+	RuleHeaderMaxLength   = "header-max-length"
+	RuleTypeEnum          = "type-enum"
+	RuleScopeEnum         = "scope-enum"
+	RuleScopeEmpty        = "scope-empty"
+	RuleScopeCandidates   = "scope-candidates"
+	RuleSubjectEmpty      = "subject-empty"
+	RuleSubjectFullStop   = "subject-full-stop"
+	RuleBodyLeadingBlank  = "body-leading-blank"
+	RuleBodyMaxLineLength = "body-max-line-length"
+	RuleReferencesEmpty   = "references-empty"
+)
+
+// DefaultTypes are the commit types allowed by default
+var DefaultTypes = []string{"build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor", "revert", "style", "test"}
+
+// typeAliases maps common misspellings of types to the intended type
+var typeAliases = map[string]string{
+	"feature":  "feat",
+	"features": "feat",
+	"bugfix":   "fix",
+	"hotfix":   "fix",
+	"doc":      "docs",
+	"tests":    "test",
+	"refact":   "refactor",
+}
+
+// headerPattern matches a Conventional Commits header
+var headerPattern = regexp.MustCompile(`^(\w+)(?:\(([^()]*)\))?(!)?: ?(.*)$`)
+
+// Rules configures the linter
+type Rules struct {
+	Enabled           bool
+	Types             []string // allowed types, any if empty
+	Scopes            []string // allowed scopes, any if empty
+	RequireScope      bool
+	MaxHeaderLength   int      // 0 disables the check
+	MaxBodyLineLength int      // 0 disables the check
+	MaxAttempts       int      // times the model is asked to fix remaining violations
+	Ignore            []string // names of rules not checked
+	Warn              []string // names of rules whose violations are only warnings
+	Candidates        []string // scopes inferred from the changed files, one must be used if set
+	IssueKey          string   // ticket key found in the branch name, must be referenced if set
+	Issue             issue.Settings
+}
+
+// DefaultRules returns the rules used when none are configured
+func DefaultRules() Rules {
+	return Rules{
+		Enabled:           true,
+		Types:             DefaultTypes,
+		MaxHeaderLength:   72,
+		MaxBodyLineLength: 100,
+		MaxAttempts:       2,
+	}
+}
+
+// Violation is a broken rule
+type Violation struct {
+	Rule    string
+	Message string
+	Warning bool // the rule only warns, the message is accepted anyway
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+}
+
+// Errors returns the violations that are not warnings
+func Errors(violations []Violation) []Violation {
+	var result []Violation
+	for _, v := range violations {
+		if !v.Warning {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// Header is the parsed first line of a commit message
+type Header struct {
+	Type     string
+	Scope    string
+	Breaking bool
+	Subject  string
+}
+
+// ParseHeader parses a Conventional Commits header. It reports false if
+// header does not have the "type(scope): subject" form.
+func ParseHeader(header string) (Header, bool) {
+	m := headerPattern.FindStringSubmatch(header)
+	if m == nil {
+		return Header{}, false
+	}
+	return Header{Type: m[1], Scope: m[2], Breaking: m[3] != "", Subject: m[4]}, true
+}
+
+// String formats the header
+func (h Header) String() string {
+	var b strings.Builder
+	b.WriteString(h.Type)
+	if h.Scope != "" {
+		b.WriteString("(" + h.Scope + ")")
+	}
+	if h.Breaking {
+		b.WriteString("!")
+	}
+	b.WriteString(": " + h.Subject)
+	return b.String()
+}
+
+// Lint returns the rules msg violates
+func Lint(msg string, rules Rules) []Violation {
+	var violations []Violation
+	add := func(rule, format string, args ...interface{}) {
+		if contains(rules.Ignore, rule) {
+			return
+		}
+		violations = append(violations, Violation{Rule: rule, Message: fmt.Sprintf(format, args...), Warning: contains(rules.Warn, rule)})
+	}
+
+	lines := strings.Split(strings.TrimSpace(msg), "\n")
+	header := lines[0]
+
+	if rules.MaxHeaderLength > 0 && len([]rune(header)) > rules.MaxHeaderLength {
+		add(RuleHeaderMaxLength, "header is %d characters long, the limit is %d", len([]rune(header)), rules.MaxHeaderLength)
+	}
+
+	h, ok := ParseHeader(header)
+	if !ok {
+		add(RuleHeaderFormat, "header %q must have the form \"type(scope): subject\"", header)
+	} else {
+		if len(rules.Types) > 0 && !contains(rules.Types, h.Type) {
+			add(RuleTypeEnum, "type %q must be one of: %s", h.Type, strings.Join(rules.Types, ", "))
+		}
+		if h.Scope == "" && rules.RequireScope {
+			add(RuleScopeEmpty, "scope is required")
+		}
+		if len(rules.Candidates) > 0 && !containsAny(rules.Candidates, splitScopes(h.Scope)) {
+			add(RuleScopeCandidates, "scope must be one of the scopes of the changed files: %s", strings.Join(rules.Candidates, ", "))
+		}
+		if h.Scope != "" && len(rules.Scopes) > 0 {
+			for _, scope := range splitScopes(h.Scope) {
+				if !contains(rules.Scopes, scope) {
+					add(RuleScopeEnum, "scope %q must be one of: %s", scope, strings.Join(rules.Scopes, ", "))
+				}
+			}
+		}
+		if strings.TrimSpace(h.Subject) == "" {
+			add(RuleSubjectEmpty, "subject is empty")
+		} else if strings.HasSuffix(h.Subject, ".") {
+			add(RuleSubjectFullStop, "subject must not end with a full stop")
+		}
+	}
+
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		add(RuleBodyLeadingBlank, "body must be separated from the header by a blank line")
+	}
+	if rules.MaxBodyLineLength > 0 {
+		for i, line := range lines[1:] {
+			if n := len([]rune(line)); n > rules.MaxBodyLineLength && !isUnbreakable(line) {
+				add(RuleBodyMaxLineLength, "body line %d is %d characters long, the limit is %d", i+2, n, rules.MaxBodyLineLength)
+			}
+		}
+	}
+	if rules.IssueKey != "" && !hasIssueKey(lines, rules) {
+		if token := rules.Issue.Token(); token != "" {
+			add(RuleReferencesEmpty, "message must end with the line \"%s: %s\"", token, rules.IssueKey)
+		} else {
+			add(RuleReferencesEmpty, "subject must start with the ticket key %s", rules.IssueKey)
+		}
+	}
+	return violations
+}
+
+// Fix repairs the violations that need no judgment: code fences, a
+// preamble before the header, the case and common misspellings of the
+// type, a missing scope when only one was inferred, a trailing full stop,
+// the blank line after the header, long body lines and a missing ticket
+// key.
+func Fix(msg string, rules Rules) string {
+	lines := stripPreamble(strings.Split(strings.TrimSpace(msg), "\n"))
+	lines = strings.Split(stripFences(strings.Join(lines, "\n")), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t\r")
+	}
+
+	header := strings.Trim(strings.TrimSpace(lines[0]), "`*\"'")
+	if h, ok := ParseHeader(header); ok {
+		h.Type = strings.ToLower(h.Type)
+		if alias, ok := typeAliases[h.Type]; ok && !contains(rules.Types, h.Type) {
+			h.Type = alias
+		}
+		if h.Scope == "" && len(rules.Candidates) == 1 {
+			h.Scope = rules.Candidates[0]
+		}
+		h.Subject = strings.TrimSpace(strings.TrimRight(h.Subject, "."))
+		header = h.String()
+	}
+
+	result := []string{header}
+	body := lines[1:]
+	for len(body) > 0 && strings.TrimSpace(body[0]) == "" {
+		body = body[1:]
+	}
+	if len(body) > 0 {
+		result = append(result, "")
+		for _, line := range body {
+			result = append(result, wrap(line, rules.MaxBodyLineLength)...)
+		}
+	}
+	return AddIssueKey(strings.Join(result, "\n"), rules)
+}
+
+// AddIssueKey references rules.IssueKey in msg where rules.Issue places
+// it, unless msg already does or the references-empty rule is ignored
+func AddIssueKey(msg string, rules Rules) string {
+	if rules.IssueKey == "" || contains(rules.Ignore, RuleReferencesEmpty) {
+		return msg
+	}
+	lines := strings.Split(strings.TrimSpace(msg), "\n")
+	if hasIssueKey(lines, rules) {
+		return msg
+	}
+	return strings.Join(addIssueKey(lines, rules), "\n")
+}
+
+// trailerPattern matches a footer or trailer line, "Token: value"
+var trailerPattern = regexp.MustCompile(`^([A-Za-z][\w-]*|BREAKING CHANGE): \S`)
+
+// hasIssueKey reports whether the lines of a message reference
+// rules.IssueKey where rules.Issue places it
+func hasIssueKey(lines []string, rules Rules) bool {
+	token := rules.Issue.Token()
+	if token == "" {
+		subject := strings.TrimSpace(lines[0])
+		if h, ok := ParseHeader(subject); ok {
+			subject = strings.TrimSpace(h.Subject)
+		}
+		rest := strings.TrimPrefix(subject, rules.IssueKey)
+		return rest != subject && (rest == "" || !isKeyChar(rest[0]))
+	}
+
+	for _, line := range lastParagraph(lines) {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), token) {
+			continue
+		}
+		for _, key := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if key == rules.IssueKey {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addIssueKey references rules.IssueKey in the lines of a message where
+// rules.Issue places it. A footer or trailer joins the footers or trailers
+// ending the message, if there are any.
+func addIssueKey(lines []string, rules Rules) []string {
+	token := rules.Issue.Token()
+	if token == "" {
+		if h, ok := ParseHeader(lines[0]); ok {
+			h.Subject = rules.IssueKey + " " + h.Subject
+			lines[0] = h.String()
+		} else {
+			lines[0] = rules.IssueKey + " " + lines[0]
+		}
+		return lines
+	}
+
+	line := token + ": " + rules.IssueKey
+	footers := lastParagraph(lines)
+	for _, footer := range footers {
+		if !trailerPattern.MatchString(footer) {
+			footers = nil
+			break
+		}
+	}
+	if len(footers) == 0 {
+		lines = append(lines, "")
+	}
+	return append(lines, line)
+}
+
+// lastParagraph returns the lines of the last paragraph of a message
+// after the header, none if there is only the header
+func lastParagraph(lines []string) []string {
+	start := len(lines)
+	for start > 1 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+	if start <= 1 {
+		return nil
+	}
+	return lines[start:]
+}
+
+// isKeyChar reports whether c can continue a ticket key
+func isKeyChar(c byte) bool {
+	return c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
+
+// Report formats msg and its violations for the model to fix
+func Report(msg string, violations []Violation) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	b.WriteString("\n\nViolations:\n")
+	for _, v := range violations {
+		b.WriteString("- " + v.String() + "\n")
+	}
+	return b.String()
+}
+
+// InsertInstructions adds instructions to the user part of prompt p,
+// before the paragraph presenting the diff
+func InsertInstructions(p prompt.Prompt, instructions string) prompt.Prompt {
+	user := p.User
+	instructions += "\n\n"
+	at := strings.Index(user, prompt.Placeholder)
+	if at >= 0 {
+		at = strings.LastIndex(user[:at], "\n\n") + 2
+	}
+	if at < 2 {
+		user = instructions + user
+	} else {
+		user = user[:at] + instructions + user[at:]
+	}
+	p.User = user
+	return p
+}
+
+// stripFences removes a markdown code fence around msg
+func stripFences(msg string) string {
+	lines := strings.Split(strings.TrimSpace(msg), "\n")
+	if strings.HasPrefix(lines[0], "```") {
+		lines = lines[1:]
+	}
+	if n := len(lines); n > 0 && strings.TrimSpace(lines[n-1]) == "```" {
+		lines = lines[:n-1]
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// preamblePattern matches chatty lines models put before the message
+var preamblePattern = regexp.MustCompile(`(?i)^(here(?:'s| is| are)|sure|certainly|okay|ok|the commit message|commit message)\b|:$`)
+
+// stripPreamble drops the lines before the first valid header if all of
+// them are chatter
+func stripPreamble(lines []string) []string {
+	for i, line := range lines {
+		if _, ok := ParseHeader(strings.Trim(strings.TrimSpace(line), "`*\"'")); ok {
+			for _, skipped := range lines[:i] {
+				skipped = strings.TrimSpace(skipped)
+				if skipped != "" && !strings.HasPrefix(skipped, "```") && !preamblePattern.MatchString(skipped) {
+					return lines
+				}
+			}
+			return lines[i:]
+		}
+	}
+	return lines
+}
+
+// wrap breaks line at word boundaries so no part exceeds width. Lines
+// continuing a bullet are indented like its text.
+func wrap(line string, width int) []string {
+	if width <= 0 || len([]rune(line)) <= width || isUnbreakable(line) {
+		return []string{line}
+	}
+
+	indent := ""
+	trimmed := strings.TrimLeft(line, " ")
+	lead := line[:len(line)-len(trimmed)]
+	if strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") {
+		indent = lead + "  "
+	} else {
+		indent = lead
+	}
+
+	var result []string
+	current := ""
+	for _, word := range strings.Fields(line) {
+		switch {
+		case current == "":
+			current = lead + word
+		case len([]rune(current))+1+len([]rune(word)) > width:
+			result = append(result, current)
+			current = indent + word
+		default:
+			current += " " + word
+		}
+	}
+	return append(result, current)
+}
+
+// isUnbreakable reports whether line is a single word, such as a URL
+func isUnbreakable(line string) bool {
+	return len(strings.Fields(line)) <= 1
+}
+
+// splitScopes splits multiple scopes given as "a,b" or "a/b"
+func splitScopes(scope string) []string {
+	parts := strings.FieldsFunc(scope, func(r rune) bool { return r == ',' || r == '/' })
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+func containsAny(list, items []string) bool {
+	for _, item := range items {
+		if contains(list, item) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/belingud/go-gptcomet/internal/issue"
+	"github.com/belingud/go-gptcomet/internal/prompt"
+	"github.com/stretchr/testify/assert"
+)
+
+// ruleNames returns the violated rule names
+func ruleNames(violations []Violation) []string {
+	var names []string
+	for _, v := range violations {
+		names = append(names, v.Rule)
+	}
+	return names
+}
+
+func TestParseHeader(t *testing.T) {
+	h, ok := ParseHeader("feat(api)!: drop the v1 endpoints")
+	assert.True(t, ok)
+	assert.Equal(t, Header{Type: "feat", Scope: "api", Breaking: true, Subject: "drop the v1 endpoints"}, h)
+	assert.Equal(t, "feat(api)!: drop the v1 endpoints", h.String())
+
+	_, ok = ParseHeader("Update the readme")
+	assert.False(t, ok)
+}
+
+func TestLint(t *testing.T) {
+	defaults := DefaultRules()
+	strict := DefaultRules()
+	strict.Scopes = []string{"api", "cli"}
+	strict.RequireScope = true
+	inferred := DefaultRules()
+	inferred.Candidates = []string{"llm", "cli"}
+
+	tests := []struct {
+		name  string
+		msg   string
+		rules Rules
+		want  []string
+	}{
+		{name: "valid", msg: "fix: handle empty diffs\n\n- return early", rules: defaults},
+		{name: "no type", msg: "Handle empty diffs", rules: defaults, want: []string{RuleHeaderFormat}},
+		{name: "unknown type", msg: "feature: add hook", rules: defaults, want: []string{RuleTypeEnum}},
+		{name: "full stop", msg: "fix: handle empty diffs.", rules: defaults, want: []string{RuleSubjectFullStop}},
+		{name: "empty subject", msg: "fix: ", rules: defaults, want: []string{RuleSubjectEmpty}},
+		{name: "long header", msg: "fix: " + strings.Repeat("a", 70), rules: defaults, want: []string{RuleHeaderMaxLength}},
+		{name: "no blank line", msg: "fix: handle empty diffs\n- return early", rules: defaults, want: []string{RuleBodyLeadingBlank}},
+		{name: "long body line", msg: "fix: x\n\n" + strings.Repeat("word ", 30), rules: defaults, want: []string{RuleBodyMaxLineLength}},
+		{name: "long url", msg: "fix: x\n\nhttps://example.com/" + strings.Repeat("a", 100), rules: defaults},
+		{name: "missing scope", msg: "fix: x", rules: strict, want: []string{RuleScopeEmpty}},
+		{name: "unknown scope", msg: "fix(api,web): x", rules: strict, want: []string{RuleScopeEnum}},
+		{name: "inferred scope", msg: "fix(cli): x", rules: inferred},
+		{name: "not inferred scope", msg: "fix(api): x", rules: inferred, want: []string{RuleScopeCandidates}},
+		{name: "missing inferred scope", msg: "fix: x", rules: inferred, want: []string{RuleScopeCandidates}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ruleNames(Lint(tt.msg, tt.rules)))
+		})
+	}
+}
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{
+			name: "fences and preamble",
+			msg:  "Here is your commit message:\n```\nFeature: add hook mode.\n- install the hook\n```",
+			want: "feat: add hook mode\n\n- install the hook",
+		},
+		{
+			name: "quoted header",
+			msg:  "`fix: handle empty diffs`",
+			want: "fix: handle empty diffs",
+		},
+		{
+			name: "wraps bullets",
+			msg:  "fix: x\n\n- " + strings.Repeat("word ", 25),
+			want: "fix: x\n\n- " + strings.TrimSpace(strings.Repeat("word ", 19)) + "\n  " + strings.TrimSpace(strings.Repeat("word ", 6)),
+		},
+		{
+			name: "keeps text before the header",
+			msg:  "The diff is empty\nfix: x",
+			want: "The diff is empty\n\nfix: x",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Fix(tt.msg, DefaultRules()))
+		})
+	}
+}
+
+func TestFix_InferredScope(t *testing.T) {
+	rules := DefaultRules()
+	rules.Candidates = []string{"llm"}
+	assert.Equal(t, "feat(llm): add provider", Fix("feat: add provider", rules))
+	assert.Equal(t, "feat(cli): add provider", Fix("feat(cli): add provider", rules))
+
+	rules.Candidates = []string{"llm", "cli"}
+	assert.Equal(t, "feat: add provider", Fix("feat: add provider", rules))
+}
+
+func TestIssueKey(t *testing.T) {
+	prefix := DefaultRules()
+	prefix.IssueKey = "PROJ-12"
+	prefix.Issue = issue.DefaultSettings()
+	footer := prefix
+	footer.Issue.Placement = issue.PlacementFooter
+	trailer := prefix
+	trailer.Issue.Placement = issue.PlacementTrailer
+
+	tests := []struct {
+		name  string
+		msg   string
+		rules Rules
+		fixed string
+	}{
+		{name: "prefix", msg: "feat: PROJ-12 add cache", rules: prefix},
+		{name: "missing prefix", msg: "feat: add cache", rules: prefix, fixed: "feat: PROJ-12 add cache"},
+		{name: "longer key", msg: "feat: PROJ-123 add cache", rules: prefix, fixed: "feat: PROJ-12 PROJ-123 add cache"},
+		{name: "prefix without type", msg: "PROJ-12 Add cache", rules: prefix},
+		{name: "footer", msg: "feat: add cache\n\nRefs: PROJ-7, PROJ-12", rules: footer},
+		{name: "key in body", msg: "feat: add cache\n\nFor PROJ-12.", rules: footer, fixed: "feat: add cache\n\nFor PROJ-12.\n\nRefs: PROJ-12"},
+		{name: "joins footers", msg: "feat: add cache\n\n- keep it\n\nBREAKING CHANGE: new format", rules: footer, fixed: "feat: add cache\n\n- keep it\n\nBREAKING CHANGE: new format\nRefs: PROJ-12"},
+		{name: "trailer", msg: "feat: add cache\n\nIssue: PROJ-12", rules: trailer},
+		{name: "missing trailer", msg: "feat: add cache", rules: trailer, fixed: "feat: add cache\n\nIssue: PROJ-12"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.fixed == "" {
+				assert.NotContains(t, ruleNames(Lint(tt.msg, tt.rules)), RuleReferencesEmpty)
+				return
+			}
+			assert.Equal(t, []string{RuleReferencesEmpty}, ruleNames(Lint(tt.msg, tt.rules)))
+			assert.Equal(t, tt.fixed, Fix(tt.msg, tt.rules))
+			assert.Empty(t, Lint(tt.fixed, tt.rules))
+		})
+	}
+
+	prefix.Ignore = []string{RuleReferencesEmpty}
+	assert.Equal(t, "feat: add cache", Fix("feat: add cache", prefix))
+}
+
+func TestInsertInstructions(t *testing.T) {
+	p := prompt.Prompt{System: "Be brief.", User: "Write a message.\n\nThe diff:\n{{ placeholder }}\n\nCommit Message:"}
+	assert.Equal(t, prompt.Prompt{System: "Be brief.", User: "Write a message.\n\nUse a scope.\n\nThe diff:\n{{ placeholder }}\n\nCommit Message:"}, InsertInstructions(p, "Use a scope."))
+	assert.Equal(t, prompt.Prompt{User: "Use a scope.\n\n{{ placeholder }}"}, InsertInstructions(prompt.Prompt{User: "{{ placeholder }}"}, "Use a scope."))
+}
+
+func TestReport(t *testing.T) {
+	report := Report("update", Lint("update", DefaultRules()))
+	assert.Equal(t, "update\n\nViolations:\n- header-format: header \"update\" must have the form \"type(scope): subject\"\n", report)
+}
@@ -1,7 +1,9 @@
 package llm
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/belingud/go-gptcomet/pkg/config"
@@ -87,3 +89,13 @@ func (a *AzureLLM) BuildHeaders() map[string]string {
 	}
 	return headers
 }
+
+// MakeStreamRequest makes a streaming request to the API
+func (a *AzureLLM) MakeStreamRequest(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	return a.BaseLLM.MakeStreamRequest(ctx, client, a, message, history, onChunk)
+}
+
+// MakeChoicesRequest makes a request for n answers with the "n" parameter
+func (a *AzureLLM) MakeChoicesRequest(ctx context.Context, client *http.Client, message string, history []types.Message, n int) (*types.CompletionResponse, error) {
+	return a.BaseLLM.MakeChoicesRequest(ctx, client, a, message, history, n)
+}
@@ -72,9 +72,16 @@ func (c *ClaudeLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 
 // FormatMessages formats messages for Claude API
 func (c *ClaudeLLM) FormatMessages(message string, history []types.Message) (interface{}, error) {
-	messages := make([]map[string]interface{}, 0, len(history)+1)
+	system, rest := splitSystem(history)
+	messages := make([]map[string]interface{}, 0, len(rest)+1)
+	for _, m := range rest {
+		messages = append(messages, map[string]interface{}{
+			"role":    m.Role,
+			"content": m.Content,
+		})
+	}
 	messages = append(messages, map[string]interface{}{
-		"role":    "user",
+		"role":    types.RoleUser,
 		"content": message,
 	})
 
@@ -87,6 +94,10 @@ func (c *ClaudeLLM) FormatMessages(message string, history []types.Message) (int
 		"frequency_penalty": c.Config.FrequencyPenalty,
 		"presence_penalty":  c.Config.PresencePenalty,
 	}
+	// Claude takes the system prompt as a top-level field, not as a message
+	if system != "" {
+		payload["system"] = system
+	}
 
 	return payload, nil
 }
@@ -119,21 +130,39 @@ func (c *ClaudeLLM) ParseResponse(response []byte) (string, error) {
 	return strings.TrimSpace(text), nil
 }
 
-// GetUsage returns usage information for the provider
-func (c *ClaudeLLM) GetUsage(data []byte) (string, error) {
+// GetUsage returns the token usage of a messages response. The stream
+// reports it in the message_start event under "message.usage" and the
+// final output count in message_delta. Claude counts cache reads and writes
+// apart from input_tokens, they are added to the input here.
+func (c *ClaudeLLM) GetUsage(data []byte) (*types.Usage, error) {
 	usage := gjson.GetBytes(data, "usage")
-	if !usage.Exists() {
-		return "", nil
+	if !usage.IsObject() {
+		usage = gjson.GetBytes(data, "message.usage")
+	}
+	if !usage.IsObject() {
+		return nil, nil
 	}
 
-	return fmt.Sprintf(
-		"Token usage> input tokens: %d, output tokens: %d",
-		usage.Get("input_tokens").Int(),
-		usage.Get("output_tokens").Int(),
-	), nil
+	cached := usage.Get("cache_read_input_tokens").Int()
+	input := usage.Get("input_tokens").Int() + cached + usage.Get("cache_creation_input_tokens").Int()
+	return newUsage(input, usage.Get("output_tokens").Int(), cached, 0), nil
 }
 
 // MakeRequest makes a request to the API
-func (c *ClaudeLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+func (c *ClaudeLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (*types.CompletionResponse, error) {
 	return c.BaseLLM.MakeRequest(ctx, client, c, message, history)
 }
+
+// ParseStreamChunk parses the text delta from a Claude messages stream event.
+// Only content_block_delta events carry text, all other event types are ignored.
+func (c *ClaudeLLM) ParseStreamChunk(data []byte) (string, error) {
+	if gjson.GetBytes(data, "type").String() != "content_block_delta" {
+		return "", nil
+	}
+	return gjson.GetBytes(data, "delta.text").String(), nil
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (c *ClaudeLLM) MakeStreamRequest(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	return c.BaseLLM.MakeStreamRequest(ctx, client, c, message, history, onChunk)
+}
@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/belingud/go-gptcomet/pkg/types"
@@ -202,3 +203,76 @@ func TestClaudeLLM_BuildHeaders(t *testing.T) {
 		})
 	}
 }
+
+func TestClaudeLLM_FormatMessages(t *testing.T) {
+	llm := NewClaudeLLM(&types.ClientConfig{Model: "claude-3-5-sonnet-latest", MaxTokens: 1024})
+	history := []types.Message{
+		{Role: types.RoleSystem, Content: "be brief"},
+		{Role: types.RoleUser, Content: "example diff"},
+		{Role: types.RoleAssistant, Content: "feat: example"},
+	}
+
+	got, err := llm.FormatMessages("test message", history)
+	if err != nil {
+		t.Fatalf("FormatMessages() error = %v", err)
+	}
+	payload := got.(map[string]interface{})
+
+	if payload["system"] != "be brief" {
+		t.Errorf("system = %v, want be brief", payload["system"])
+	}
+	want := []map[string]interface{}{
+		{"role": "user", "content": "example diff"},
+		{"role": "assistant", "content": "feat: example"},
+		{"role": "user", "content": "test message"},
+	}
+	if !reflect.DeepEqual(payload["messages"], want) {
+		t.Errorf("messages = %v, want %v", payload["messages"], want)
+	}
+
+	got, err = llm.FormatMessages("test message", nil)
+	if err != nil {
+		t.Fatalf("FormatMessages() error = %v", err)
+	}
+	if _, ok := got.(map[string]interface{})["system"]; ok {
+		t.Errorf("system is set without a system message")
+	}
+}
+
+func TestClaudeLLM_GetUsage(t *testing.T) {
+	llm := NewClaudeLLM(&types.ClientConfig{})
+	tests := []struct {
+		name string
+		data []byte
+		want *types.Usage
+	}{
+		{
+			name: "messages response",
+			data: []byte(`{"usage": {"input_tokens": 10, "cache_read_input_tokens": 5, "cache_creation_input_tokens": 2, "output_tokens": 20}}`),
+			want: &types.Usage{InputTokens: 17, OutputTokens: 20, CachedTokens: 5, TotalTokens: 37},
+		},
+		{
+			name: "message_start event",
+			data: []byte(`{"type": "message_start", "message": {"usage": {"input_tokens": 10, "output_tokens": 1}}}`),
+			want: &types.Usage{InputTokens: 10, OutputTokens: 1, TotalTokens: 11},
+		},
+		{
+			name: "no usage info",
+			data: []byte(`{}`),
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := llm.GetUsage(tt.data)
+			if err != nil {
+				t.Errorf("GetUsage() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetUsage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package llm
 
 import (
-	"fmt"
+	"context"
+	"net/http"
+	"strings"
 
 	"github.com/belingud/go-gptcomet/pkg/config"
 	"github.com/belingud/go-gptcomet/pkg/types"
@@ -54,37 +56,76 @@ func (c *CohereLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 	}
 }
 
-// FormatMessages formats messages for Cohere API
+// FormatMessages formats messages for Cohere API. The v2 API takes the
+// system prompt as a system message, the v1 API as the preamble.
 func (c *CohereLLM) FormatMessages(message string, history []types.Message) (interface{}, error) {
-
-	messages := []map[string]string{
-		{
-			"role":    "user",
-			"content": message,
-		},
-	}
-
+	system, rest := splitSystem(history)
 	payload := map[string]interface{}{
-		"messages":    messages,
 		"model":       c.Config.Model,
 		"stream":      false,
 		"max_tokens":  c.Config.MaxTokens,
 		"temperature": c.Config.Temperature,
 	}
 
+	if strings.HasSuffix(strings.TrimSuffix(c.Config.APIBase, "/"), "/v1") {
+		chatHistory := make([]map[string]string, 0, len(rest))
+		for _, m := range rest {
+			role := "USER"
+			if m.Role == types.RoleAssistant {
+				role = "CHATBOT"
+			}
+			chatHistory = append(chatHistory, map[string]string{"role": role, "message": m.Content})
+		}
+		payload["message"] = message
+		payload["chat_history"] = chatHistory
+		if system != "" {
+			payload["preamble"] = system
+		}
+		return payload, nil
+	}
+
+	messages := make([]map[string]string, 0, len(rest)+2)
+	if system != "" {
+		messages = append(messages, map[string]string{"role": types.RoleSystem, "content": system})
+	}
+	for _, m := range rest {
+		messages = append(messages, map[string]string{"role": m.Role, "content": m.Content})
+	}
+	messages = append(messages, map[string]string{"role": types.RoleUser, "content": message})
+	payload["messages"] = messages
+
 	return payload, nil
 }
 
-// GetUsage returns usage information for the provider
-func (c *CohereLLM) GetUsage(response []byte) (string, error) {
+// GetUsage returns the token usage of a chat response. The stream reports
+// it in the message-end event under "delta.usage".
+func (c *CohereLLM) GetUsage(response []byte) (*types.Usage, error) {
 	usage := gjson.GetBytes(response, "usage")
-	if !usage.Exists() {
-		return "", nil
+	if !usage.IsObject() {
+		usage = gjson.GetBytes(response, "delta.usage")
+	}
+	if !usage.IsObject() {
+		return nil, nil
 	}
 
-	return fmt.Sprintf(
-		"Token usage> input: %d, output: %d",
-		usage.Get("input_tokens").Int(),
-		usage.Get("output_tokens").Int(),
+	return newUsage(
+		firstInt(usage, "tokens.input_tokens", "input_tokens", "billed_units.input_tokens"),
+		firstInt(usage, "tokens.output_tokens", "output_tokens", "billed_units.output_tokens"),
+		0,
+		0,
 	), nil
 }
+
+// ParseStreamChunk parses the text delta from a Cohere v2 chat stream event.
+// Only content-delta events carry text.
+func (c *CohereLLM) ParseStreamChunk(data []byte) (string, error) {
+	if gjson.GetBytes(data, "type").String() != "content-delta" {
+		return "", nil
+	}
+	return gjson.GetBytes(data, "delta.message.content.text").String(), nil
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (c *CohereLLM) MakeStreamRequest(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	return c.BaseLLM.MakeStreamRequest(ctx, client, c, message, history, onChunk)
+}
@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/belingud/go-gptcomet/pkg/types"
@@ -87,3 +88,81 @@ func TestCohereLLM_GetRequiredConfig(t *testing.T) {
 		t.Errorf("GetRequiredConfig() model default value = %v, want %v", got["model"].DefaultValue, "command-r-plus")
 	}
 }
+
+func TestCohereLLM_GetUsage(t *testing.T) {
+	llm := NewCohereLLM(&types.ClientConfig{})
+	tests := []struct {
+		name string
+		data []byte
+		want *types.Usage
+	}{
+		{
+			name: "v2 tokens",
+			data: []byte(`{"usage": {"tokens": {"input_tokens": 10, "output_tokens": 20}, "billed_units": {"input_tokens": 8, "output_tokens": 20}}}`),
+			want: &types.Usage{InputTokens: 10, OutputTokens: 20, TotalTokens: 30},
+		},
+		{
+			name: "message-end event",
+			data: []byte(`{"type": "message-end", "delta": {"usage": {"tokens": {"input_tokens": 3, "output_tokens": 4}}}}`),
+			want: &types.Usage{InputTokens: 3, OutputTokens: 4, TotalTokens: 7},
+		},
+		{
+			name: "no usage info",
+			data: []byte(`{}`),
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := llm.GetUsage(tt.data)
+			if err != nil {
+				t.Errorf("GetUsage() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetUsage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCohereLLM_FormatMessages(t *testing.T) {
+	history := []types.Message{
+		{Role: types.RoleSystem, Content: "be brief"},
+		{Role: types.RoleUser, Content: "example diff"},
+		{Role: types.RoleAssistant, Content: "feat: example"},
+	}
+
+	llm := NewCohereLLM(&types.ClientConfig{})
+	got, err := llm.FormatMessages("test message", history)
+	if err != nil {
+		t.Fatalf("FormatMessages() error = %v", err)
+	}
+	want := []map[string]string{
+		{"role": "system", "content": "be brief"},
+		{"role": "user", "content": "example diff"},
+		{"role": "assistant", "content": "feat: example"},
+		{"role": "user", "content": "test message"},
+	}
+	if messages := got.(map[string]interface{})["messages"]; !reflect.DeepEqual(messages, want) {
+		t.Errorf("messages = %v, want %v", messages, want)
+	}
+
+	llm = NewCohereLLM(&types.ClientConfig{APIBase: "https://api.cohere.ai/v1"})
+	got, err = llm.FormatMessages("test message", history)
+	if err != nil {
+		t.Fatalf("FormatMessages() error = %v", err)
+	}
+	payload := got.(map[string]interface{})
+	if payload["preamble"] != "be brief" || payload["message"] != "test message" {
+		t.Errorf("preamble = %v, message = %v", payload["preamble"], payload["message"])
+	}
+	wantHistory := []map[string]string{
+		{"role": "USER", "message": "example diff"},
+		{"role": "CHATBOT", "message": "feat: example"},
+	}
+	if !reflect.DeepEqual(payload["chat_history"], wantHistory) {
+		t.Errorf("chat_history = %v, want %v", payload["chat_history"], wantHistory)
+	}
+}
@@ -0,0 +1,260 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/belingud/go-gptcomet/pkg/config"
+	"github.com/belingud/go-gptcomet/pkg/types"
+)
+
+// Auth styles of a custom provider
+const (
+	AuthBearer = "bearer"  // Authorization: Bearer <api_key>
+	AuthAPIKey = "api-key" // api-key: <api_key>
+	AuthHeader = "header"  // <auth_header>: <api_key>
+	AuthQuery  = "query"   // ?<auth_param>=<api_key>
+	AuthNone   = "none"    // no api_key needed
+)
+
+// UsagePaths are the gjson paths of the token counts in a response
+type UsagePaths struct {
+	Input  string `yaml:"input"`
+	Output string `yaml:"output"`
+	Cached string `yaml:"cached"`
+	Total  string `yaml:"total"`
+}
+
+// CustomProfile describes an OpenAI-compatible endpoint declared under
+// custom_providers in the config, such as vLLM, LiteLLM or OpenRouter
+type CustomProfile struct {
+	Name           string `yaml:"-"`
+	DisplayName    string `yaml:"display_name"`
+	APIBase        string `yaml:"api_base"`
+	CompletionPath string `yaml:"completion_path"`
+	Model          string `yaml:"model"`
+	Auth           string `yaml:"auth"`        // one of the Auth styles, bearer if empty
+	AuthHeader     string `yaml:"auth_header"` // header carrying the key for the header style
+	AuthParam      string `yaml:"auth_param"`  // query parameter carrying the key for the query style
+	AnswerPath     string `yaml:"answer_path"`
+	StreamPath     string `yaml:"stream_path"` // text delta of a stream event
+	// UsagePaths defaults to the OpenAI usage object when empty
+	UsagePaths UsagePaths `yaml:"usage_paths"`
+	// Fields renames payload fields, e.g. max_tokens: max_completion_tokens.
+	// Fields mapped to an empty name are dropped from the payload.
+	Fields map[string]string `yaml:"fields"`
+}
+
+// Validate checks the profile and fills in its defaults
+func (p *CustomProfile) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("provider name cannot be empty")
+	}
+	if p.APIBase == "" {
+		return fmt.Errorf("api_base is required")
+	}
+	if p.DisplayName == "" {
+		p.DisplayName = p.Name
+	}
+	if p.Auth == "" {
+		p.Auth = AuthBearer
+	}
+	switch p.Auth {
+	case AuthBearer, AuthAPIKey, AuthNone:
+	case AuthHeader:
+		if p.AuthHeader == "" {
+			return fmt.Errorf("auth_header is required for the %s auth style", AuthHeader)
+		}
+	case AuthQuery:
+		if p.AuthParam == "" {
+			return fmt.Errorf("auth_param is required for the %s auth style", AuthQuery)
+		}
+	default:
+		return fmt.Errorf("invalid auth style %q, must be one of: %s, %s, %s, %s, %s",
+			p.Auth, AuthBearer, AuthAPIKey, AuthHeader, AuthQuery, AuthNone)
+	}
+	return nil
+}
+
+// RegisterCustomProvider validates profile and registers it like a built-in
+// provider. Built-in providers cannot be replaced by a profile.
+func RegisterCustomProvider(profile CustomProfile) error {
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+	if builtins[profile.Name] {
+		return fmt.Errorf("%s is a built-in provider", profile.Name)
+	}
+
+	return Register(ProviderInfo{
+		Name:        profile.Name,
+		DisplayName: profile.DisplayName,
+		Constructor: func(config *types.ClientConfig) LLM {
+			return NewCustomLLM(profile, config)
+		},
+		Capabilities: Capabilities{
+			Streaming:      true,
+			RequiresAPIKey: profile.Auth != AuthNone,
+		},
+	})
+}
+
+// CustomLLM is an OpenAI-compatible provider described by a CustomProfile
+type CustomLLM struct {
+	*BaseLLM
+	profile CustomProfile
+}
+
+// NewCustomLLM creates a new CustomLLM, taking the settings missing from
+// config from profile
+func NewCustomLLM(profile CustomProfile, config *types.ClientConfig) *CustomLLM {
+	if config.APIBase == "" {
+		config.APIBase = profile.APIBase
+	}
+	if config.Model == "" {
+		config.Model = profile.Model
+	}
+	if config.CompletionPath == "" {
+		config.CompletionPath = profile.CompletionPath
+	}
+	if config.AnswerPath == "" {
+		config.AnswerPath = profile.AnswerPath
+	}
+
+	return &CustomLLM{
+		BaseLLM: NewBaseLLM(config),
+		profile: profile,
+	}
+}
+
+// Name returns the name of the profile
+func (c *CustomLLM) Name() string {
+	return c.profile.Name
+}
+
+// GetRequiredConfig returns provider-specific configuration requirements
+func (c *CustomLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
+	required := map[string]config.ConfigRequirement{
+		"api_base": {
+			DefaultValue:  c.profile.APIBase,
+			PromptMessage: fmt.Sprintf("Enter %s API base", c.profile.DisplayName),
+		},
+		"model": {
+			DefaultValue:  c.profile.Model,
+			PromptMessage: "Enter model name",
+		},
+		"max_tokens": {
+			DefaultValue:  "1024",
+			PromptMessage: "Enter max tokens",
+		},
+	}
+	if c.profile.Auth != AuthNone {
+		required["api_key"] = config.ConfigRequirement{
+			DefaultValue:  "",
+			PromptMessage: "Enter API key",
+		}
+	}
+	return required
+}
+
+// BuildURL builds the API URL, adding the API key as query parameter for
+// the query auth style
+func (c *CustomLLM) BuildURL() string {
+	u := c.BaseLLM.BuildURL()
+	if c.profile.Auth != AuthQuery || c.Config.APIKey == "" {
+		return u
+	}
+
+	sep := "?"
+	if strings.Contains(u, "?") {
+		sep = "&"
+	}
+	return u + sep + url.QueryEscape(c.profile.AuthParam) + "=" + url.QueryEscape(c.Config.APIKey)
+}
+
+// BuildHeaders builds request headers according to the auth style
+func (c *CustomLLM) BuildHeaders() map[string]string {
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	if c.Config.APIKey != "" {
+		switch c.profile.Auth {
+		case AuthBearer:
+			headers["Authorization"] = fmt.Sprintf("Bearer %s", c.Config.APIKey)
+		case AuthAPIKey:
+			headers["api-key"] = c.Config.APIKey
+		case AuthHeader:
+			headers[c.profile.AuthHeader] = c.Config.APIKey
+		}
+	}
+	for k, v := range c.Config.ExtraHeaders {
+		headers[k] = v
+	}
+	return headers
+}
+
+// FormatMessages formats messages as an OpenAI chat request and applies
+// the profile's field mapping
+func (c *CustomLLM) FormatMessages(message string, history []types.Message) (interface{}, error) {
+	payload, err := c.BaseLLM.FormatMessages(message, history)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := payload.(map[string]interface{})
+	for from, to := range c.profile.Fields {
+		value, ok := fields[from]
+		if !ok || from == to {
+			continue
+		}
+		delete(fields, from)
+		if to != "" {
+			fields[to] = value
+		}
+	}
+	return fields, nil
+}
+
+// GetUsage returns the token usage found at the profile's usage paths, or
+// in the OpenAI usage object if none are configured
+func (c *CustomLLM) GetUsage(data []byte) (*types.Usage, error) {
+	paths := c.profile.UsagePaths
+	if paths == (UsagePaths{}) {
+		return c.BaseLLM.GetUsage(data)
+	}
+
+	get := func(path string) gjson.Result {
+		if path == "" {
+			return gjson.Result{}
+		}
+		return gjson.GetBytes(data, path)
+	}
+	input, output := get(paths.Input), get(paths.Output)
+	if !input.Exists() && !output.Exists() {
+		return nil, nil
+	}
+	return newUsage(input.Int(), output.Int(), get(paths.Cached).Int(), get(paths.Total).Int()), nil
+}
+
+// ParseStreamChunk parses the text delta at the profile's stream path
+func (c *CustomLLM) ParseStreamChunk(data []byte) (string, error) {
+	if c.profile.StreamPath == "" {
+		return c.BaseLLM.ParseStreamChunk(data)
+	}
+	return gjson.GetBytes(data, c.profile.StreamPath).String(), nil
+}
+
+// MakeRequest makes a request to the API
+func (c *CustomLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (*types.CompletionResponse, error) {
+	return c.BaseLLM.MakeRequest(ctx, client, c, message, history)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (c *CustomLLM) MakeStreamRequest(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	return c.BaseLLM.MakeStreamRequest(ctx, client, c, message, history, onChunk)
+}
@@ -0,0 +1,222 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/belingud/go-gptcomet/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomProfile_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		profile     CustomProfile
+		wantAuth    string
+		errContains string
+	}{
+		{
+			name:     "defaults to bearer",
+			profile:  CustomProfile{Name: "vllm", APIBase: "http://localhost:8000/v1"},
+			wantAuth: AuthBearer,
+		},
+		{
+			name:        "missing api_base",
+			profile:     CustomProfile{Name: "vllm"},
+			errContains: "api_base is required",
+		},
+		{
+			name:        "header without auth_header",
+			profile:     CustomProfile{Name: "gw", APIBase: "http://gw", Auth: AuthHeader},
+			errContains: "auth_header is required",
+		},
+		{
+			name:        "query without auth_param",
+			profile:     CustomProfile{Name: "gw", APIBase: "http://gw", Auth: AuthQuery},
+			errContains: "auth_param is required",
+		},
+		{
+			name:        "unknown auth style",
+			profile:     CustomProfile{Name: "gw", APIBase: "http://gw", Auth: "basic"},
+			errContains: "invalid auth style",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.profile.Validate()
+			if tt.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAuth, tt.profile.Auth)
+			assert.Equal(t, tt.profile.Name, tt.profile.DisplayName)
+		})
+	}
+}
+
+func TestRegisterCustomProvider(t *testing.T) {
+	t.Cleanup(func() { delete(providers, "lmstudio") })
+
+	err := RegisterCustomProvider(CustomProfile{
+		Name:    "lmstudio",
+		APIBase: "http://localhost:1234/v1",
+		Model:   "qwen2.5-coder",
+		Auth:    AuthNone,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, GetProviders(), "lmstudio")
+
+	info, ok := GetProviderInfo("lmstudio")
+	require.True(t, ok)
+	assert.False(t, info.Capabilities.RequiresAPIKey)
+	assert.Equal(t, "http://localhost:1234/v1", info.Defaults["api_base"])
+	assert.Equal(t, "qwen2.5-coder", info.Defaults["model"])
+	assert.NotContains(t, info.RequiredConfig, "api_key")
+
+	err = RegisterCustomProvider(CustomProfile{Name: "openai", APIBase: "http://gw"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "built-in provider")
+}
+
+func TestCustomLLM_Auth(t *testing.T) {
+	tests := []struct {
+		name        string
+		profile     CustomProfile
+		wantURL     string
+		wantHeaders map[string]string
+	}{
+		{
+			name:        "bearer",
+			profile:     CustomProfile{Auth: AuthBearer},
+			wantURL:     "http://gw/v1/chat/completions",
+			wantHeaders: map[string]string{"Authorization": "Bearer secret"},
+		},
+		{
+			name:        "api-key",
+			profile:     CustomProfile{Auth: AuthAPIKey},
+			wantURL:     "http://gw/v1/chat/completions",
+			wantHeaders: map[string]string{"api-key": "secret"},
+		},
+		{
+			name:        "custom header",
+			profile:     CustomProfile{Auth: AuthHeader, AuthHeader: "X-Gateway-Token"},
+			wantURL:     "http://gw/v1/chat/completions",
+			wantHeaders: map[string]string{"X-Gateway-Token": "secret"},
+		},
+		{
+			name:        "query parameter",
+			profile:     CustomProfile{Auth: AuthQuery, AuthParam: "key"},
+			wantURL:     "http://gw/v1/chat/completions?key=secret",
+			wantHeaders: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.profile.Name = "gw"
+			tt.profile.APIBase = "http://gw/v1"
+			c := NewCustomLLM(tt.profile, &types.ClientConfig{APIKey: "secret"})
+
+			assert.Equal(t, tt.wantURL, c.BuildURL())
+			headers := c.BuildHeaders()
+			for k, v := range tt.wantHeaders {
+				assert.Equal(t, v, headers[k])
+			}
+			if tt.profile.Auth != AuthBearer {
+				assert.NotContains(t, headers, "Authorization")
+			}
+		})
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	c := NewCustomLLM(CustomProfile{Name: "gw", APIBase: "http://gw/v1", Auth: AuthQuery, AuthParam: "key"}, &types.ClientConfig{APIKey: "sk/secret+1"})
+	u := c.BuildURL()
+	assert.Equal(t, "http://gw/v1/chat/completions?key=sk%2Fsecret%2B1", u)
+	assert.Equal(t, "http://gw/v1/chat/completions?key=***", redactURL(u, c.Config.APIKey))
+	assert.Equal(t, u, redactURL(u, ""))
+}
+
+func TestCustomLLM_RequestErrorHidesKey(t *testing.T) {
+	// A server that is closed again leaves a port nothing listens on
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	c := NewCustomLLM(CustomProfile{Name: "gw", APIBase: server.URL, Auth: AuthQuery, AuthParam: "key"}, &types.ClientConfig{APIKey: "sk-secret"})
+	_, err := c.MakeRequest(context.Background(), http.DefaultClient, "diff", nil)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "sk-secret")
+	assert.Contains(t, err.Error(), "key=***")
+
+	_, err = c.MakeStreamRequest(context.Background(), http.DefaultClient, "diff", nil, func(string) {})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "sk-secret")
+}
+
+func TestCustomLLM_FormatMessages(t *testing.T) {
+	c := NewCustomLLM(CustomProfile{
+		Name:    "openrouter",
+		APIBase: "https://openrouter.ai/api/v1",
+		Fields: map[string]string{
+			"max_tokens": "max_completion_tokens",
+			"top_p":      "",
+		},
+	}, &types.ClientConfig{Model: "m", MaxTokens: 100, TopP: 0.9})
+
+	payload, err := c.FormatMessages("diff", nil)
+	require.NoError(t, err)
+
+	fields := payload.(map[string]interface{})
+	assert.Equal(t, 100, fields["max_completion_tokens"])
+	assert.NotContains(t, fields, "max_tokens")
+	assert.NotContains(t, fields, "top_p")
+	assert.Equal(t, "m", fields["model"])
+}
+
+func TestCustomLLM_MakeRequest(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		assert.Equal(t, "/generate", r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+		w.Write([]byte(`{"output":{"text":"feat: custom"},"meta":{"in":7,"out":2}}`))
+	}))
+	defer server.Close()
+
+	c := NewCustomLLM(CustomProfile{
+		Name:           "gw",
+		APIBase:        server.URL,
+		CompletionPath: "generate",
+		Model:          "gw-model",
+		Auth:           AuthHeader,
+		AuthHeader:     "X-Token",
+		AnswerPath:     "output.text",
+		UsagePaths:     UsagePaths{Input: "meta.in", Output: "meta.out"},
+	}, &types.ClientConfig{APIKey: "secret"})
+
+	got, err := c.MakeRequest(context.Background(), server.Client(), "diff", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: custom", got.Content)
+	assert.Equal(t, &types.Usage{InputTokens: 7, OutputTokens: 2, TotalTokens: 9}, got.Usage)
+	assert.Contains(t, body, `"model":"gw-model"`)
+}
+
+func TestCustomLLM_ParseStreamChunk(t *testing.T) {
+	c := NewCustomLLM(CustomProfile{Name: "gw", APIBase: "http://gw"}, &types.ClientConfig{})
+	got, err := c.ParseStreamChunk([]byte(`{"choices":[{"delta":{"content":"feat"}}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "feat", got)
+
+	c = NewCustomLLM(CustomProfile{Name: "gw", APIBase: "http://gw", StreamPath: "token.text"}, &types.ClientConfig{})
+	got, err = c.ParseStreamChunk([]byte(`{"token":{"text":"fix"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "fix", got)
+}
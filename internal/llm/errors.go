@@ -0,0 +1,30 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrParseResponse is returned when no answer can be extracted from a response
+var ErrParseResponse = errors.New("failed to parse response")
+
+// APIError is returned when a provider answers a request with a non-200 status
+type APIError struct {
+	StatusCode int
+	Body       string
+	Header     http.Header
+}
+
+// NewAPIError creates an APIError from the response and its already read body
+func NewAPIError(resp *http.Response, body []byte) *APIError {
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		Header:     resp.Header,
+	}
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Body)
+}
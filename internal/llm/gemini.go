@@ -69,12 +69,7 @@ func (g *GeminiLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 
 // FormatMessages formats messages for Gemini API
 func (g *GeminiLLM) FormatMessages(message string, history []types.Message) (interface{}, error) {
-	var contents []map[string]interface{}
-
-	contents = append(contents, map[string]interface{}{
-		"role":  "user",
-		"parts": []map[string]string{{"text": message}},
-	})
+	system, contents := geminiContents(message, history)
 
 	payload := map[string]interface{}{
 		"contents": contents,
@@ -82,6 +77,9 @@ func (g *GeminiLLM) FormatMessages(message string, history []types.Message) (int
 			"maxOutputTokens": g.Config.MaxTokens,
 		},
 	}
+	if system != nil {
+		payload["systemInstruction"] = system
+	}
 
 	if g.Config.Temperature > 0 {
 		payload["generationConfig"].(map[string]interface{})["temperature"] = g.Config.Temperature
@@ -100,6 +98,33 @@ func (g *GeminiLLM) FormatMessages(message string, history []types.Message) (int
 	return payload, nil
 }
 
+// geminiContents returns the system instruction of history, nil if there is
+// none, and the other messages of history followed by message as contents.
+// Gemini calls the assistant role "model".
+func geminiContents(message string, history []types.Message) (map[string]interface{}, []map[string]interface{}) {
+	system, rest := splitSystem(history)
+	contents := make([]map[string]interface{}, 0, len(rest)+1)
+	for _, m := range rest {
+		role := m.Role
+		if role == types.RoleAssistant {
+			role = "model"
+		}
+		contents = append(contents, map[string]interface{}{
+			"role":  role,
+			"parts": []map[string]string{{"text": m.Content}},
+		})
+	}
+	contents = append(contents, map[string]interface{}{
+		"role":  types.RoleUser,
+		"parts": []map[string]string{{"text": message}},
+	})
+
+	if system == "" {
+		return nil, contents
+	}
+	return map[string]interface{}{"parts": []map[string]string{{"text": system}}}, contents
+}
+
 // BuildURL builds the API URL
 func (g *GeminiLLM) BuildURL() string {
 	return fmt.Sprintf("%s/%s:generateContent?key=%s", strings.TrimSuffix(g.Config.APIBase, "/"), g.Config.Model, g.Config.APIKey)
@@ -112,40 +137,45 @@ func (g *GeminiLLM) BuildHeaders() map[string]string {
 	}
 }
 
-// GetUsage returns usage information for the provider
-func (g *GeminiLLM) GetUsage(data []byte) (string, error) {
+// GetUsage returns the token usage from the response's usageMetadata
+func (g *GeminiLLM) GetUsage(data []byte) (*types.Usage, error) {
+	return geminiUsage(data), nil
+}
+
+// geminiUsage reads the usageMetadata object shared by Gemini and Vertex AI
+func geminiUsage(data []byte) *types.Usage {
 	usage := gjson.GetBytes(data, "usageMetadata")
-	if !usage.Exists() {
-		return "", nil
+	if !usage.IsObject() {
+		return nil
 	}
 
-	return fmt.Sprintf(
-		"Token usage> promptTokenCount: %d, candidatesTokenCount: %d, totalTokenCount: %d",
+	return newUsage(
 		usage.Get("promptTokenCount").Int(),
 		usage.Get("candidatesTokenCount").Int(),
+		usage.Get("cachedContentTokenCount").Int(),
 		usage.Get("totalTokenCount").Int(),
-	), nil
+	)
 }
 
 // MakeRequest makes a request to the API
-func (g *GeminiLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+func (g *GeminiLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (*types.CompletionResponse, error) {
 	url := g.BuildURL()
 	headers := g.BuildHeaders()
 	payload, err := g.FormatMessages(message, history)
 	if err != nil {
-		return "", fmt.Errorf("failed to format messages: %w", err)
+		return nil, fmt.Errorf("failed to format messages: %w", err)
 	}
 
 	debug.Printf("Sending request...")
 
 	reqBody, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBody))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	for k, v := range headers {
@@ -154,28 +184,39 @@ func (g *GeminiLLM) MakeRequest(ctx context.Context, client *http.Client, messag
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", redactError(err, g.Config.APIKey))
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %s", err)
+		return nil, fmt.Errorf("failed to read response: %s", err)
 	}
 
 	debug.Printf("Response: %s", string(respBody))
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, NewAPIError(resp, respBody)
 	}
 
-	usage, err := g.GetUsage(respBody)
+	return newCompletionResponse(g, respBody)
+}
+
+// BuildStreamURL builds the streamGenerateContent API URL, asking for SSE output
+func (g *GeminiLLM) BuildStreamURL() string {
+	return fmt.Sprintf("%s/%s:streamGenerateContent?alt=sse&key=%s", strings.TrimSuffix(g.Config.APIBase, "/"), g.Config.Model, g.Config.APIKey)
+}
+
+// ParseStreamChunk parses the text delta from a streamGenerateContent event
+func (g *GeminiLLM) ParseStreamChunk(data []byte) (string, error) {
+	return gjson.GetBytes(data, "candidates.0.content.parts.0.text").String(), nil
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (g *GeminiLLM) MakeStreamRequest(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	payload, err := g.FormatMessages(message, history)
 	if err != nil {
-		return "", fmt.Errorf("failed to get usage: %w", err)
-	}
-	if usage != "" {
-		fmt.Printf("%s\n", usage)
+		return nil, fmt.Errorf("failed to format messages: %w", err)
 	}
-
-	return g.ParseResponse(respBody)
+	return g.BaseLLM.SendStreamRequest(ctx, client, g, g.BuildStreamURL(), payload, onChunk)
 }
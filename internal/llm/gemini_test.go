@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/belingud/go-gptcomet/pkg/types"
@@ -189,6 +190,34 @@ func TestGeminiLLM_FormatMessages(t *testing.T) {
 	}
 }
 
+func TestGeminiLLM_FormatMessages_History(t *testing.T) {
+	llm := NewGeminiLLM(&types.ClientConfig{MaxTokens: 1024})
+	history := []types.Message{
+		{Role: types.RoleSystem, Content: "be brief"},
+		{Role: types.RoleUser, Content: "example diff"},
+		{Role: types.RoleAssistant, Content: "feat: example"},
+	}
+
+	got, err := llm.FormatMessages("test message", history)
+	if err != nil {
+		t.Fatalf("FormatMessages() error = %v", err)
+	}
+	payload := got.(map[string]interface{})
+
+	wantSystem := map[string]interface{}{"parts": []map[string]string{{"text": "be brief"}}}
+	if !reflect.DeepEqual(payload["systemInstruction"], wantSystem) {
+		t.Errorf("systemInstruction = %v, want %v", payload["systemInstruction"], wantSystem)
+	}
+	want := []map[string]interface{}{
+		{"role": "user", "parts": []map[string]string{{"text": "example diff"}}},
+		{"role": "model", "parts": []map[string]string{{"text": "feat: example"}}},
+		{"role": "user", "parts": []map[string]string{{"text": "test message"}}},
+	}
+	if !reflect.DeepEqual(payload["contents"], want) {
+		t.Errorf("contents = %v, want %v", payload["contents"], want)
+	}
+}
+
 func TestGeminiLLM_GetUsage(t *testing.T) {
 	llm := NewGeminiLLM(&types.ClientConfig{})
 	testData := []byte(`{
@@ -205,8 +234,8 @@ func TestGeminiLLM_GetUsage(t *testing.T) {
 		return
 	}
 
-	expected := "Token usage> promptTokenCount: 10, candidatesTokenCount: 20, totalTokenCount: 30"
-	if usage != expected {
-		t.Errorf("GetUsage() = %v, want %v", usage, expected)
+	expected := &types.Usage{InputTokens: 10, OutputTokens: 20, TotalTokens: 30}
+	if !reflect.DeepEqual(usage, expected) {
+		t.Errorf("GetUsage() = %+v, want %+v", usage, expected)
 	}
 }
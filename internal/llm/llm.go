@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/belingud/go-gptcomet/pkg/config"
@@ -28,16 +30,30 @@ type LLM interface {
 	FormatMessages(message string, history []types.Message) (interface{}, error)
 
 	// MakeRequest makes a request to the API
-	MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error)
+	MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (*types.CompletionResponse, error)
 
-	// GetUsage returns usage information for the provider
-	GetUsage(data []byte) (string, error)
+	// MakeStreamRequest makes a streaming request to the API, calling onChunk for every text delta
+	MakeStreamRequest(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error)
+
+	// GetUsage returns the token usage reported in a response or stream
+	// event, or nil if it carries none
+	GetUsage(data []byte) (*types.Usage, error)
 
 	// BuildHeaders builds request headers
 	BuildHeaders() map[string]string
 
 	// ParseResponse parses the response from the API
 	ParseResponse(response []byte) (string, error)
+
+	// ParseStreamChunk parses the text delta from a single streaming event
+	ParseStreamChunk(data []byte) (string, error)
+}
+
+// ChoicesLLM is implemented by providers that generate several alternative
+// answers in one request, which is announced by Capabilities.Choices
+type ChoicesLLM interface {
+	// MakeChoicesRequest makes a request for n answers to the API
+	MakeChoicesRequest(ctx context.Context, client *http.Client, message string, history []types.Message, n int) (*types.CompletionResponse, error)
 }
 
 // BaseLLM provides common functionality for all LLM providers
@@ -99,12 +115,14 @@ func (b *BaseLLM) GetRequiredConfig() map[string]config.ConfigRequirement {
 // This is a default implementation which should be overridden by the
 // provider if it needs to format the messages differently.
 func (b *BaseLLM) FormatMessages(message string, history []types.Message) (interface{}, error) {
+	system, rest := splitSystem(history)
 	messages := make([]types.Message, 0, len(history)+1)
-	if history != nil {
-		messages = append(messages, history...)
+	if system != "" {
+		messages = append(messages, types.Message{Role: types.RoleSystem, Content: system})
 	}
+	messages = append(messages, rest...)
 	messages = append(messages, types.Message{
-		Role:    "user",
+		Role:    types.RoleUser,
 		Content: message,
 	})
 
@@ -129,6 +147,22 @@ func (b *BaseLLM) FormatMessages(message string, history []types.Message) (inter
 	return payload, nil
 }
 
+// splitSystem returns the content of the system messages in history,
+// separated by blank lines, and the other messages. Providers with a
+// dedicated field for the system prompt put it there.
+func splitSystem(history []types.Message) (string, []types.Message) {
+	var system []string
+	messages := make([]types.Message, 0, len(history))
+	for _, m := range history {
+		if m.Role == types.RoleSystem {
+			system = append(system, m.Content)
+			continue
+		}
+		messages = append(messages, m)
+	}
+	return strings.Join(system, "\n\n"), messages
+}
+
 // BuildHeaders provides a default implementation for building headers
 func (b *BaseLLM) BuildHeaders() map[string]string {
 	headers := map[string]string{
@@ -148,6 +182,26 @@ func (b *BaseLLM) BuildURL() string {
 	return fmt.Sprintf("%s/%s", strings.TrimSuffix(b.Config.APIBase, "/"), strings.TrimPrefix(b.Config.CompletionPath, "/"))
 }
 
+// redactURL hides the API key in rawURL, where the query auth style puts
+// it, so that the URL can be logged
+func redactURL(rawURL, apiKey string) string {
+	if apiKey == "" {
+		return rawURL
+	}
+	rawURL = strings.ReplaceAll(rawURL, url.QueryEscape(apiKey), "***")
+	return strings.ReplaceAll(rawURL, apiKey, "***")
+}
+
+// redactError hides the API key in the URL of a transport error, which
+// would otherwise print it with the error message
+func redactError(err error, apiKey string) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = redactURL(urlErr.URL, apiKey)
+	}
+	return err
+}
+
 // ParseResponse parses the response from the API according to the provider's
 // configuration. It first tries to extract the answer using the answer path
 // specified in the configuration. If the answer is not found, it returns an
@@ -156,43 +210,56 @@ func (b *BaseLLM) BuildURL() string {
 func (b *BaseLLM) ParseResponse(response []byte) (string, error) {
 	result := gjson.GetBytes(response, b.Config.AnswerPath)
 	if !result.Exists() {
-		return "", fmt.Errorf("failed to parse response: %s", string(response))
-	}
-	text := result.String()
-	if strings.HasPrefix(text, "```") && strings.HasSuffix(text, "```") {
-		text = strings.TrimPrefix(text, "```")
-		text = strings.TrimSuffix(text, "```")
+		return "", fmt.Errorf("%w: %s", ErrParseResponse, string(response))
 	}
-	return strings.TrimSpace(text), nil
+	return trimCodeFence(result.String()), nil
 }
 
-// GetUsage returns a string representing the token usage of the response.
-// It tries to extract the usage information from the response data using the
-// following field names: "prompt_tokens", "completion_tokens", and "total_tokens".
-// If the information is not found, it returns an empty string.
-func (b *BaseLLM) GetUsage(data []byte) (string, error) {
+// GetUsage returns the token usage of an OpenAI-compatible response.
+// It reads the "usage" object with either the "prompt_tokens" and
+// "completion_tokens" or the "input_tokens" and "output_tokens" field names,
+// and the cached prompt tokens as reported by OpenAI or DeepSeek.
+// If the information is not found, it returns nil.
+func (b *BaseLLM) GetUsage(data []byte) (*types.Usage, error) {
 	usage := gjson.GetBytes(data, "usage")
-	if !usage.Exists() {
-		return "", nil
+	if !usage.IsObject() {
+		return nil, nil
 	}
 
-	var promptTokens, completionTokens, totalTokens int64
+	return newUsage(
+		firstInt(usage, "prompt_tokens", "input_tokens"),
+		firstInt(usage, "completion_tokens", "output_tokens"),
+		firstInt(usage, "prompt_tokens_details.cached_tokens", "prompt_cache_hit_tokens"),
+		usage.Get("total_tokens").Int(),
+	), nil
+}
 
-	// Try different field names used by different providers
-	promptTokens = usage.Get("prompt_tokens").Int()
-	completionTokens = usage.Get("completion_tokens").Int()
-	totalTokens = usage.Get("total_tokens").Int()
+// newUsage builds a Usage, computing the total if the provider did not
+// report it
+func newUsage(input, output, cached, total int64) *types.Usage {
+	if total == 0 {
+		total = input + output
+	}
+	return &types.Usage{
+		InputTokens:  int(input),
+		OutputTokens: int(output),
+		CachedTokens: int(cached),
+		TotalTokens:  int(total),
+	}
+}
 
-	return fmt.Sprintf(
-		"Token usage> prompt: %d, completion: %d, total: %d",
-		promptTokens,
-		completionTokens,
-		totalTokens,
-	), nil
+// firstInt returns the first of paths that exists in result
+func firstInt(result gjson.Result, paths ...string) int64 {
+	for _, path := range paths {
+		if v := result.Get(path); v.Exists() {
+			return v.Int()
+		}
+	}
+	return 0
 }
 
 // MakeRequest makes a request to the provider's API, formats the response, and
-// returns the result as a string.
+// returns the answer together with the reported token usage.
 //
 // If the request fails or the response is invalid, it returns an error.
 //
@@ -203,54 +270,101 @@ func (b *BaseLLM) GetUsage(data []byte) (string, error) {
 //   - message: the message to send to the provider
 //   - history: the message history to send to the provider
 //
-// The function returns the response from the provider as a string, or an error
-// if the request fails.
-func (b *BaseLLM) MakeRequest(ctx context.Context, client *http.Client, provider LLM, message string, history []types.Message) (string, error) {
-	url := provider.BuildURL()
-	headers := provider.BuildHeaders()
+// The function returns the response from the provider, or an error if the
+// request fails.
+func (b *BaseLLM) MakeRequest(ctx context.Context, client *http.Client, provider LLM, message string, history []types.Message) (*types.CompletionResponse, error) {
 	payload, err := provider.FormatMessages(message, history)
 	if err != nil {
-		return "", fmt.Errorf("failed to format messages: %w", err)
+		return nil, fmt.Errorf("failed to format messages: %w", err)
 	}
 
+	respBody, err := b.sendRequest(ctx, client, provider, payload)
+	if err != nil {
+		return nil, err
+	}
+	return newCompletionResponse(provider, respBody)
+}
+
+// MakeChoicesRequest makes a request for n alternative answers with the
+// OpenAI "n" parameter. Content of the response is the first answer,
+// Choices holds all of them.
+func (b *BaseLLM) MakeChoicesRequest(ctx context.Context, client *http.Client, provider LLM, message string, history []types.Message, n int) (*types.CompletionResponse, error) {
+	payload, err := provider.FormatMessages(message, history)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format messages: %w", err)
+	}
+	p, ok := payload.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support several answers per request", provider.Name())
+	}
+	p["n"] = n
+
+	respBody, err := b.sendRequest(ctx, client, provider, p)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := newCompletionResponse(provider, respBody)
+	if err != nil {
+		return nil, err
+	}
+	for _, choice := range gjson.GetBytes(respBody, "choices.#.message.content").Array() {
+		resp.Choices = append(resp.Choices, trimCodeFence(choice.String()))
+	}
+	return resp, nil
+}
+
+// sendRequest posts payload to the provider's API and returns the body of
+// a successful response
+func (b *BaseLLM) sendRequest(ctx context.Context, client *http.Client, provider LLM, payload interface{}) ([]byte, error) {
 	reqBody, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", provider.BuildURL(), bytes.NewReader(reqBody))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	for k, v := range headers {
+	for k, v := range provider.BuildHeaders() {
 		req.Header.Set(k, v)
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", redactError(err, b.Config.APIKey))
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %s", err)
+		return nil, fmt.Errorf("failed to read response: %s", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, NewAPIError(resp, respBody)
 	}
+	return respBody, nil
+}
 
+// newCompletionResponse parses the answer and the token usage of a
+// successful response body
+func newCompletionResponse(provider LLM, respBody []byte) (*types.CompletionResponse, error) {
 	usage, err := provider.GetUsage(respBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to get usage: %w", err)
+		return nil, fmt.Errorf("failed to get usage: %w", err)
 	}
-	if usage != "" {
-		fmt.Printf("%s\n", usage)
+
+	content, err := provider.ParseResponse(respBody)
+	if err != nil {
+		return nil, err
 	}
 
-	return provider.ParseResponse(respBody)
+	return &types.CompletionResponse{
+		Content: content,
+		Raw:     make(map[string]interface{}),
+		Usage:   usage,
+	}, nil
 }
 
 // DefaultLLM provides default implementation of LLM interface
@@ -284,6 +398,11 @@ func (d *DefaultLLM) Name() string {
 }
 
 // MakeRequest implements the LLM interface for DefaultLLM.
-func (d *DefaultLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+func (d *DefaultLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (*types.CompletionResponse, error) {
 	return d.BaseLLM.MakeRequest(ctx, client, d, message, history)
 }
+
+// MakeStreamRequest implements the LLM interface for DefaultLLM.
+func (d *DefaultLLM) MakeStreamRequest(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	return d.BaseLLM.MakeStreamRequest(ctx, client, d, message, history, onChunk)
+}
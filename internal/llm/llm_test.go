@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/belingud/go-gptcomet/pkg/types"
@@ -109,6 +110,30 @@ func TestBaseLLM_FormatMessages(t *testing.T) {
 	}
 }
 
+func TestBaseLLM_FormatMessages_System(t *testing.T) {
+	llm := NewBaseLLM(&types.ClientConfig{Model: "test-model"})
+	history := []types.Message{
+		{Role: types.RoleUser, Content: "example diff"},
+		{Role: types.RoleSystem, Content: "be brief"},
+		{Role: types.RoleAssistant, Content: "feat: example"},
+	}
+
+	got, err := llm.FormatMessages("test message", history)
+	if err != nil {
+		t.Fatalf("FormatMessages() error = %v", err)
+	}
+
+	want := []types.Message{
+		{Role: types.RoleSystem, Content: "be brief"},
+		{Role: types.RoleUser, Content: "example diff"},
+		{Role: types.RoleAssistant, Content: "feat: example"},
+		{Role: types.RoleUser, Content: "test message"},
+	}
+	if messages := got.(map[string]interface{})["messages"]; !reflect.DeepEqual(messages, want) {
+		t.Errorf("messages = %v, want %v", messages, want)
+	}
+}
+
 func TestBaseLLM_BuildHeaders(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -245,7 +270,7 @@ func TestBaseLLM_GetUsage(t *testing.T) {
 	tests := []struct {
 		name    string
 		data    []byte
-		want    string
+		want    *types.Usage
 		wantErr bool
 	}{
 		{
@@ -257,13 +282,13 @@ func TestBaseLLM_GetUsage(t *testing.T) {
 					"total_tokens": 30
 				}
 			}`),
-			want:    "Token usage> prompt: 10, completion: 20, total: 30",
+			want:    &types.Usage{InputTokens: 10, OutputTokens: 20, TotalTokens: 30},
 			wantErr: false,
 		},
 		{
 			name:    "no usage info",
 			data:    []byte(`{}`),
-			want:    "",
+			want:    nil,
 			wantErr: false,
 		},
 	}
@@ -276,8 +301,8 @@ func TestBaseLLM_GetUsage(t *testing.T) {
 				t.Errorf("GetUsage() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
-				t.Errorf("GetUsage() = %s, want %s", got, tt.want)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetUsage() = %+v, want %+v", got, tt.want)
 			}
 		})
 	}
@@ -5,7 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
 
 	"github.com/belingud/go-gptcomet/pkg/config"
 	"github.com/belingud/go-gptcomet/pkg/types"
@@ -92,36 +96,66 @@ func (o *OllamaLLM) FormatMessages(message string, history []types.Message) (int
 		options["presence_penalty"] = o.Config.PresencePenalty
 	}
 
+	// The generate API has no messages, earlier turns are put before the
+	// prompt as a transcript
+	system, rest := splitSystem(history)
+	var prompt strings.Builder
+	for _, m := range rest {
+		role := "User"
+		if m.Role == types.RoleAssistant {
+			role = "Assistant"
+		}
+		fmt.Fprintf(&prompt, "%s: %s\n\n", role, m.Content)
+	}
+	if len(rest) > 0 {
+		prompt.WriteString("User: ")
+	}
+	prompt.WriteString(message)
+
 	payload := map[string]interface{}{
 		"model":   o.Config.Model,
-		"prompt":  message,
+		"prompt":  prompt.String(),
 		"options": options,
 	}
+	if system != "" {
+		payload["system"] = system
+	}
 
 	return payload, nil
 }
 
-// GetUsage returns usage information for the provider
-func (o *OllamaLLM) GetUsage(data []byte) (string, error) {
-	return "", nil
+// GetUsage returns the token usage reported in the final generate
+// response or stream line as prompt_eval_count and eval_count
+func (o *OllamaLLM) GetUsage(data []byte) (*types.Usage, error) {
+	input := gjson.GetBytes(data, "prompt_eval_count")
+	output := gjson.GetBytes(data, "eval_count")
+	if !input.Exists() && !output.Exists() {
+		return nil, nil
+	}
+	return newUsage(input.Int(), output.Int(), 0, 0), nil
 }
 
 // MakeRequest makes a request to the API
-func (o *OllamaLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+func (o *OllamaLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (*types.CompletionResponse, error) {
 	payload, err := o.FormatMessages(message, history)
 	if err != nil {
-		return "", fmt.Errorf("failed to format messages: %w", err)
+		return nil, fmt.Errorf("failed to format messages: %w", err)
+	}
+	// Ollama streams NDJSON unless asked not to, MakeStreamRequest sets it
+	// back to true
+	if p, ok := payload.(map[string]interface{}); ok {
+		p["stream"] = false
 	}
 
 	reqBody, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	url := fmt.Sprintf("%s/%s", o.Config.APIBase, o.Config.CompletionPath)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBody))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	for k, v := range o.BuildHeaders() {
@@ -130,22 +164,40 @@ func (o *OllamaLLM) MakeRequest(ctx context.Context, client *http.Client, messag
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+		return nil, fmt.Errorf("failed to make request: %w", redactError(err, o.Config.APIKey))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("request failed with status: %s", resp.Status)
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		return nil, NewAPIError(resp, respBody)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	var result struct {
 		Response string `json:"response"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrParseResponse, err)
+	}
+
+	usage, err := o.GetUsage(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
 	}
 
-	return result.Response, nil
+	return &types.CompletionResponse{
+		Content: result.Response,
+		Raw:     make(map[string]interface{}),
+		Usage:   usage,
+	}, nil
 }
 
 // BuildHeaders builds request headers
@@ -158,3 +210,14 @@ func (o *OllamaLLM) BuildHeaders() map[string]string {
 	}
 	return headers
 }
+
+// ParseStreamChunk parses the text delta from a single NDJSON line of the
+// Ollama generate stream
+func (o *OllamaLLM) ParseStreamChunk(data []byte) (string, error) {
+	return gjson.GetBytes(data, "response").String(), nil
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (o *OllamaLLM) MakeStreamRequest(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	return o.BaseLLM.MakeStreamRequest(ctx, client, o, message, history, onChunk)
+}
@@ -1,6 +1,11 @@
 package llm
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
 
 	"github.com/belingud/go-gptcomet/pkg/types"
@@ -186,6 +191,29 @@ func TestOllamaLLM_FormatMessages(t *testing.T) {
 	}
 }
 
+func TestOllamaLLM_FormatMessages_History(t *testing.T) {
+	llm := NewOllamaLLM(&types.ClientConfig{})
+	history := []types.Message{
+		{Role: types.RoleSystem, Content: "be brief"},
+		{Role: types.RoleUser, Content: "example diff"},
+		{Role: types.RoleAssistant, Content: "feat: example"},
+	}
+
+	got, err := llm.FormatMessages("test message", history)
+	if err != nil {
+		t.Fatalf("FormatMessages() error = %v", err)
+	}
+	payload := got.(map[string]interface{})
+
+	if payload["system"] != "be brief" {
+		t.Errorf("system = %v, want be brief", payload["system"])
+	}
+	want := "User: example diff\n\nAssistant: feat: example\n\nUser: test message"
+	if payload["prompt"] != want {
+		t.Errorf("prompt = %q, want %q", payload["prompt"], want)
+	}
+}
+
 func TestOllamaLLM_BuildHeaders(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -226,3 +254,63 @@ func TestOllamaLLM_BuildHeaders(t *testing.T) {
 		})
 	}
 }
+
+func TestOllamaLLM_GetUsage(t *testing.T) {
+	llm := NewOllamaLLM(&types.ClientConfig{})
+	tests := []struct {
+		name string
+		data []byte
+		want *types.Usage
+	}{
+		{
+			name: "final response",
+			data: []byte(`{"response": "", "done": true, "prompt_eval_count": 26, "eval_count": 290}`),
+			want: &types.Usage{InputTokens: 26, OutputTokens: 290, TotalTokens: 316},
+		},
+		{
+			name: "intermediate stream line",
+			data: []byte(`{"response": "feat", "done": false}`),
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := llm.GetUsage(tt.data)
+			if err != nil {
+				t.Errorf("GetUsage() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetUsage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOllamaLLM_MakeRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if stream, ok := payload["stream"].(bool); !ok || stream {
+			t.Errorf("stream = %v, want false", payload["stream"])
+		}
+		w.Write([]byte(`{"response": "feat: add cache", "done": true, "prompt_eval_count": 26, "eval_count": 5}`))
+	}))
+	defer server.Close()
+
+	llm := NewOllamaLLM(&types.ClientConfig{APIBase: server.URL})
+	resp, err := llm.MakeRequest(context.Background(), server.Client(), "diff", nil)
+	if err != nil {
+		t.Fatalf("MakeRequest() error = %v", err)
+	}
+	if resp.Content != "feat: add cache" {
+		t.Errorf("Content = %q, want %q", resp.Content, "feat: add cache")
+	}
+	want := &types.Usage{InputTokens: 26, OutputTokens: 5, TotalTokens: 31}
+	if !reflect.DeepEqual(resp.Usage, want) {
+		t.Errorf("Usage = %+v, want %+v", resp.Usage, want)
+	}
+}
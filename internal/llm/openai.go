@@ -9,8 +9,6 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/tidwall/gjson"
-
 	"github.com/belingud/go-gptcomet/internal/debug"
 	"github.com/belingud/go-gptcomet/pkg/config"
 	"github.com/belingud/go-gptcomet/pkg/types"
@@ -93,41 +91,26 @@ func (o *OpenAILLM) BuildHeaders() map[string]string {
 	return headers
 }
 
-// GetUsage returns usage information for the provider
-func (o *OpenAILLM) GetUsage(data []byte) (string, error) {
-	usage := gjson.GetBytes(data, "usage")
-	if !usage.Exists() {
-		return "", nil
-	}
-
-	return fmt.Sprintf(
-		"Token usage> prompt tokens: %d, completion tokens: %d, total tokens: %d",
-		usage.Get("prompt_tokens").Int(),
-		usage.Get("completion_tokens").Int(),
-		usage.Get("total_tokens").Int(),
-	), nil
-}
-
 // MakeRequest makes a request to the API
-func (o *OpenAILLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+func (o *OpenAILLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (*types.CompletionResponse, error) {
 	url := o.BuildURL()
-	debug.Printf("API URL: %s", url)
+	debug.Printf("API URL: %s", redactURL(url, o.Config.APIKey))
 	headers := o.BuildHeaders()
 	payload, err := o.FormatMessages(message, history)
 	if err != nil {
-		return "", fmt.Errorf("failed to format messages: %w", err)
+		return nil, fmt.Errorf("failed to format messages: %w", err)
 	}
 
 	debug.Printf("Sending request...")
 
 	reqBody, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBody))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	for k, v := range headers {
@@ -136,28 +119,30 @@ func (o *OpenAILLM) MakeRequest(ctx context.Context, client *http.Client, messag
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", redactError(err, o.Config.APIKey))
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	debug.Printf("Response: %s", string(respBody))
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, NewAPIError(resp, respBody)
 	}
 
-	usage, err := o.GetUsage(respBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to get usage: %w", err)
-	}
-	if usage != "" {
-		debug.Printf("%s", usage)
-	}
+	return newCompletionResponse(o, respBody)
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (o *OpenAILLM) MakeStreamRequest(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	return o.BaseLLM.MakeStreamRequest(ctx, client, o, message, history, onChunk)
+}
 
-	return o.ParseResponse(respBody)
+// MakeChoicesRequest makes a request for n answers with the "n" parameter
+func (o *OpenAILLM) MakeChoicesRequest(ctx context.Context, client *http.Client, message string, history []types.Message, n int) (*types.CompletionResponse, error) {
+	return o.BaseLLM.MakeChoicesRequest(ctx, client, o, message, history, n)
 }
@@ -1,6 +1,11 @@
 package llm
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
 
 	"github.com/belingud/go-gptcomet/pkg/types"
@@ -191,7 +196,7 @@ func TestOpenAILLM_GetUsage(t *testing.T) {
 	tests := []struct {
 		name    string
 		data    []byte
-		want    string
+		want    *types.Usage
 		wantErr bool
 	}{
 		{
@@ -203,13 +208,13 @@ func TestOpenAILLM_GetUsage(t *testing.T) {
                     "total_tokens": 30
                 }
             }`),
-			want:    "Token usage> prompt tokens: 10, completion tokens: 20, total tokens: 30",
+			want:    &types.Usage{InputTokens: 10, OutputTokens: 20, TotalTokens: 30},
 			wantErr: false,
 		},
 		{
 			name:    "no usage info",
 			data:    []byte(`{}`),
-			want:    "",
+			want:    nil,
 			wantErr: false,
 		},
 	}
@@ -221,9 +226,47 @@ func TestOpenAILLM_GetUsage(t *testing.T) {
 				t.Errorf("GetUsage() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
-				t.Errorf("GetUsage() = %s, want %s", got, tt.want)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetUsage() = %+v, want %+v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestOpenAILLM_MakeChoicesRequest(t *testing.T) {
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Write([]byte(`{
+			"choices": [
+				{"message": {"content": "feat: add candidates"}},
+				{"message": {"content": "feat: generate several messages"}}
+			],
+			"usage": {"prompt_tokens": 100, "completion_tokens": 20}
+		}`))
+	}))
+	defer server.Close()
+
+	llm := NewOpenAILLM(&types.ClientConfig{APIBase: server.URL, APIKey: "sk-test"})
+	got, err := llm.MakeChoicesRequest(context.Background(), server.Client(), "diff", nil, 2)
+	if err != nil {
+		t.Fatalf("MakeChoicesRequest() error = %v", err)
+	}
+
+	if payload["n"] != float64(2) {
+		t.Errorf("request n = %v, want 2", payload["n"])
+	}
+	wantChoices := []string{"feat: add candidates", "feat: generate several messages"}
+	if !reflect.DeepEqual(got.Choices, wantChoices) {
+		t.Errorf("Choices = %v, want %v", got.Choices, wantChoices)
+	}
+	if got.Content != wantChoices[0] {
+		t.Errorf("Content = %q, want %q", got.Content, wantChoices[0])
+	}
+	wantUsage := &types.Usage{InputTokens: 100, OutputTokens: 20, TotalTokens: 120}
+	if !reflect.DeepEqual(got.Usage, wantUsage) {
+		t.Errorf("Usage = %+v, want %+v", got.Usage, wantUsage)
+	}
+}
@@ -4,29 +4,87 @@ import (
 	"fmt"
 	"sort"
 
+	"github.com/belingud/go-gptcomet/pkg/config"
 	"github.com/belingud/go-gptcomet/pkg/types"
 )
 
+// DefaultProvider is used when no provider is configured
+const DefaultProvider = "openai"
+
 // ProviderConstructor 是创建 LLM 实例的函数类型
 type ProviderConstructor func(config *types.ClientConfig) LLM
 
+// Capabilities describes optional features of a provider
+type Capabilities struct {
+	Streaming      bool // supports MakeStreamRequest
+	RequiresAPIKey bool // refuses requests without api_key
+	Choices        bool // generates several answers per request, implements ChoicesLLM
+}
+
+// ProviderInfo is the registry entry of a provider
+type ProviderInfo struct {
+	Name         string
+	DisplayName  string
+	Constructor  ProviderConstructor
+	Capabilities Capabilities
+	// RequiredConfig lists the settings asked for by newprovider. It is
+	// taken from the provider's GetRequiredConfig when not set.
+	RequiredConfig map[string]config.ConfigRequirement
+	// Defaults holds the default value of every setting, taken from
+	// RequiredConfig when not set
+	Defaults map[string]string
+}
+
 var (
 	// providers 存储所有注册的 provider
-	providers = make(map[string]ProviderConstructor)
+	providers = make(map[string]ProviderInfo)
+	// builtins holds the names of the providers shipped with gptcomet
+	builtins = make(map[string]bool)
 )
 
-// RegisterProvider 注册一个新的 provider
-func RegisterProvider(name string, constructor ProviderConstructor) error {
-	if name == "" {
+// Register adds a provider with its metadata to the registry, replacing
+// any provider of the same name. Missing metadata is filled in from an
+// instance created with an empty config.
+func Register(info ProviderInfo) error {
+	if info.Name == "" {
 		return fmt.Errorf("provider name cannot be empty")
 	}
-	if constructor == nil {
+	if info.Constructor == nil {
 		return fmt.Errorf("constructor cannot be nil")
 	}
-	providers[name] = constructor
+	if info.DisplayName == "" {
+		info.DisplayName = info.Name
+	}
+	if info.RequiredConfig == nil {
+		info.RequiredConfig = info.Constructor(&types.ClientConfig{}).GetRequiredConfig()
+	}
+	if info.Defaults == nil {
+		info.Defaults = make(map[string]string, len(info.RequiredConfig))
+		for key, req := range info.RequiredConfig {
+			if req.DefaultValue != "" {
+				info.Defaults[key] = req.DefaultValue
+			}
+		}
+	}
+	providers[info.Name] = info
 	return nil
 }
 
+// RegisterProvider 注册一个新的 provider
+//
+// The provider is registered with streaming support and an API key
+// requirement, use Register to describe it in more detail.
+func RegisterProvider(name string, constructor ProviderConstructor) error {
+	return Register(ProviderInfo{
+		Name:        name,
+		Constructor: constructor,
+		Capabilities: Capabilities{
+			Streaming:      true,
+			RequiresAPIKey: true,
+		},
+	})
+}
+
 // GetProviders 返回所有已注册的 provider 名称
 func GetProviders() []string {
 	names := make([]string, 0, len(providers))
@@ -37,93 +95,86 @@ func GetProviders() []string {
 	return names
 }
 
+// GetProviderInfo returns the registry entry of the named provider
+func GetProviderInfo(name string) (ProviderInfo, bool) {
+	info, ok := providers[name]
+	return info, ok
+}
+
 // NewProvider 根据名称创建对应的 LLM 实例
 func NewProvider(name string, config *types.ClientConfig) (LLM, error) {
 	if config == nil {
 		return nil, fmt.Errorf("config cannot be nil")
 	}
 
-	constructor, ok := providers[name]
+	info, ok := providers[name]
 	if !ok {
 		return nil, fmt.Errorf("unknown provider: %s", name)
 	}
-	return constructor(config), nil
+	return info.Constructor(config), nil
+}
+
+// builtinProvider registers a provider shipped with gptcomet
+func builtinProvider(name, displayName string, constructor ProviderConstructor, caps Capabilities) {
+	if err := Register(ProviderInfo{
+		Name:         name,
+		DisplayName:  displayName,
+		Constructor:  constructor,
+		Capabilities: caps,
+	}); err != nil {
+		panic(err)
+	}
+	builtins[name] = true
 }
 
 // 在 init 函数中注册所有 provider
 func init() {
-	// Azure
-	RegisterProvider("azure", func(config *types.ClientConfig) LLM {
-		return &AzureLLM{}
-	})
-
-	// ChatGLM
-	RegisterProvider("chatglm", func(config *types.ClientConfig) LLM {
-		return &ChatGLMLLM{}
-	})
-
-	// Claude
-	RegisterProvider("claude", func(config *types.ClientConfig) LLM {
-		return &ClaudeLLM{}
-	})
-
-	// Cohere
-	RegisterProvider("cohere", func(config *types.ClientConfig) LLM {
-		return &CohereLLM{}
-	})
-
-	// Deepseek
-	RegisterProvider("deepseek", func(config *types.ClientConfig) LLM {
-		return &DeepSeekLLM{}
-	})
-
-	// Gemini
-	RegisterProvider("gemini", func(config *types.ClientConfig) LLM {
-		return &GeminiLLM{}
-	})
-
-	// Kimi
-	RegisterProvider("kimi", func(config *types.ClientConfig) LLM {
-		return &KimiLLM{}
-	})
-
-	// Mistral
-	RegisterProvider("mistral", func(config *types.ClientConfig) LLM {
-		return &MistralLLM{}
-	})
-
-	// Ollama
-	RegisterProvider("ollama", func(config *types.ClientConfig) LLM {
-		return &OllamaLLM{}
-	})
-
-	// OpenAI
-	RegisterProvider("openai", func(config *types.ClientConfig) LLM {
-		return &OpenAILLM{}
-	})
-
-	// Sambanova
-	RegisterProvider("sambanova", func(config *types.ClientConfig) LLM {
-		return &SambanovaLLM{}
-	})
-
-	// Silicon
-	RegisterProvider("silicon", func(config *types.ClientConfig) LLM {
-		return &SiliconLLM{}
-	})
-
-	// Tongyi
-	RegisterProvider("tongyi", func(config *types.ClientConfig) LLM {
-		return &TongyiLLM{}
-	})
-
-	// Vertex
-	RegisterProvider("vertex", func(config *types.ClientConfig) LLM {
-		return &VertexLLM{}
-	})
-
-	// XAI
-	RegisterProvider("xai", func(config *types.ClientConfig) LLM {
-		return &XAILLM{}
-	})
+	keyed := Capabilities{Streaming: true, RequiresAPIKey: true}
+	choices := Capabilities{Streaming: true, RequiresAPIKey: true, Choices: true}
+
+	builtinProvider("azure", "Azure OpenAI", func(config *types.ClientConfig) LLM {
+		return NewAzureLLM(config)
+	}, choices)
+	builtinProvider("chatglm", "ChatGLM", func(config *types.ClientConfig) LLM {
+		return NewChatGLMLLM(config)
+	}, keyed)
+	builtinProvider("claude", "Claude", func(config *types.ClientConfig) LLM {
+		return NewClaudeLLM(config)
+	}, keyed)
+	builtinProvider("cohere", "Cohere", func(config *types.ClientConfig) LLM {
+		return NewCohereLLM(config)
+	}, keyed)
+	builtinProvider("deepseek", "DeepSeek", func(config *types.ClientConfig) LLM {
+		return NewDeepSeekLLM(config)
+	}, keyed)
+	builtinProvider("gemini", "Gemini", func(config *types.ClientConfig) LLM {
+		return NewGeminiLLM(config)
+	}, keyed)
+	builtinProvider("kimi", "Kimi", func(config *types.ClientConfig) LLM {
+		return NewKimiLLM(config)
+	}, keyed)
+	builtinProvider("mistral", "Mistral", func(config *types.ClientConfig) LLM {
+		return NewMistralLLM(config)
+	}, keyed)
+	builtinProvider("ollama", "Ollama", func(config *types.ClientConfig) LLM {
+		return NewOllamaLLM(config)
+	}, Capabilities{Streaming: true})
+	builtinProvider("openai", "OpenAI", func(config *types.ClientConfig) LLM {
+		return NewOpenAILLM(config)
+	}, choices)
+	builtinProvider("sambanova", "SambaNova", func(config *types.ClientConfig) LLM {
+		return NewSambanovaLLM(config)
+	}, keyed)
+	builtinProvider("silicon", "SiliconFlow", func(config *types.ClientConfig) LLM {
+		return NewSiliconLLM(config)
+	}, keyed)
+	builtinProvider("tongyi", "Tongyi Qianwen", func(config *types.ClientConfig) LLM {
+		return NewTongyiLLM(config)
+	}, keyed)
+	builtinProvider("vertex", "Vertex AI", func(config *types.ClientConfig) LLM {
+		return NewVertexLLM(config)
+	}, keyed)
+	builtinProvider("xai", "xAI", func(config *types.ClientConfig) LLM {
+		return NewXAILLM(config)
+	}, keyed)
 }
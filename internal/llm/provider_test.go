@@ -39,12 +39,20 @@ func (p *MockProvider) ParseResponse(response []byte) (string, error) {
 	return "", nil
 }
 
-func (p *MockProvider) GetUsage(data []byte) (string, error) {
-	return "", nil
+func (p *MockProvider) GetUsage(data []byte) (*types.Usage, error) {
+	return nil, nil
 }
 
-func (p *MockProvider) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
-	return "mock response", nil
+func (p *MockProvider) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (*types.CompletionResponse, error) {
+	return &types.CompletionResponse{Content: "mock response"}, nil
+}
+
+func (p *MockProvider) MakeStreamRequest(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	return &types.CompletionResponse{Content: "mock response"}, nil
+}
+
+func (p *MockProvider) ParseStreamChunk(data []byte) (string, error) {
+	return "", nil
 }
 
 func (p *MockProvider) Chat(messages []types.Message) (string, error) {
@@ -83,14 +91,26 @@ func (m *mockLLM) ParseResponse(response []byte) (string, error) {
 	return "", nil
 }
 
-func (m *mockLLM) GetUsage(data []byte) (string, error) {
-	return "", nil
+func (m *mockLLM) GetUsage(data []byte) (*types.Usage, error) {
+	return nil, nil
 }
 
-func (m *mockLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+func (m *mockLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (*types.CompletionResponse, error) {
 	if m.makeRequest != nil {
-		return m.makeRequest(ctx, client, message, history)
+		content, err := m.makeRequest(ctx, client, message, history)
+		if err != nil {
+			return nil, err
+		}
+		return &types.CompletionResponse{Content: content}, nil
 	}
+	return &types.CompletionResponse{}, nil
+}
+
+func (m *mockLLM) MakeStreamRequest(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	return m.MakeRequest(ctx, client, message, history)
+}
+
+func (m *mockLLM) ParseStreamChunk(data []byte) (string, error) {
 	return "", nil
 }
 
@@ -112,6 +132,51 @@ func (m *mockLLM) Name() string {
 	return m.name
 }
 
+func TestBuiltinProviders(t *testing.T) {
+	for _, name := range []string{"claude", "gemini", "ollama", "openai", "vertex"} {
+		info, ok := GetProviderInfo(name)
+		require.True(t, ok, name)
+		assert.NotEmpty(t, info.DisplayName)
+		assert.True(t, info.Capabilities.Streaming)
+		assert.NotEmpty(t, info.Defaults["model"], name)
+
+		provider, err := NewProvider(name, &types.ClientConfig{})
+		require.NoError(t, err)
+		assert.NotNil(t, provider)
+	}
+
+	ollama, _ := GetProviderInfo("ollama")
+	assert.False(t, ollama.Capabilities.RequiresAPIKey)
+	claude, _ := GetProviderInfo("claude")
+	assert.True(t, claude.Capabilities.RequiresAPIKey)
+	assert.Equal(t, "https://api.anthropic.com/v1", claude.Defaults["api_base"])
+}
+
+func TestRegister(t *testing.T) {
+	saved := providers
+	t.Cleanup(func() { providers = saved })
+	providers = make(map[string]ProviderInfo)
+
+	err := Register(ProviderInfo{
+		Name: "plugin",
+		Constructor: func(config *types.ClientConfig) LLM {
+			return &MockProvider{name: "plugin"}
+		},
+		Capabilities: Capabilities{Streaming: true},
+	})
+	require.NoError(t, err)
+
+	info, ok := GetProviderInfo("plugin")
+	require.True(t, ok)
+	assert.Equal(t, "plugin", info.DisplayName)
+	assert.False(t, info.Capabilities.RequiresAPIKey)
+	assert.NotNil(t, info.RequiredConfig)
+	assert.NotNil(t, info.Defaults)
+
+	_, ok = GetProviderInfo("missing")
+	assert.False(t, ok)
+}
+
 func TestRegisterProvider(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -149,7 +214,7 @@ func TestRegisterProvider(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Reset providers before each test
-			providers = make(map[string]ProviderConstructor)
+			providers = make(map[string]ProviderInfo)
 
 			err := RegisterProvider(tt.provider, tt.constructor)
 			if tt.wantErr {
@@ -165,7 +230,7 @@ func TestRegisterProvider(t *testing.T) {
 
 func TestNewProvider(t *testing.T) {
 	// Reset providers before test
-	providers = make(map[string]ProviderConstructor)
+	providers = make(map[string]ProviderInfo)
 
 	// Register a mock provider
 	err := RegisterProvider("mock", func(config *types.ClientConfig) LLM {
@@ -219,7 +284,7 @@ func TestNewProvider(t *testing.T) {
 
 func TestListProviders(t *testing.T) {
 	// Clear providers
-	providers = make(map[string]ProviderConstructor)
+	providers = make(map[string]ProviderInfo)
 
 	// Register test providers
 	testProviders := []string{"mock1", "mock2", "mock3"}
@@ -240,7 +305,7 @@ func TestListProviders(t *testing.T) {
 
 func TestCreateProvider(t *testing.T) {
 	// Clear providers
-	providers = make(map[string]ProviderConstructor)
+	providers = make(map[string]ProviderInfo)
 
 	// Register test provider
 	err := RegisterProvider("mock", func(config *types.ClientConfig) LLM {
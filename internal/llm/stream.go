@@ -0,0 +1,189 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/belingud/go-gptcomet/internal/debug"
+	"github.com/belingud/go-gptcomet/pkg/types"
+)
+
+// maxStreamLineSize is the largest single event line accepted from a stream
+const maxStreamLineSize = 1024 * 1024
+
+// streamUsageProviders are asked for token usage in the final stream event
+// via stream_options, which other OpenAI-compatible APIs may reject
+var streamUsageProviders = map[string]bool{
+	"openai":   true,
+	"azure":    true,
+	"deepseek": true,
+}
+
+// ParseStreamChunk extracts the text delta from a single OpenAI-compatible
+// streaming event. Events without content (role announcements, finish
+// reasons) yield an empty string.
+func (b *BaseLLM) ParseStreamChunk(data []byte) (string, error) {
+	return gjson.GetBytes(data, "choices.0.delta.content").String(), nil
+}
+
+// MakeStreamRequest makes a streaming request to the provider's API. The
+// payload is built by the provider's FormatMessages with "stream" switched
+// on, and every text delta is passed to onChunk as soon as it arrives.
+//
+// The function returns the full response text once the stream is finished.
+func (b *BaseLLM) MakeStreamRequest(ctx context.Context, client *http.Client, provider LLM, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	payload, err := provider.FormatMessages(message, history)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format messages: %w", err)
+	}
+	if p, ok := payload.(map[string]interface{}); ok {
+		p["stream"] = true
+		if streamUsageProviders[b.Config.Provider] {
+			p["stream_options"] = map[string]interface{}{"include_usage": true}
+		}
+	}
+
+	return b.SendStreamRequest(ctx, client, provider, provider.BuildURL(), payload, onChunk)
+}
+
+// SendStreamRequest posts payload to url and consumes the response as a
+// stream of events. Both Server-Sent Events ("data: {...}" lines) and
+// newline-delimited JSON are understood, every event is handed to the
+// provider's ParseStreamChunk and GetUsage.
+func (b *BaseLLM) SendStreamRequest(ctx context.Context, client *http.Client, provider LLM, url string, payload interface{}, onChunk func(string)) (*types.CompletionResponse, error) {
+	debug.Printf("Stream API URL: %s", redactURL(url, b.Config.APIKey))
+
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for k, v := range provider.BuildHeaders() {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", redactError(err, b.Config.APIKey))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		return nil, NewAPIError(resp, respBody)
+	}
+
+	var usage *types.Usage
+	text, err := readStream(resp.Body, provider.ParseStreamChunk, func(data []byte) {
+		if u, err := provider.GetUsage(data); err == nil && u != nil {
+			usage = mergeUsage(usage, u)
+		}
+	}, onChunk)
+	if err != nil {
+		return nil, err
+	}
+	return &types.CompletionResponse{
+		Content: trimCodeFence(text),
+		Raw:     make(map[string]interface{}),
+		Usage:   usage,
+	}, nil
+}
+
+// mergeUsage combines the usage reported by several stream events. Later
+// events report running totals, so every non-zero field replaces the
+// previous value.
+func mergeUsage(acc, u *types.Usage) *types.Usage {
+	if acc == nil {
+		acc = &types.Usage{}
+	}
+	if u.InputTokens != 0 {
+		acc.InputTokens = u.InputTokens
+	}
+	if u.OutputTokens != 0 {
+		acc.OutputTokens = u.OutputTokens
+	}
+	if u.CachedTokens != 0 {
+		acc.CachedTokens = u.CachedTokens
+	}
+	acc.TotalTokens = acc.InputTokens + acc.OutputTokens
+	return acc
+}
+
+// readStream reads SSE or NDJSON events from r, parses each of them with
+// parse and forwards non-empty deltas to onChunk. Every event is also
+// passed to onEvent, if set, to pick up metadata such as token usage. It
+// returns the concatenation of all deltas.
+func readStream(r io.Reader, parse func([]byte) (string, error), onEvent func([]byte), onChunk func(string)) (string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+
+	var sb strings.Builder
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ":") {
+			continue
+		}
+		if strings.HasPrefix(line, "event:") || strings.HasPrefix(line, "id:") || strings.HasPrefix(line, "retry:") {
+			continue
+		}
+		if strings.HasPrefix(line, "data:") {
+			line = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+		if line == "[DONE]" {
+			break
+		}
+
+		data := []byte(line)
+		if errResult := gjson.GetBytes(data, "error"); errResult.IsObject() {
+			return sb.String(), fmt.Errorf("stream error: %s", errResult.Raw)
+		}
+
+		if onEvent != nil {
+			onEvent(data)
+		}
+
+		delta, err := parse(data)
+		if err != nil {
+			return sb.String(), fmt.Errorf("failed to parse stream chunk: %w", err)
+		}
+		if delta == "" {
+			continue
+		}
+		sb.WriteString(delta)
+		if onChunk != nil {
+			onChunk(delta)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return sb.String(), fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	return sb.String(), nil
+}
+
+// trimCodeFence removes a wrapping pair of triple backticks and surrounding
+// whitespace from a model answer.
+func trimCodeFence(text string) string {
+	if strings.HasPrefix(text, "```") && strings.HasSuffix(text, "```") {
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(text, "```")
+	}
+	return strings.TrimSpace(text)
+}
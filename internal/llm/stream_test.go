@@ -0,0 +1,163 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/belingud/go-gptcomet/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStreamServer returns a server that writes every event and flushes it
+// immediately, so the client sees a real chunked response
+func newStreamServer(t *testing.T, events []string, gotBody *string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if gotBody != nil {
+			body, _ := io.ReadAll(r.Body)
+			*gotBody = string(body)
+		}
+		flusher, ok := w.(http.Flusher)
+		require.True(t, ok)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			fmt.Fprint(w, event)
+			flusher.Flush()
+		}
+	}))
+}
+
+func TestOpenAILLM_MakeStreamRequest(t *testing.T) {
+	var body string
+	server := newStreamServer(t, []string{
+		"data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n",
+		"data: {\"choices\":[{\"delta\":{\"content\":\"feat: \"}}]}\n\n",
+		": keep-alive\n\n",
+		"data: {\"choices\":[{\"delta\":{\"content\":\"add streaming\"}}]}\n\n",
+		"data: [DONE]\n\n",
+	}, &body)
+	defer server.Close()
+
+	llm := NewOpenAILLM(&types.ClientConfig{APIBase: server.URL, APIKey: "test-key"})
+
+	var chunks []string
+	got, err := llm.MakeStreamRequest(context.Background(), server.Client(), "diff", nil, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add streaming", got.Content)
+	assert.Equal(t, []string{"feat: ", "add streaming"}, chunks)
+	assert.Contains(t, body, `"stream":true`)
+	assert.Nil(t, got.Usage)
+}
+
+func TestOpenAILLM_MakeStreamRequest_Usage(t *testing.T) {
+	var body string
+	server := newStreamServer(t, []string{
+		"data: {\"choices\":[{\"delta\":{\"content\":\"feat: usage\"}}],\"usage\":null}\n\n",
+		"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":3,\"total_tokens\":15,\"prompt_tokens_details\":{\"cached_tokens\":4}}}\n\n",
+		"data: [DONE]\n\n",
+	}, &body)
+	defer server.Close()
+
+	llm := NewOpenAILLM(&types.ClientConfig{APIBase: server.URL, APIKey: "test-key", Provider: "openai"})
+
+	got, err := llm.MakeStreamRequest(context.Background(), server.Client(), "diff", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: usage", got.Content)
+	assert.Equal(t, &types.Usage{InputTokens: 12, OutputTokens: 3, CachedTokens: 4, TotalTokens: 15}, got.Usage)
+	assert.Contains(t, body, `"include_usage":true`)
+}
+
+func TestClaudeLLM_MakeStreamRequest(t *testing.T) {
+	server := newStreamServer(t, []string{
+		"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":25,\"output_tokens\":1}}}\n\n",
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0}\n\n",
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"fix: \"}}\n\n",
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"handle nil config\"}}\n\n",
+		"event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":7}}\n\n",
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n",
+	}, nil)
+	defer server.Close()
+
+	llm := NewClaudeLLM(&types.ClientConfig{APIBase: server.URL, APIKey: "test-key"})
+
+	var chunks []string
+	got, err := llm.MakeStreamRequest(context.Background(), server.Client(), "diff", nil, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "fix: handle nil config", got.Content)
+	assert.Len(t, chunks, 2)
+	assert.Equal(t, &types.Usage{InputTokens: 25, OutputTokens: 7, TotalTokens: 32}, got.Usage)
+}
+
+func TestGeminiLLM_MakeStreamRequest(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.String()
+		fmt.Fprint(w, "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"docs: \"}]}}]}\n\n")
+		fmt.Fprint(w, "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"update readme\"}]}}]}\n\n")
+	}))
+	defer server.Close()
+
+	llm := NewGeminiLLM(&types.ClientConfig{APIBase: server.URL, APIKey: "test-key", Model: "gemini-1.5-flash"})
+
+	got, err := llm.MakeStreamRequest(context.Background(), server.Client(), "diff", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "docs: update readme", got.Content)
+	assert.Equal(t, "/gemini-1.5-flash:streamGenerateContent?alt=sse&key=test-key", path)
+}
+
+func TestOllamaLLM_MakeStreamRequest(t *testing.T) {
+	server := newStreamServer(t, []string{
+		"{\"model\":\"llama2\",\"response\":\"chore: \",\"done\":false}\n",
+		"{\"model\":\"llama2\",\"response\":\"bump deps\",\"done\":false}\n",
+		"{\"model\":\"llama2\",\"response\":\"\",\"done\":true}\n",
+	}, nil)
+	defer server.Close()
+
+	llm := NewOllamaLLM(&types.ClientConfig{APIBase: server.URL})
+
+	var sb strings.Builder
+	got, err := llm.MakeStreamRequest(context.Background(), server.Client(), "diff", nil, func(chunk string) {
+		sb.WriteString(chunk)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "chore: bump deps", got.Content)
+	assert.Equal(t, "chore: bump deps", sb.String())
+}
+
+func TestMakeStreamRequest_Errors(t *testing.T) {
+	t.Run("status error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":{"message":"invalid key"}}`)
+		}))
+		defer server.Close()
+
+		llm := NewOpenAILLM(&types.ClientConfig{APIBase: server.URL})
+		_, err := llm.MakeStreamRequest(context.Background(), server.Client(), "diff", nil, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status 401")
+	})
+
+	t.Run("error event", func(t *testing.T) {
+		server := newStreamServer(t, []string{
+			"data: {\"choices\":[{\"delta\":{\"content\":\"feat\"}}]}\n\n",
+			"data: {\"error\":{\"message\":\"overloaded\"}}\n\n",
+		}, nil)
+		defer server.Close()
+
+		llm := NewOpenAILLM(&types.ClientConfig{APIBase: server.URL})
+		_, err := llm.MakeStreamRequest(context.Background(), server.Client(), "diff", nil, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "overloaded")
+	})
+}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/belingud/go-gptcomet/pkg/config"
 	"github.com/belingud/go-gptcomet/pkg/types"
-	"github.com/tidwall/gjson"
 )
 
 // TongyiLLM implements the LLM interface for Tongyi (DashScope)
@@ -70,18 +69,3 @@ func (t *TongyiLLM) BuildHeaders() map[string]string {
 	}
 	return headers
 }
-
-// GetUsage returns usage information for the provider
-func (t *TongyiLLM) GetUsage(data []byte) (string, error) {
-	usage := gjson.GetBytes(data, "usage")
-	if !usage.Exists() {
-		return "", nil
-	}
-
-	return fmt.Sprintf(
-		"Token usage> input: %d, output: %d, total: %d",
-		usage.Get("prompt_tokens").Int(),
-		usage.Get("completion_tokens").Int(),
-		usage.Get("total_tokens").Int(),
-	), nil
-}
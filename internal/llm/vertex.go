@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/belingud/go-gptcomet/pkg/config"
 	"github.com/belingud/go-gptcomet/pkg/types"
@@ -86,21 +87,15 @@ func (v *VertexLLM) BuildURL() string {
 
 // FormatMessages formats messages for Vertex AI
 func (v *VertexLLM) FormatMessages(message string, history []types.Message) (interface{}, error) {
-	contents := []map[string]interface{}{
-		{
-			"role": "user",
-			"parts": []map[string]string{
-				{
-					"text": message,
-				},
-			},
-		},
-	}
+	system, contents := geminiContents(message, history)
 
 	payload := map[string]interface{}{
 		"contents":          contents,
 		"generation_config": map[string]interface{}{},
 	}
+	if system != nil {
+		payload["system_instruction"] = system
+	}
 
 	if v.Config.MaxTokens != 0 {
 		payload["generation_config"].(map[string]interface{})["max_output_tokens"] = v.Config.MaxTokens
@@ -118,22 +113,46 @@ func (v *VertexLLM) FormatMessages(message string, history []types.Message) (int
 	return payload, nil
 }
 
-// GetUsage returns usage information for the provider
-func (v *VertexLLM) GetUsage(data []byte) (string, error) {
+// GetUsage returns the token usage from the response's usageMetadata, or
+// from metadata.tokenMetadata as returned by the older PaLM models
+func (v *VertexLLM) GetUsage(data []byte) (*types.Usage, error) {
+	if usage := geminiUsage(data); usage != nil {
+		return usage, nil
+	}
+
 	usage := gjson.GetBytes(data, "metadata.tokenMetadata")
-	if !usage.Exists() {
-		return "", nil
+	if !usage.IsObject() {
+		return nil, nil
 	}
 
-	return fmt.Sprintf(
-		"Token usage> input: %d, output: %d, total: %d",
+	return newUsage(
 		usage.Get("inputTokenCount").Int(),
 		usage.Get("outputTokenCount").Int(),
+		0,
 		usage.Get("totalTokenCount").Int(),
 	), nil
 }
 
 // MakeRequest makes a request to the API
-func (v *VertexLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (string, error) {
+func (v *VertexLLM) MakeRequest(ctx context.Context, client *http.Client, message string, history []types.Message) (*types.CompletionResponse, error) {
 	return v.BaseLLM.MakeRequest(ctx, client, v, message, history)
 }
+
+// BuildStreamURL builds the streamGenerateContent API URL, asking for SSE output
+func (v *VertexLLM) BuildStreamURL() string {
+	return strings.Replace(v.BuildURL(), ":generateContent", ":streamGenerateContent?alt=sse", 1)
+}
+
+// ParseStreamChunk parses the text delta from a streamGenerateContent event
+func (v *VertexLLM) ParseStreamChunk(data []byte) (string, error) {
+	return gjson.GetBytes(data, "candidates.0.content.parts.0.text").String(), nil
+}
+
+// MakeStreamRequest makes a streaming request to the API
+func (v *VertexLLM) MakeStreamRequest(ctx context.Context, client *http.Client, message string, history []types.Message, onChunk func(string)) (*types.CompletionResponse, error) {
+	payload, err := v.FormatMessages(message, history)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format messages: %w", err)
+	}
+	return v.BaseLLM.SendStreamRequest(ctx, client, v, v.BuildStreamURL(), payload, onChunk)
+}
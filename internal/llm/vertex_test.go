@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/belingud/go-gptcomet/pkg/types"
@@ -178,12 +179,35 @@ func TestVertexLLM_FormatMessages(t *testing.T) {
 	}
 }
 
+func TestVertexLLM_FormatMessages_History(t *testing.T) {
+	llm := NewVertexLLM(&types.ClientConfig{})
+	history := []types.Message{
+		{Role: types.RoleSystem, Content: "be brief"},
+		{Role: types.RoleUser, Content: "example diff"},
+		{Role: types.RoleAssistant, Content: "feat: example"},
+	}
+
+	got, err := llm.FormatMessages("test message", history)
+	if err != nil {
+		t.Fatalf("FormatMessages() error = %v", err)
+	}
+	payload := got.(map[string]interface{})
+
+	wantSystem := map[string]interface{}{"parts": []map[string]string{{"text": "be brief"}}}
+	if !reflect.DeepEqual(payload["system_instruction"], wantSystem) {
+		t.Errorf("system_instruction = %v, want %v", payload["system_instruction"], wantSystem)
+	}
+	if contents := payload["contents"].([]map[string]interface{}); len(contents) != 3 || contents[1]["role"] != "model" {
+		t.Errorf("contents = %v, want the history and the message", contents)
+	}
+}
+
 func TestVertexLLM_GetUsage(t *testing.T) {
 	llm := NewVertexLLM(&types.ClientConfig{})
 	tests := []struct {
 		name    string
 		data    []byte
-		want    string
+		want    *types.Usage
 		wantErr bool
 	}{
 		{
@@ -197,13 +221,13 @@ func TestVertexLLM_GetUsage(t *testing.T) {
                     }
                 }
             }`),
-			want:    "Token usage> input: 10, output: 20, total: 30",
+			want:    &types.Usage{InputTokens: 10, OutputTokens: 20, TotalTokens: 30},
 			wantErr: false,
 		},
 		{
 			name:    "no usage info",
 			data:    []byte(`{}`),
-			want:    "",
+			want:    nil,
 			wantErr: false,
 		},
 	}
@@ -215,8 +239,8 @@ func TestVertexLLM_GetUsage(t *testing.T) {
 				t.Errorf("GetUsage() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
-				t.Errorf("GetUsage() = %s, want %s", got, tt.want)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetUsage() = %+v, want %+v", got, tt.want)
 			}
 		})
 	}
@@ -0,0 +1,94 @@
+// Package pr builds pull request titles and descriptions.
+package pr
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// TemplateFiles are where GitHub looks for the pull request template,
+// relative to the repository root
+var TemplateFiles = []string{
+	".github/pull_request_template.md",
+	".github/PULL_REQUEST_TEMPLATE.md",
+	"pull_request_template.md",
+	"PULL_REQUEST_TEMPLATE.md",
+	"docs/pull_request_template.md",
+	"docs/PULL_REQUEST_TEMPLATE.md",
+}
+
+// titlePrefix matches the decoration the model may put before the title
+var titlePrefix = regexp.MustCompile(`(?i)^(#+\s*)?(\*\*)?((pull request\s+)?title:\s*)?(\*\*)?`)
+
+// bodyPrefix matches a line that only announces the body
+var bodyPrefix = regexp.MustCompile(`(?i)^(#+\s*)?(\*\*)?(body|description):?(\*\*)?\s*$`)
+
+// PullRequest is a generated pull request title and markdown body
+type PullRequest struct {
+	Title string
+	Body  string
+}
+
+// String returns the title, a blank line and the body
+func (p PullRequest) String() string {
+	if p.Body == "" {
+		return p.Title + "\n"
+	}
+	return p.Title + "\n\n" + p.Body + "\n"
+}
+
+// FindTemplate returns the pull request template of the repository at
+// root, or an empty string if it has none
+func FindTemplate(root string) (string, error) {
+	for _, name := range TemplateFiles {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// Instructions asks the model to structure the body like template
+func Instructions(template string) string {
+	return "The repository has the following pull request template. Use its headings and structure for the description " +
+		"instead of the sections above, fill them in from the changes, and leave out its comments and the checklist items that do not apply:\n\n" +
+		template
+}
+
+// Input presents the commit log and the diff of a pull request
+func Input(log, diff string) string {
+	return "Commits:\n" + strings.TrimSpace(log) + "\n\nDiff:\n" + diff
+}
+
+// Parse splits the answer of the model into the title, its first line,
+// and the body, the rest. A markdown fence around the answer and labels
+// like "Title:" are removed.
+func Parse(answer string) (PullRequest, error) {
+	answer = strings.TrimSpace(answer)
+	if strings.HasPrefix(answer, "```") && strings.HasSuffix(answer, "```") {
+		answer = strings.TrimSuffix(answer, "```")
+		if _, rest, ok := strings.Cut(answer, "\n"); ok {
+			answer = strings.TrimSpace(rest)
+		}
+	}
+
+	title, body, _ := strings.Cut(answer, "\n")
+	title = strings.TrimSpace(titlePrefix.ReplaceAllString(strings.TrimSpace(title), ""))
+	title = strings.Trim(title, "*`\"' ")
+	if title == "" {
+		return PullRequest{}, errors.New("the answer has no title")
+	}
+
+	body = strings.TrimSpace(body)
+	if first, rest, _ := strings.Cut(body, "\n"); bodyPrefix.MatchString(strings.TrimSpace(first)) {
+		body = strings.TrimSpace(rest)
+	}
+	return PullRequest{Title: title, Body: body}, nil
+}
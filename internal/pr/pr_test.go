@@ -0,0 +1,51 @@
+package pr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	body := "## Summary\n\nAdd the pr command.\n\n## Changes\n\n- generate the description"
+	tests := []struct {
+		name   string
+		answer string
+	}{
+		{name: "plain", answer: "Add the pr command\n\n" + body},
+		{name: "heading", answer: "# Add the pr command\n\n" + body},
+		{name: "labels", answer: "**Title:** Add the pr command\n\nDescription:\n" + body},
+		{name: "fenced", answer: "```markdown\nTitle: `Add the pr command`\n\n" + body + "\n```"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.answer)
+			require.NoError(t, err)
+			assert.Equal(t, PullRequest{Title: "Add the pr command", Body: body}, p)
+		})
+	}
+
+	p, err := Parse("Pull request templates support")
+	require.NoError(t, err)
+	assert.Equal(t, "Pull request templates support\n", p.String())
+
+	_, err = Parse("```\n```")
+	assert.Error(t, err)
+}
+
+func TestFindTemplate(t *testing.T) {
+	root := t.TempDir()
+	template, err := FindTemplate(root)
+	require.NoError(t, err)
+	assert.Empty(t, template)
+
+	path := filepath.Join(root, ".github", "pull_request_template.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("## What\n\n## Why\n"), 0644))
+	template, err = FindTemplate(root)
+	require.NoError(t, err)
+	assert.Equal(t, "## What\n\n## Why", template)
+}
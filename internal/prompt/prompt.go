@@ -0,0 +1,196 @@
+// Package prompt renders prompt templates written in text/template syntax,
+// with the older {{ placeholder }}, {{ output.lang }} and
+// {{ output.rich_template }} markers still supported.
+//
+// A template may define its system part with
+// {{ define "system" }}...{{ end }}, which providers send as the system
+// prompt and the rest of the template as the user message. The two parts
+// are rendered separately into a Prompt.
+package prompt
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Placeholder marks where the text a prompt works on, such as the diff, is
+// inserted. Rendering keeps it in place of {{ .Diff }} and the client fills
+// it when the prompt is sent, so the text is never parsed as a template.
+const Placeholder = "{{ placeholder }}"
+
+// systemTemplate is the name of the template defining the system part
+const systemTemplate = "system"
+
+// Prompt is a rendered prompt template
+type Prompt struct {
+	System string // sent as the system prompt, empty if the template has none
+	User   string // sent as the user message
+}
+
+// String returns the whole text of p, the system part first
+func (p Prompt) String() string {
+	if p.System == "" {
+		return p.User
+	}
+	return p.System + "\n\n" + p.User
+}
+
+// Vars are the variables a prompt template can use
+type Vars struct {
+	Files         []string // paths of the changed files
+	Branch        string   // current branch
+	RecentCommits []string // messages of the latest commits, newest first
+	Lang          string   // name of the output language, such as English
+	RichTemplate  string   // template of rich commit messages
+	Repo          string   // name of the repository directory
+	IssueKey      string   // ticket key found in the branch name
+}
+
+// data is what templates are executed with
+type data struct {
+	Vars
+	Diff string
+}
+
+// legacyMarkers maps the markers of older prompts to template actions
+var legacyMarkers = []struct {
+	pattern *regexp.Regexp
+	action  string
+}{
+	{regexp.MustCompile(`\{\{\s*placeholder\s*\}\}`), "{{ .Diff }}"},
+	{regexp.MustCompile(`\{\{\s*output\.lang\s*\}\}`), "{{ .Lang }}"},
+	{regexp.MustCompile(`\{\{\s*output\.rich_template\s*\}\}`), "{{ .RichTemplate }}"},
+}
+
+// Names returns the variables templates can use, as written in a template
+func Names() []string {
+	names := []string{".Diff"}
+	t := reflect.TypeOf(Vars{})
+	for i := 0; i < t.NumField(); i++ {
+		names = append(names, "."+t.Field(i).Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render executes the prompt template tmpl with vars. A variable that does
+// not exist is an error naming it, rather than an empty string.
+func Render(tmpl string, vars Vars) (Prompt, error) {
+	for _, m := range legacyMarkers {
+		tmpl = m.pattern.ReplaceAllLiteralString(tmpl, m.action)
+	}
+
+	t, err := template.New("prompt").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return Prompt{}, fmt.Errorf("invalid prompt template: %w", err)
+	}
+	for _, tt := range t.Templates() {
+		if tt.Tree == nil {
+			continue
+		}
+		if err := checkFields(tt.Tree.Root); err != nil {
+			return Prompt{}, err
+		}
+	}
+
+	d := data{Vars: vars, Diff: Placeholder}
+	var user strings.Builder
+	if err := t.Execute(&user, d); err != nil {
+		return Prompt{}, fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	if t.Lookup(systemTemplate) == nil {
+		return Prompt{User: user.String()}, nil
+	}
+	var system strings.Builder
+	if err := t.ExecuteTemplate(&system, systemTemplate, d); err != nil {
+		return Prompt{}, fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	// Blank lines around the system part and before the user part are
+	// left over from the define block
+	return Prompt{
+		System: strings.TrimSpace(system.String()),
+		User:   strings.TrimLeft(user.String(), "\n"),
+	}, nil
+}
+
+// checkFields returns an error for the first field of the top-level data
+// that is not a known variable. Fields inside range and with refer to
+// other data and are checked when the template is executed.
+func checkFields(node parse.Node) error {
+	known := make(map[string]bool)
+	for _, name := range Names() {
+		known[strings.TrimPrefix(name, ".")] = true
+	}
+
+	var check func(node parse.Node, top bool) error
+	check = func(node parse.Node, top bool) error {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return nil
+			}
+			for _, child := range n.Nodes {
+				if err := check(child, top); err != nil {
+					return err
+				}
+			}
+		case *parse.ActionNode:
+			return check(n.Pipe, top)
+		case *parse.PipeNode:
+			if n == nil {
+				return nil
+			}
+			for _, cmd := range n.Cmds {
+				for _, arg := range cmd.Args {
+					if err := check(arg, top); err != nil {
+						return err
+					}
+				}
+			}
+		case *parse.IfNode:
+			return checkBranch(&n.BranchNode, top, top, check)
+		case *parse.RangeNode:
+			return checkBranch(&n.BranchNode, top, false, check)
+		case *parse.WithNode:
+			return checkBranch(&n.BranchNode, top, false, check)
+		case *parse.TemplateNode:
+			return check(n.Pipe, top)
+		case *parse.FieldNode:
+			if top && !known[n.Ident[0]] {
+				return unknownVariable(n.Ident[0])
+			}
+		case *parse.VariableNode:
+			// $.Name always refers to the top-level data
+			if n.Ident[0] == "$" && len(n.Ident) > 1 && !known[n.Ident[1]] {
+				return unknownVariable(n.Ident[1])
+			}
+		case *parse.ChainNode:
+			return check(n.Node, top)
+		}
+		return nil
+	}
+	return check(node, true)
+}
+
+// checkBranch checks the pipeline of an if, range or with node in the
+// current scope, its body in the scope of the body and its else branch in
+// the current scope
+func checkBranch(n *parse.BranchNode, top, body bool, check func(parse.Node, bool) error) error {
+	if err := check(n.Pipe, top); err != nil {
+		return err
+	}
+	if err := check(n.List, body); err != nil {
+		return err
+	}
+	return check(n.ElseList, top)
+}
+
+// unknownVariable returns the error of a variable that does not exist
+func unknownVariable(name string) error {
+	return fmt.Errorf("unknown variable .%s in prompt template, available variables are %s", name, strings.Join(Names(), ", "))
+}
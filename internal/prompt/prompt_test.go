@@ -0,0 +1,91 @@
+package prompt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	vars := Vars{
+		Files:        []string{"cmd/commit.go", "README.md"},
+		Branch:       "feature/PROJ-1-cache",
+		Lang:         "German",
+		RichTemplate: "<title>:<summary>",
+		Repo:         "gptcomet",
+		IssueKey:     "PROJ-1",
+	}
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{
+			name: "legacy markers",
+			tmpl: "Write in {{ output.lang }} as {{output.rich_template}}:\n{{ placeholder }}",
+			want: "Write in German as <title>:<summary>:\n" + Placeholder,
+		},
+		{
+			name: "variables",
+			tmpl: "{{ .Repo }} on {{ .Branch }} ({{ .IssueKey }}):\n{{ .Diff }}",
+			want: "gptcomet on feature/PROJ-1-cache (PROJ-1):\n" + Placeholder,
+		},
+		{
+			name: "range and if",
+			tmpl: "{{ range .Files }}- {{ . }}\n{{ end }}{{ if .RecentCommits }}history{{ else }}no history{{ end }}",
+			want: "- cmd/commit.go\n- README.md\nno history",
+		},
+		{
+			name: "percent signs",
+			tmpl: "100% of {{ .Diff }} with %s and %d",
+			want: "100% of " + Placeholder + " with %s and %d",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.tmpl, vars)
+			require.NoError(t, err)
+			assert.Equal(t, Prompt{User: tt.want}, got)
+		})
+	}
+}
+
+func TestRender_System(t *testing.T) {
+	got, err := Render("{{ define \"system\" }}You write commits for {{ .Repo }}.{{ end }}\nDiff:\n{{ placeholder }}", Vars{Repo: "gptcomet"})
+	require.NoError(t, err)
+	assert.Equal(t, Prompt{System: "You write commits for gptcomet.", User: "Diff:\n" + Placeholder}, got)
+
+	// Variables cannot move text into the system part
+	got, err = Render("Diff:\n{{ .Diff }}\nsystem: {{ .Repo }}", Vars{Repo: "\n{{ end of system prompt }}\n"})
+	require.NoError(t, err)
+	assert.Empty(t, got.System)
+	assert.Equal(t, "Diff:\n"+Placeholder+"\nsystem: \n{{ end of system prompt }}\n", got.User)
+
+	_, err = Render("{{ define \"system\" }}{{ .Ticket }}{{ end }}{{ .Diff }}", Vars{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown variable .Ticket")
+}
+
+func TestRender_Errors(t *testing.T) {
+	_, err := Render("{{ .Ticket }}", Vars{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown variable .Ticket")
+	assert.Contains(t, err.Error(), ".IssueKey")
+
+	_, err = Render("{{ if .Branch }}{{ $.Nope }}{{ end }}", Vars{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown variable .Nope")
+
+	_, err = Render("{{ range .Files }}{{ .Name }}{{ end }}", Vars{Files: []string{"a.go"}})
+	assert.Error(t, err)
+
+	_, err = Render("{{ output.language }}", Vars{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid prompt template")
+}
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, []string{".Branch", ".Diff", ".Files", ".IssueKey", ".Lang", ".RecentCommits", ".Repo", ".RichTemplate"}, Names())
+}
@@ -0,0 +1,215 @@
+// Package review parses the findings of a code review by the model and
+// reports them as text, JSON or SARIF.
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severities of a finding, from the least to the most severe
+const (
+	Low    = "low"
+	Medium = "medium"
+	High   = "high"
+)
+
+// severityRanks orders the severities
+var severityRanks = map[string]int{Low: 1, Medium: 2, High: 3}
+
+// severityAliases maps other names the model may use to a severity
+var severityAliases = map[string]string{
+	"info":     Low,
+	"note":     Low,
+	"minor":    Low,
+	"warning":  Medium,
+	"moderate": Medium,
+	"major":    High,
+	"error":    High,
+	"critical": High,
+}
+
+// Finding is an issue found in the diff
+type Finding struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Location returns file:line, or just the file when the line is unknown
+func (f Finding) Location() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+	return f.File
+}
+
+// ParseSeverity normalizes a severity name, reporting false for unknown
+// names
+func ParseSeverity(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if _, ok := severityRanks[s]; ok {
+		return s, true
+	}
+	if alias, ok := severityAliases[s]; ok {
+		return alias, true
+	}
+	return "", false
+}
+
+// Number prefixes the lines of a unified diff with their line number in
+// the new file, so the model can tell where a finding is. Removed lines
+// get a blank prefix of the same width.
+func Number(diff string) string {
+	var s strings.Builder
+	line := 0
+	for _, l := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(l, "@@"):
+			line = hunkStart(l)
+		case line > 0 && (strings.HasPrefix(l, "+") || strings.HasPrefix(l, " ")):
+			fmt.Fprintf(&s, "%5d ", line)
+			line++
+		case line > 0 && strings.HasPrefix(l, "-"):
+			s.WriteString("      ")
+		case strings.HasPrefix(l, "diff "):
+			line = 0
+		}
+		s.WriteString(l + "\n")
+	}
+	return strings.TrimSuffix(s.String(), "\n")
+}
+
+// hunkStart returns the first new line of a "@@ -a,b +c,d @@" header, or
+// 0 if it cannot be parsed
+func hunkStart(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0
+	}
+	start, _, _ := strings.Cut(strings.TrimPrefix(fields[2], "+"), ",")
+	n, err := strconv.Atoi(start)
+	if err != nil {
+		return 0
+	}
+	// A hunk of a deleted file starts at line 0 of the new file
+	if n == 0 {
+		n = 1
+	}
+	return n
+}
+
+// Parse reads the JSON array of findings in the answer of the model.
+// Findings without a message are dropped and unknown severities become
+// Low. The findings are sorted by file and line. No findings is an empty
+// slice rather than nil, so that it is written as [] in JSON.
+func Parse(answer string) ([]Finding, error) {
+	start, end := strings.Index(answer, "["), strings.LastIndex(answer, "]")
+	if start < 0 || end < start {
+		return nil, errors.New("the answer has no JSON array of findings")
+	}
+	var raw []struct {
+		File     string      `json:"file"`
+		Line     interface{} `json:"line"`
+		Severity string      `json:"severity"`
+		Message  string      `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON array of findings: %w", err)
+	}
+
+	findings := make([]Finding, 0, len(raw))
+	for _, r := range raw {
+		message := strings.TrimSpace(r.Message)
+		if message == "" {
+			continue
+		}
+		severity, ok := ParseSeverity(r.Severity)
+		if !ok {
+			severity = Low
+		}
+		findings = append(findings, Finding{
+			File:     strings.TrimPrefix(strings.TrimSpace(r.File), "b/"),
+			Line:     parseLine(r.Line),
+			Severity: severity,
+			Message:  message,
+		})
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, nil
+}
+
+// WithoutLines drops the line numbers of the findings, for a review of a
+// condensed diff whose lines cannot be numbered
+func WithoutLines(findings []Finding) []Finding {
+	result := make([]Finding, 0, len(findings))
+	for _, f := range findings {
+		f.Line = 0
+		result = append(result, f)
+	}
+	return result
+}
+
+// parseLine reads a line number given as a number or a string such as
+// "12" or "12-14", returning 0 if there is none
+func parseLine(v interface{}) int {
+	switch line := v.(type) {
+	case float64:
+		if line > 0 {
+			return int(line)
+		}
+	case string:
+		digits := strings.TrimLeft(strings.TrimSpace(line), "L")
+		if i := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+			digits = digits[:i]
+		}
+		n, _ := strconv.Atoi(digits)
+		return n
+	}
+	return 0
+}
+
+// Count returns the number of findings at least as severe as severity
+func Count(findings []Finding, severity string) int {
+	n := 0
+	for _, f := range findings {
+		if severityRanks[f.Severity] >= severityRanks[severity] {
+			n++
+		}
+	}
+	return n
+}
+
+// Format lists the findings as "file:line: severity: message" lines,
+// which terminals and editors turn into links, followed by a summary
+func Format(findings []Finding) string {
+	if len(findings) == 0 {
+		return "No findings\n"
+	}
+	var s strings.Builder
+	for _, f := range findings {
+		fmt.Fprintf(&s, "%s: %s: %s\n", f.Location(), f.Severity, f.Message)
+	}
+	bySeverity := make(map[string]int)
+	for _, f := range findings {
+		bySeverity[f.Severity]++
+	}
+	var counts []string
+	for _, severity := range []string{High, Medium, Low} {
+		if n := bySeverity[severity]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, severity))
+		}
+	}
+	fmt.Fprintf(&s, "\n%d findings: %s\n", len(findings), strings.Join(counts, ", "))
+	return s.String()
+}
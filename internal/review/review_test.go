@@ -0,0 +1,113 @@
+package review
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNumber(t *testing.T) {
+	diff := "diff --git a/main.go b/main.go\n" +
+		"--- a/main.go\n" +
+		"+++ b/main.go\n" +
+		"@@ -9,3 +9,3 @@ func main() {\n" +
+		" \tx := 1\n" +
+		"-\ty := 2\n" +
+		"+\ty := 3\n" +
+		" \treturn\n"
+	assert.Equal(t, "diff --git a/main.go b/main.go\n"+
+		"--- a/main.go\n"+
+		"+++ b/main.go\n"+
+		"@@ -9,3 +9,3 @@ func main() {\n"+
+		"    9  \tx := 1\n"+
+		"      -\ty := 2\n"+
+		"   10 +\ty := 3\n"+
+		"   11  \treturn\n", Number(diff))
+}
+
+func TestParse(t *testing.T) {
+	answer := "```json\n[" +
+		`{"file": "b/main.go", "line": "12-14", "severity": "critical", "message": "nil pointer dereference"},` +
+		`{"file": "cmd/a.go", "line": 3, "severity": "warning", "message": " unused variable "},` +
+		`{"file": "cmd/a.go", "severity": "odd", "message": "missing test"},` +
+		`{"file": "cmd/a.go", "line": 1, "severity": "high", "message": ""}` +
+		"]\n```"
+	findings, err := Parse(answer)
+	require.NoError(t, err)
+	assert.Equal(t, []Finding{
+		{File: "cmd/a.go", Severity: Low, Message: "missing test"},
+		{File: "cmd/a.go", Line: 3, Severity: Medium, Message: "unused variable"},
+		{File: "main.go", Line: 12, Severity: High, Message: "nil pointer dereference"},
+	}, findings)
+
+	assert.Equal(t, 1, Count(findings, High))
+	assert.Equal(t, 2, Count(findings, Medium))
+	assert.Equal(t, 3, Count(findings, Low))
+
+	assert.Equal(t, "cmd/a.go: low: missing test\n"+
+		"cmd/a.go:3: medium: unused variable\n"+
+		"main.go:12: high: nil pointer dereference\n"+
+		"\n3 findings: 1 high, 1 medium, 1 low\n", Format(findings))
+
+	findings, err = Parse("[]")
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+	assert.Equal(t, "No findings\n", Format(findings))
+	data, err := json.Marshal(findings)
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(data))
+
+	_, err = Parse("Looks good to me")
+	assert.Error(t, err)
+}
+
+func TestWithoutLines(t *testing.T) {
+	findings := []Finding{{File: "main.go", Line: 12, Severity: High, Message: "nil pointer dereference"}}
+	assert.Equal(t, []Finding{{File: "main.go", Severity: High, Message: "nil pointer dereference"}}, WithoutLines(findings))
+	assert.Equal(t, 12, findings[0].Line)
+
+	data, err := SARIF(WithoutLines(findings), "1.0.0")
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "region")
+	assert.Contains(t, string(data), `"uri": "main.go"`)
+}
+
+func TestSARIF(t *testing.T) {
+	data, err := SARIF([]Finding{
+		{File: "main.go", Line: 12, Severity: High, Message: "nil pointer dereference"},
+		{File: "go.mod", Severity: Low, Message: "old toolchain"},
+	}, "1.0.0")
+	require.NoError(t, err)
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []map[string]interface{} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(data, &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	assert.Equal(t, "gptcomet", log.Runs[0].Tool.Driver.Name)
+	assert.Equal(t, "1.0.0", log.Runs[0].Tool.Driver.Version)
+	require.Len(t, log.Runs[0].Results, 2)
+
+	first := log.Runs[0].Results[0]
+	assert.Equal(t, "error", first["level"])
+	assert.Equal(t, map[string]interface{}{"text": "nil pointer dereference"}, first["message"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"physicalLocation": map[string]interface{}{
+			"artifactLocation": map[string]interface{}{"uri": "main.go"},
+			"region":           map[string]interface{}{"startLine": float64(12)},
+		},
+	}}, first["locations"])
+	assert.Equal(t, "note", log.Runs[0].Results[1]["level"])
+}
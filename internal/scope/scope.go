@@ -0,0 +1,157 @@
+// Package scope infers the Conventional Commits scope of a change from the
+// paths of the changed files.
+package scope
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// mappingSeparator separates the glob from the scope in a mapping
+const mappingSeparator = "->"
+
+// ModuleFiles mark the root of a module in a monorepo
+var ModuleFiles = []string{"go.mod", "package.json"}
+
+// Mapping maps the files matching Pattern to Scope
+type Mapping struct {
+	Pattern string
+	Scope   string
+}
+
+// Settings configures the inference
+type Settings struct {
+	Enabled  bool
+	Monorepo bool // fall back to the directory of the nearest module file
+	Mappings []Mapping
+}
+
+// DefaultSettings returns the settings used when none are configured
+func DefaultSettings() Settings {
+	return Settings{Enabled: true, Monorepo: true}
+}
+
+// Infer returns the scopes of files as configured by s
+func (s Settings) Infer(root string, files []string) []string {
+	if !s.Enabled {
+		return nil
+	}
+	return Infer(root, files, s.Mappings, s.Monorepo)
+}
+
+// ParseMapping parses a mapping given as "glob -> scope"
+func ParseMapping(s string) (Mapping, error) {
+	pattern, scope, ok := strings.Cut(s, mappingSeparator)
+	pattern, scope = strings.TrimSpace(pattern), strings.TrimSpace(scope)
+	if !ok || pattern == "" || scope == "" {
+		return Mapping{}, fmt.Errorf("invalid scope mapping %q, expected \"glob -> scope\"", s)
+	}
+	if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+		return Mapping{}, fmt.Errorf("invalid scope mapping %q: %w", s, err)
+	}
+	return Mapping{Pattern: pattern, Scope: scope}, nil
+}
+
+// Infer returns the scopes of files, paths relative to root, the most
+// frequent first. A file gets the scope of the first mapping it matches.
+// Other files get the name of the directory of the nearest go.mod or
+// package.json below root if monorepo is set, or no scope.
+func Infer(root string, files []string, mappings []Mapping, monorepo bool) []string {
+	counts := make(map[string]int)
+	modules := make(map[string]string)
+	for _, file := range files {
+		file = filepath.ToSlash(file)
+		scope := ""
+		for _, m := range mappings {
+			if Match(m.Pattern, file) {
+				scope = m.Scope
+				break
+			}
+		}
+		if scope == "" && monorepo {
+			scope = moduleScope(root, path.Dir(file), modules)
+		}
+		if scope != "" {
+			counts[scope]++
+		}
+	}
+
+	scopes := make([]string, 0, len(counts))
+	for scope := range counts {
+		scopes = append(scopes, scope)
+	}
+	sort.Slice(scopes, func(i, j int) bool {
+		if counts[scopes[i]] != counts[scopes[j]] {
+			return counts[scopes[i]] > counts[scopes[j]]
+		}
+		return scopes[i] < scopes[j]
+	})
+	return scopes
+}
+
+// moduleScope returns the name of the nearest directory from dir up to,
+// but excluding, root that has a module file. Results are cached in
+// modules by directory.
+func moduleScope(root, dir string, modules map[string]string) string {
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	if scope, ok := modules[dir]; ok {
+		return scope
+	}
+
+	scope := ""
+	for _, name := range ModuleFiles {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(dir), name)); err == nil {
+			scope = path.Base(dir)
+			break
+		}
+	}
+	if scope == "" {
+		scope = moduleScope(root, path.Dir(dir), modules)
+	}
+	modules[dir] = scope
+	return scope
+}
+
+// Match reports whether the slash separated file matches pattern. "**"
+// matches any number of directories, the other wildcards are those of
+// path.Match and do not cross directories.
+func Match(pattern, file string) bool {
+	return matchParts(strings.Split(pattern, "/"), strings.Split(file, "/"))
+}
+
+func matchParts(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			pattern = pattern[1:]
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := range parts {
+				if matchParts(pattern, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], parts[0]); err != nil || !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// Instructions asks the model to use one of the scopes
+func Instructions(scopes []string) string {
+	return "The changed files belong to the following scopes, use the most relevant one in the title as type(scope): subject: " +
+		strings.Join(scopes, ", ") + "."
+}
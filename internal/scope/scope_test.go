@@ -0,0 +1,72 @@
+package scope
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"internal/llm/**", "internal/llm/openai.go", true},
+		{"internal/llm/**", "internal/llm/testdata/a.json", true},
+		{"internal/llm/**", "internal/llmx/a.go", false},
+		{"**/*_test.go", "internal/llm/openai_test.go", true},
+		{"**/*_test.go", "main_test.go", true},
+		{"cmd/*.go", "cmd/commit.go", true},
+		{"cmd/*.go", "cmd/sub/commit.go", false},
+		{"docs/**/*.md", "docs/a/b/guide.md", true},
+		{"docs/**/*.md", "docs/guide.md", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Match(tt.pattern, tt.file), "%s %s", tt.pattern, tt.file)
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	m, err := ParseMapping("internal/llm/** -> llm")
+	require.NoError(t, err)
+	assert.Equal(t, Mapping{Pattern: "internal/llm/**", Scope: "llm"}, m)
+
+	for _, s := range []string{"internal/llm/**", "-> llm", "[ -> llm"} {
+		_, err := ParseMapping(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestInfer(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{"go.mod", "services/api/go.mod", "web/package.json"} {
+		path := filepath.Join(root, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, nil, 0644))
+	}
+
+	mappings := []Mapping{
+		{Pattern: "internal/llm/**", Scope: "llm"},
+		{Pattern: "cmd/**", Scope: "cli"},
+	}
+	files := []string{
+		"internal/llm/openai.go",
+		"internal/llm/claude.go",
+		"cmd/commit.go",
+		"services/api/handlers/user.go",
+		"web/src/app.ts",
+		"README.md",
+		"internal/config/config.go",
+	}
+
+	assert.Equal(t, []string{"llm", "api", "cli", "web"}, Infer(root, files, mappings, true))
+	assert.Equal(t, []string{"llm", "cli"}, Infer(root, files, mappings, false))
+
+	settings := DefaultSettings()
+	settings.Enabled = false
+	assert.Empty(t, settings.Infer(root, files))
+}
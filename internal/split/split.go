@@ -0,0 +1,254 @@
+// Package split plans how to split the staged changes into several
+// commits, each with its own message.
+package split
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/belingud/go-gptcomet/internal/debug"
+	"github.com/belingud/go-gptcomet/internal/git"
+)
+
+// idPrefix starts the ID of every hunk
+const idPrefix = "H"
+
+// wholeFileMarkers start the file header lines of changes that git apply
+// cannot apply hunk by hunk
+var wholeFileMarkers = []string{
+	"new file mode",
+	"deleted file mode",
+	"old mode",
+	"rename from",
+	"copy from",
+	"GIT binary patch",
+	"Binary files",
+}
+
+// describedMarkers start the file header lines shown to the model
+var describedMarkers = []string{
+	"new file mode",
+	"deleted file mode",
+	"old mode",
+	"new mode",
+	"rename from",
+	"rename to",
+	"copy from",
+	"copy to",
+}
+
+// Hunk is a part of the staged changes that can be committed on its own:
+// a hunk of a modified file, or a whole file that is added, deleted,
+// renamed, binary or changes its mode
+type Hunk struct {
+	ID     string
+	Path   string
+	Header string // the file header of the diff
+	Text   string // the hunks, empty if the file has none
+}
+
+// binary reports whether h is a binary file
+func (h Hunk) binary() bool {
+	return strings.Contains(h.Header, "\nGIT binary patch") || strings.Contains(h.Header, "\nBinary files")
+}
+
+// Group is one commit of a split plan
+type Group struct {
+	Message string
+	Hunks   []string // the IDs of the hunks to commit
+}
+
+// Hunks splits the staged diff into hunks, numbered H1, H2 and so on
+func Hunks(diff string) []Hunk {
+	var hunks []Hunk
+	add := func(file git.FileDiff, text string) {
+		hunks = append(hunks, Hunk{
+			ID:     fmt.Sprintf("%s%d", idPrefix, len(hunks)+1),
+			Path:   file.Path,
+			Header: file.Header,
+			Text:   text,
+		})
+	}
+
+	for _, file := range git.ParseDiff(diff) {
+		if len(file.Hunks) <= 1 || hasMarker(file.Header, wholeFileMarkers) {
+			add(file, strings.Join(file.Hunks, ""))
+			continue
+		}
+		for _, hunk := range file.Hunks {
+			add(file, hunk)
+		}
+	}
+	return hunks
+}
+
+// hasMarker reports whether a line of header starts with one of markers
+func hasMarker(header string, markers []string) bool {
+	for _, line := range strings.Split(header, "\n") {
+		for _, marker := range markers {
+			if strings.HasPrefix(line, marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Patch returns the patch of the hunks with the given IDs, in diff order
+func Patch(hunks []Hunk, ids []string) string {
+	selected := idSet(ids)
+	var (
+		patch strings.Builder
+		path  string
+	)
+	for _, h := range hunks {
+		if !selected[h.ID] {
+			continue
+		}
+		// The hunks of a file follow each other, the header goes first
+		if h.Path != path {
+			patch.WriteString(h.Header)
+			path = h.Path
+		}
+		patch.WriteString(h.Text)
+	}
+	return patch.String()
+}
+
+// Files returns the paths of the hunks with the given IDs, in diff order
+func Files(hunks []Hunk, ids []string) []string {
+	selected := idSet(ids)
+	var files []string
+	for _, h := range hunks {
+		if selected[h.ID] && (len(files) == 0 || files[len(files)-1] != h.Path) {
+			files = append(files, h.Path)
+		}
+	}
+	return files
+}
+
+func idSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// Describe lists the hunks for the model, each under a "### <ID> <path>"
+// line. Hunks longer than maxLines lines are cut, the content of binary
+// files and of the files omit returns true for is left out.
+func Describe(hunks []Hunk, omit func(path string) bool, maxLines int) string {
+	var s strings.Builder
+	for _, h := range hunks {
+		fmt.Fprintf(&s, "### %s %s\n", h.ID, h.Path)
+		for _, line := range strings.Split(h.Header, "\n") {
+			if hasMarker(line, describedMarkers) {
+				s.WriteString(line + "\n")
+			}
+		}
+		switch {
+		case h.binary():
+			s.WriteString("(binary file)\n")
+		case omit != nil && omit(h.Path):
+			s.WriteString("(content omitted)\n")
+		default:
+			s.WriteString(truncateLines(h.Text, maxLines))
+		}
+		s.WriteString("\n")
+	}
+	return strings.TrimSuffix(s.String(), "\n")
+}
+
+// truncateLines cuts text after maxLines lines
+func truncateLines(text string, maxLines int) string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if maxLines <= 0 || len(lines) <= maxLines {
+		return text
+	}
+	return strings.Join(lines[:maxLines], "") + fmt.Sprintf("... %d more lines\n", len(lines)-maxLines)
+}
+
+// ParsePlan parses the JSON array of commits the model answered with, like
+// [{"message": "feat: ...", "hunks": ["H1", "H3"]}]. Unknown and repeated
+// hunk IDs are dropped. A hunk the plan leaves out is added to the first
+// commit with another hunk of the same file, or else to a last commit
+// without a message.
+func ParsePlan(answer string, hunks []Hunk) ([]Group, error) {
+	start, end := strings.Index(answer, "["), strings.LastIndex(answer, "]")
+	if start < 0 || end < start {
+		return nil, errors.New("the answer has no JSON array of commits")
+	}
+	var commits []struct {
+		Message string   `json:"message"`
+		Hunks   []string `json:"hunks"`
+	}
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &commits); err != nil {
+		return nil, fmt.Errorf("invalid JSON array of commits: %w", err)
+	}
+
+	known := make(map[string]bool, len(hunks))
+	for _, h := range hunks {
+		known[h.ID] = true
+	}
+	assigned := make(map[string]int)
+	var groups []Group
+	for _, commit := range commits {
+		var ids []string
+		for _, id := range commit.Hunks {
+			id = normalizeID(id)
+			if !known[id] {
+				debug.Printf("Dropping unknown hunk %s from the split plan", id)
+				continue
+			}
+			if _, ok := assigned[id]; ok {
+				debug.Printf("Dropping repeated hunk %s from the split plan", id)
+				continue
+			}
+			assigned[id] = len(groups)
+			ids = append(ids, id)
+		}
+		if len(ids) > 0 {
+			groups = append(groups, Group{Message: strings.TrimSpace(commit.Message), Hunks: ids})
+		}
+	}
+
+	var rest []string
+	for _, h := range hunks {
+		if _, ok := assigned[h.ID]; ok {
+			continue
+		}
+		group := -1
+		for _, other := range hunks {
+			if g, ok := assigned[other.ID]; ok && other.Path == h.Path && (group < 0 || g < group) {
+				group = g
+			}
+		}
+		if group < 0 {
+			rest = append(rest, h.ID)
+			continue
+		}
+		groups[group].Hunks = append(groups[group].Hunks, h.ID)
+	}
+	if len(rest) > 0 {
+		groups = append(groups, Group{Hunks: rest})
+	}
+	if len(groups) == 0 {
+		return nil, errors.New("the plan has no commits")
+	}
+	return groups, nil
+}
+
+// normalizeID accepts "h1" and "1" for "H1"
+func normalizeID(id string) string {
+	id = strings.ToUpper(strings.TrimSpace(id))
+	if !strings.HasPrefix(id, idPrefix) {
+		id = idPrefix + id
+	}
+	return id
+}
@@ -0,0 +1,103 @@
+package split
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const stagedDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+-package old
++package main
+
+ import "fmt"
+@@ -20,3 +20,4 @@ func main() {
+ 	fmt.Println("a")
++	fmt.Println("b")
+ }
+diff --git a/README.md b/README.md
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/README.md
+@@ -0,0 +1 @@
++# Title
+diff --git a/logo.png b/logo.png
+index 4444444..5555555 100644
+GIT binary patch
+literal 10
+Rcmb=
+
+literal 8
+Pcmb=
+`
+
+func TestHunks(t *testing.T) {
+	hunks := Hunks(stagedDiff)
+	require.Len(t, hunks, 4)
+
+	assert.Equal(t, []string{"H1", "H2", "H3", "H4"}, []string{hunks[0].ID, hunks[1].ID, hunks[2].ID, hunks[3].ID})
+	assert.Equal(t, "main.go", hunks[0].Path)
+	assert.Equal(t, "main.go", hunks[1].Path)
+	assert.True(t, strings.HasPrefix(hunks[1].Text, "@@ -20,3"))
+	assert.Equal(t, "README.md", hunks[2].Path)
+	assert.Equal(t, "logo.png", hunks[3].Path)
+	assert.Empty(t, hunks[3].Text)
+	assert.True(t, hunks[3].binary())
+}
+
+func TestPatch(t *testing.T) {
+	hunks := Hunks(stagedDiff)
+
+	patch := Patch(hunks, []string{"H2", "H3"})
+	assert.Equal(t, 1, strings.Count(patch, "diff --git a/main.go"))
+	assert.NotContains(t, patch, "package main")
+	assert.Contains(t, patch, `+	fmt.Println("b")`)
+	assert.Contains(t, patch, "+# Title")
+
+	assert.Equal(t, stagedDiff, Patch(hunks, []string{"H1", "H2", "H3", "H4"}))
+	assert.Equal(t, []string{"main.go", "README.md"}, Files(hunks, []string{"H1", "H2", "H3"}))
+}
+
+func TestDescribe(t *testing.T) {
+	hunks := Hunks(stagedDiff)
+	description := Describe(hunks, func(path string) bool { return path == "README.md" }, 2)
+
+	assert.Contains(t, description, "### H1 main.go\n@@ -1,3 +1,3 @@\n-package old\n... 3 more lines\n")
+	assert.Contains(t, description, "### H3 README.md\nnew file mode 100644\n(content omitted)\n")
+	assert.Contains(t, description, "### H4 logo.png\n(binary file)")
+	assert.NotContains(t, description, "literal")
+}
+
+func TestParsePlan(t *testing.T) {
+	hunks := Hunks(stagedDiff)
+
+	answer := "Here is the plan:\n```json\n" + `[
+  {"message": "fix: rename the package", "hunks": ["H1", "h3", "H9"]},
+  {"message": "feat: print b", "hunks": ["3", "H4"]}
+]` + "\n```"
+	groups, err := ParsePlan(answer, hunks)
+	require.NoError(t, err)
+	assert.Equal(t, []Group{
+		{Message: "fix: rename the package", Hunks: []string{"H1", "H3", "H2"}},
+		{Message: "feat: print b", Hunks: []string{"H4"}},
+	}, groups)
+
+	groups, err = ParsePlan(`[{"message": "docs: add readme", "hunks": ["H3"]}]`, hunks)
+	require.NoError(t, err)
+	assert.Equal(t, []Group{
+		{Message: "docs: add readme", Hunks: []string{"H3"}},
+		{Hunks: []string{"H1", "H2", "H4"}},
+	}, groups)
+
+	_, err = ParsePlan("I cannot split this change", hunks)
+	assert.Error(t, err)
+	_, err = ParsePlan(`[{"message": "fix: x", "hunks": "H1"}]`, hunks)
+	assert.Error(t, err)
+}
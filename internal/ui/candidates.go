@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// CandidateAction is what the user chose to do in the CandidatePicker
+type CandidateAction int
+
+const (
+	// CandidateCancel quits without a message
+	CandidateCancel CandidateAction = iota
+	// CandidatePick uses the selected message
+	CandidatePick
+	// CandidateEdit edits the selected message before using it
+	CandidateEdit
+	// CandidateRegenerate asks for new candidates
+	CandidateRegenerate
+)
+
+var bodyStyle = lipgloss.NewStyle().
+	PaddingLeft(7).
+	Foreground(lipgloss.Color("245"))
+
+type candidateDelegate struct{}
+
+func (d candidateDelegate) Height() int                             { return 1 }
+func (d candidateDelegate) Spacing() int                            { return 0 }
+func (d candidateDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+func (d candidateDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	i, ok := listItem.(item)
+	if !ok {
+		return
+	}
+
+	str := fmt.Sprintf("%d. %s", index+1, i.Title())
+	if index != m.Index() {
+		fmt.Fprint(w, itemStyle.Render(str))
+		return
+	}
+	fmt.Fprint(w, selectedItemStyle.Render("> "+str))
+}
+
+// CandidatePicker lets the user pick one of several generated commit
+// messages. The full message of the selected candidate is shown below the
+// list of headers.
+type CandidatePicker struct {
+	list       list.Model
+	candidates []string
+	action     CandidateAction
+	done       bool
+}
+
+// NewCandidatePicker creates a picker for the candidate messages
+func NewCandidatePicker(candidates []string) *CandidatePicker {
+	items := make([]list.Item, len(candidates))
+	for i, c := range candidates {
+		header, body, _ := strings.Cut(c, "\n")
+		items[i] = item{title: header, description: strings.TrimSpace(body)}
+	}
+	return newCandidatePicker("Select Commit Message", "pick", items, candidates)
+}
+
+// NewSplitReview creates a picker to review the commits of a split, with
+// the files of every commit shown below its message. CandidatePick
+// accepts all commits, CandidateEdit edits the selected message and
+// CandidateRegenerate asks for a new split.
+func NewSplitReview(messages []string, files [][]string) *CandidatePicker {
+	items := make([]list.Item, len(messages))
+	for i, msg := range messages {
+		header, body, _ := strings.Cut(msg, "\n")
+		description := strings.TrimSpace(body)
+		if i < len(files) {
+			description = strings.TrimSpace(description + "\n\nFiles: " + strings.Join(files[i], ", "))
+		}
+		items[i] = item{title: header, description: description}
+	}
+	return newCandidatePicker("Review Commits", "commit all", items, messages)
+}
+
+func newCandidatePicker(title, pickHelp string, items []list.Item, candidates []string) *CandidatePicker {
+	const defaultWidth = 80
+	l := list.New(items, candidateDelegate{}, defaultWidth, len(items)+helpTextHeight)
+	l.Title = title
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.SetShowPagination(false)
+	l.DisableQuitKeybindings()
+	l.Styles.Title = titleStyle
+	l.Styles.HelpStyle = helpStyle
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", pickHelp)),
+			key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit")),
+			key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "regenerate")),
+			key.NewBinding(key.WithKeys("q"), key.WithHelp("q", "quit")),
+		}
+	}
+
+	return &CandidatePicker{
+		list:       l,
+		candidates: candidates,
+	}
+}
+
+func (m *CandidatePicker) Init() tea.Cmd {
+	return nil
+}
+
+func (m *CandidatePicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.list.SetWidth(msg.Width)
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m.finish(CandidateCancel)
+		case "enter":
+			return m.finish(CandidatePick)
+		case "e":
+			return m.finish(CandidateEdit)
+		case "r":
+			return m.finish(CandidateRegenerate)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+func (m *CandidatePicker) finish(action CandidateAction) (tea.Model, tea.Cmd) {
+	m.action = action
+	m.done = true
+	return m, tea.Quit
+}
+
+func (m *CandidatePicker) View() string {
+	if m.done {
+		return ""
+	}
+
+	var s strings.Builder
+	s.WriteString("\n" + m.list.View())
+	if i, ok := m.list.SelectedItem().(item); ok && i.Description() != "" {
+		s.WriteString("\n" + bodyStyle.Render(i.Description()) + "\n")
+	}
+	return s.String()
+}
+
+// Action returns what the user chose to do
+func (m *CandidatePicker) Action() CandidateAction {
+	return m.action
+}
+
+// Index returns the index of the selected candidate
+func (m *CandidatePicker) Index() int {
+	return m.list.Index()
+}
+
+// Selected returns the selected candidate message
+func (m *CandidatePicker) Selected() string {
+	if len(m.candidates) == 0 {
+		return ""
+	}
+	return m.candidates[m.list.Index()]
+}
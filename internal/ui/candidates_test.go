@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCandidatePicker(t *testing.T) {
+	candidates := []string{
+		"feat: add candidates\n\n- request several messages",
+		"feat: pick one of several messages",
+	}
+
+	tests := []struct {
+		name     string
+		keys     []tea.KeyMsg
+		action   CandidateAction
+		selected string
+	}{
+		{
+			name:     "pick first",
+			keys:     []tea.KeyMsg{{Type: tea.KeyEnter}},
+			action:   CandidatePick,
+			selected: candidates[0],
+		},
+		{
+			name:     "pick second",
+			keys:     []tea.KeyMsg{{Type: tea.KeyDown}, {Type: tea.KeyEnter}},
+			action:   CandidatePick,
+			selected: candidates[1],
+		},
+		{
+			name:     "edit",
+			keys:     []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("e")}},
+			action:   CandidateEdit,
+			selected: candidates[0],
+		},
+		{
+			name:     "regenerate",
+			keys:     []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("r")}},
+			action:   CandidateRegenerate,
+			selected: candidates[0],
+		},
+		{
+			name:     "cancel",
+			keys:     []tea.KeyMsg{{Type: tea.KeyEsc}},
+			action:   CandidateCancel,
+			selected: candidates[0],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			picker := NewCandidatePicker(candidates)
+			assert.Contains(t, picker.View(), "- request several messages")
+
+			var cmd tea.Cmd
+			for _, key := range tt.keys {
+				_, cmd = picker.Update(key)
+			}
+			assert.NotNil(t, cmd)
+			assert.Equal(t, tt.action, picker.Action())
+			assert.Equal(t, tt.selected, picker.Selected())
+		})
+	}
+}
+
+func TestSplitReview(t *testing.T) {
+	messages := []string{"feat: add the split command", "docs: describe the split command"}
+	review := NewSplitReview(messages, [][]string{{"cmd/split.go", "main.go"}, {"README.md"}})
+	assert.Contains(t, review.View(), "Files: cmd/split.go, main.go")
+
+	review.Update(tea.KeyMsg{Type: tea.KeyDown})
+	assert.Contains(t, review.View(), "Files: README.md")
+	review.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	assert.Equal(t, CandidateEdit, review.Action())
+	assert.Equal(t, 1, review.Index())
+	assert.Equal(t, messages[1], review.Selected())
+}
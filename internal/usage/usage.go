@@ -0,0 +1,160 @@
+// Package usage keeps a local ledger of the tokens used and the money spent
+// on every request to an LLM provider.
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/belingud/go-gptcomet/pkg/types"
+)
+
+// Record is a single entry of the usage ledger
+type Record struct {
+	Time         time.Time `json:"time"`
+	Provider     string    `json:"provider"`
+	Model        string    `json:"model"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	CachedTokens int       `json:"cached_tokens"`
+	Cost         float64   `json:"cost"`             // USD
+	Priced       bool      `json:"priced,omitempty"` // false if no price was known for the model
+}
+
+// Total sums up the records sharing the same key
+type Total struct {
+	Key          string
+	Requests     int
+	InputTokens  int
+	OutputTokens int
+	CachedTokens int
+	Cost         float64
+	Unpriced     int // number of requests without a known price
+}
+
+// Cost returns the price in USD of usage at price. Cached input tokens are
+// charged at the cached price, or at the input price if there is none.
+func Cost(usage types.Usage, price types.ModelPrice) float64 {
+	cachedPrice := price.Cached
+	if cachedPrice == 0 {
+		cachedPrice = price.Input
+	}
+	uncached := usage.InputTokens - usage.CachedTokens
+	if uncached < 0 {
+		uncached = 0
+	}
+	return (float64(uncached)*price.Input +
+		float64(usage.CachedTokens)*cachedPrice +
+		float64(usage.OutputTokens)*price.Output) / 1e6
+}
+
+// Ledger is an append-only JSON lines file of usage records
+type Ledger struct {
+	path string
+}
+
+// NewLedger returns the ledger stored at path
+func NewLedger(path string) *Ledger {
+	return &Ledger{path: path}
+}
+
+// Path returns the ledger file path
+func (l *Ledger) Path() string {
+	return l.path
+}
+
+// Append adds records to the ledger, creating the file if needed
+func (l *Ledger) Append(records ...Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create usage ledger directory: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open usage ledger: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return fmt.Errorf("failed to write usage ledger: %w", err)
+		}
+	}
+	return nil
+}
+
+// Load reads all records of the ledger. A missing ledger has no records.
+func (l *Ledger) Load() ([]Record, error) {
+	f, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open usage ledger: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("failed to parse usage ledger line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage ledger: %w", err)
+	}
+	return records, nil
+}
+
+// ByDay groups records by their local date
+func ByDay(r Record) string { return r.Time.Local().Format("2006-01-02") }
+
+// ByProvider groups records by provider
+func ByProvider(r Record) string { return r.Provider }
+
+// ByModel groups records by provider and model
+func ByModel(r Record) string { return r.Provider + "/" + r.Model }
+
+// Summarize sums up records by the key returned by group, sorted by key
+func Summarize(records []Record, group func(Record) string) []Total {
+	totals := make(map[string]*Total)
+	for _, r := range records {
+		key := group(r)
+		t, ok := totals[key]
+		if !ok {
+			t = &Total{Key: key}
+			totals[key] = t
+		}
+		t.Requests++
+		t.InputTokens += r.InputTokens
+		t.OutputTokens += r.OutputTokens
+		t.CachedTokens += r.CachedTokens
+		t.Cost += r.Cost
+		if !r.Priced {
+			t.Unpriced++
+		}
+	}
+
+	result := make([]Total, 0, len(totals))
+	for _, t := range totals {
+		result = append(result, *t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
@@ -0,0 +1,74 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/belingud/go-gptcomet/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCost(t *testing.T) {
+	price := types.ModelPrice{Input: 2.5, Output: 10, Cached: 1.25}
+
+	got := Cost(types.Usage{InputTokens: 1000000, OutputTokens: 100000}, price)
+	assert.InDelta(t, 3.5, got, 1e-9)
+
+	got = Cost(types.Usage{InputTokens: 1000000, CachedTokens: 400000}, price)
+	assert.InDelta(t, 0.6*2.5+0.4*1.25, got, 1e-9)
+
+	// Without a cached price, cached tokens cost as much as other input
+	got = Cost(types.Usage{InputTokens: 1000000, CachedTokens: 400000}, types.ModelPrice{Input: 1})
+	assert.InDelta(t, 1.0, got, 1e-9)
+}
+
+func TestLedger(t *testing.T) {
+	ledger := NewLedger(filepath.Join(t.TempDir(), "nested", "usage.jsonl"))
+
+	records, err := ledger.Load()
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	day1 := time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local)
+	day2 := day1.Add(24 * time.Hour)
+	require.NoError(t, ledger.Append(
+		Record{Time: day1, Provider: "openai", Model: "gpt-4o", InputTokens: 100, OutputTokens: 10, Cost: 0.5, Priced: true},
+		Record{Time: day1, Provider: "claude", Model: "claude-3-5-sonnet", InputTokens: 200, OutputTokens: 20, Cost: 1, Priced: true},
+	))
+	require.NoError(t, ledger.Append(
+		Record{Time: day2, Provider: "openai", Model: "gpt-4o", InputTokens: 300, OutputTokens: 30, CachedTokens: 100, Cost: 0.25, Priced: true},
+		Record{Time: day2, Provider: "ollama", Model: "llama3", InputTokens: 50, OutputTokens: 5},
+	))
+
+	records, err = ledger.Load()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+
+	byDay := Summarize(records, ByDay)
+	require.Len(t, byDay, 2)
+	assert.Equal(t, "2025-03-01", byDay[0].Key)
+	assert.Equal(t, 2, byDay[0].Requests)
+	assert.InDelta(t, 1.5, byDay[0].Cost, 1e-9)
+	assert.Equal(t, 1, byDay[1].Unpriced)
+
+	byProvider := Summarize(records, ByProvider)
+	require.Len(t, byProvider, 3)
+	assert.Equal(t, "openai", byProvider[2].Key)
+	assert.Equal(t, 400, byProvider[2].InputTokens)
+	assert.Equal(t, 100, byProvider[2].CachedTokens)
+
+	byModel := Summarize(records, ByModel)
+	assert.Equal(t, "claude/claude-3-5-sonnet", byModel[0].Key)
+}
+
+func TestLedger_Malformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"provider\":\"openai\"}\nnot json\n"), 0644))
+
+	_, err := NewLedger(path).Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+}
@@ -15,6 +15,7 @@ func main() {
 	var (
 		debugEnabled bool
 		configPath   string
+		overrides    []string
 	)
 
 	var rootCmd = &cobra.Command{
@@ -34,10 +35,21 @@ func main() {
 	// Add persistent flags to root command
 	rootCmd.PersistentFlags().BoolVarP(&debugEnabled, "debug", "d", false, "Enable debug mode")
 	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "Config file path")
+	rootCmd.PersistentFlags().StringArrayVar(&overrides, "set", nil, "Override a config value for this run, as key=value")
 
 	rootCmd.AddCommand(cmd.NewProviderCmd())
 	rootCmd.AddCommand(cmd.NewCommitCmd())
 	rootCmd.AddCommand(cmd.NewConfigCmd())
+	rootCmd.AddCommand(cmd.NewUsageCmd())
+	rootCmd.AddCommand(cmd.NewHookCmd())
+	rootCmd.AddCommand(cmd.NewSplitCmd())
+	rootCmd.AddCommand(cmd.NewRewordCmd())
+	rootCmd.AddCommand(cmd.NewAmendCmd())
+	rootCmd.AddCommand(cmd.NewPRCmd())
+	rootCmd.AddCommand(cmd.NewChangelogCmd())
+	rootCmd.AddCommand(cmd.NewReviewCmd())
+	rootCmd.AddCommand(cmd.NewExplainCmd())
+	rootCmd.AddCommand(cmd.NewPromptCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		// fmt.Fprintln(os.Stderr, err)
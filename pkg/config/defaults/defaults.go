@@ -1,8 +1,76 @@
 package defaults
 
+import (
+	"strings"
+
+	"github.com/belingud/go-gptcomet/pkg/types"
+)
+
+// PriceDefaults contains the prices of common models in USD per million
+// tokens, used when the model has no entry under "pricing" in the config
+var PriceDefaults = map[string]types.ModelPrice{
+	"gpt-4o":                   {Input: 2.5, Output: 10, Cached: 1.25},
+	"gpt-4o-mini":              {Input: 0.15, Output: 0.6, Cached: 0.075},
+	"claude-3-5-sonnet-latest": {Input: 3, Output: 15, Cached: 0.3},
+	"claude-3-5-haiku-latest":  {Input: 0.8, Output: 4, Cached: 0.08},
+	"deepseek-chat":            {Input: 0.27, Output: 1.1, Cached: 0.07},
+	"gemini-1.5-flash":         {Input: 0.075, Output: 0.3},
+	"gemini-1.5-pro":           {Input: 1.25, Output: 5},
+	"mistral-large-latest":     {Input: 2, Output: 6},
+	"moonshot-v1-8k":           {Input: 1.7, Output: 1.7},
+}
+
+// ContextDefaults contains the context windows of common models in tokens,
+// used for the input budget when the provider has no "max_input_tokens".
+// Dated and tagged variants, such as gpt-4o-2024-08-06, get the window of
+// the longest name they start with.
+var ContextDefaults = map[string]int{
+	"gpt-3.5-turbo":        16385,
+	"gpt-4":                8192,
+	"gpt-4-turbo":          128000,
+	"gpt-4o":               128000,
+	"gpt-4o-mini":          128000,
+	"gpt-4.1":              1047576,
+	"o1":                   200000,
+	"o1-mini":              128000,
+	"o3-mini":              200000,
+	"claude-3":             200000,
+	"claude-3-5":           200000,
+	"claude-3-7":           200000,
+	"deepseek-chat":        64000,
+	"deepseek-reasoner":    64000,
+	"gemini-1.5-flash":     1048576,
+	"gemini-1.5-pro":       2097152,
+	"gemini-2.0-flash":     1048576,
+	"mistral-large-latest": 128000,
+	"mistral-small-latest": 32000,
+	"moonshot-v1-8k":       8192,
+	"moonshot-v1-32k":      32768,
+	"moonshot-v1-128k":     131072,
+	"grok-2":               131072,
+	"glm-4":                128000,
+	"qwen-turbo":           1000000,
+	"qwen-plus":            131072,
+	"qwen-max":             32768,
+	"command-r":            128000,
+	"command-r-plus":       128000,
+}
+
+// ContextLimit returns the context window of model from ContextDefaults,
+// false if the model is unknown
+func ContextLimit(model string) (int, bool) {
+	var limit, matched int
+	for name, tokens := range ContextDefaults {
+		if strings.HasPrefix(model, name) && len(name) > matched {
+			limit, matched = tokens, len(name)
+		}
+	}
+	return limit, matched > 0
+}
+
 // PromptDefaults contains default prompt configurations
 var PromptDefaults = map[string]string{
-	"brief_commit_message": `you are an expert software engineer responsible for writing a clear and concise commit message.
+	"brief_commit_message": `{{ define "system" }}you are an expert software engineer responsible for writing a clear and concise commit message.{{ end }}
 Task: Write a concise commit message based on the provided git diff content.
 
 Guidelines:
@@ -52,7 +120,7 @@ Generate commit message by below git diff:
 {{ placeholder }}
 
 Commit Message:`,
-	"rich_commit_message": `you are an expert software engineer responsible for writing a clear and concise commit message.
+	"rich_commit_message": `{{ define "system" }}you are an expert software engineer responsible for writing a clear and concise commit message.{{ end }}
 Task: Write a concise commit message based on the provided git diff content.
 
 Guidelines:
@@ -104,8 +172,8 @@ Generate commit message by below git diff:
 {{ placeholder }}
 
 Commit Message:`,
-	"translation": `You are a professional polyglot programmer and translator. You are translating a git commit message.
-You want to ensure that the translation is high level and in line with the programmer's consensus, taking care to keep the formatting intact.
+	"translation": `{{ define "system" }}You are a professional polyglot programmer and translator. You are translating a git commit message.
+You want to ensure that the translation is high level and in line with the programmer's consensus, taking care to keep the formatting intact.{{ end }}
 
 Translate the following message into {{ output.lang }}.
 
@@ -115,4 +183,194 @@ GIT COMMIT MESSAGE:
 
 Remember translate all given git commit message and give me only the translation.
 THE TRANSLATION:`,
+	"summarize_chunk": `{{ define "system" }}you are an expert software engineer reviewing one part of a large git diff.{{ end }}
+Task: Summarize the changes in the provided part of the diff so that a commit message can be written later from the summaries of all parts.
+
+Guidelines:
+- list each significant change as a bullet point in imperative tense.
+- mention the files, functions or components that are affected.
+- say whether a change adds a feature, fixes a bug, refactors, updates tests, docs or build files.
+- do not write a commit message, only the summary.
+
+Part of the git diff:
+{{ placeholder }}
+
+Summary:`,
+	"combine_summaries": `The git diff of this commit is too large to show at once. It was split into parts and every part was summarized.
+Use the following summaries of all parts in place of the git diff:
+
+{{ placeholder }}`,
+	"repair_commit_message": `{{ define "system" }}you are an expert software engineer fixing a git commit message that breaks the Conventional Commits rules of the repository.{{ end }}
+Task: Rewrite the commit message below so that it has none of the listed violations.
+
+Guidelines:
+- the first line is the header, in the form type(scope): subject.
+- keep the meaning, the details and the language of the original message.
+- separate the body from the header with a blank line.
+- your answer should only include the fixed commit message, no other text or ` + "`" + `.
+
+{{ placeholder }}
+
+Fixed Commit Message:`,
+	"split_commits": `{{ define "system" }}you are an expert software engineer splitting a large set of staged changes into small, coherent git commits.{{ end }}
+Task: Group the hunks below into commits so that every commit contains one logical change, and write a Conventional Commits message for each commit.
+
+Guidelines:
+- every hunk starts with a "### <ID> <file>" line, refer to hunks by their ID.
+- put every hunk in exactly one commit, keep hunks that depend on each other in the same commit.
+- order the commits so that every commit builds on the previous ones.
+- the first line of a message is the header, in the form type(scope): subject.
+- your answer should only be a JSON array, no other text or ` + "`" + `, for example:
+[{"message": "feat(cli): add the split command\n\n- group hunks into commits", "hunks": ["H1", "H3"]}, {"message": "docs: describe the split command", "hunks": ["H2"]}]
+
+Hunks:
+{{ placeholder }}
+
+JSON:`,
+	"pull_request": `{{ define "system" }}you are an expert software engineer writing the title and description of a pull request.{{ end }}
+Task: Write them from the commits and the diff of the pull request below.
+
+Guidelines:
+- the first line of your answer is the title, short and in imperative tense, without markdown.
+- after a blank line, write the description in markdown with these sections:
+  ## Summary: what the pull request does and why, in one or two sentences.
+  ## Changes: the notable changes as bullet points.
+  ## Testing: how the changes were tested or should be tested.
+  ## Risk: what could break and how likely it is.
+- only state what the commits and the diff support.
+- your answer should only include the title and the description, no other text.
+
+{{ placeholder }}
+
+Pull Request:`,
+	"changelog": `{{ define "system" }}you are an expert technical writer writing the release notes of a software project.{{ end }}
+Task: Rewrite the commits below, grouped by kind, into release notes for the users of the project.
+
+Guidelines:
+- keep the "### " section headings, in the same order, and drop the sections that end up empty.
+- write one bullet point per user-facing change, in past tense, without commit hashes or conventional commit prefixes.
+- merge commits that describe the same change, and drop changes that do not affect users, such as tests, CI or refactoring.
+- point out breaking changes at the start of their bullet point.
+- write the release notes in {{ output.lang }}, keeping the section headings in English.
+- your answer should only include the markdown release notes, no other text or ` + "```" + `.
+
+Commits:
+{{ placeholder }}
+
+Release Notes:`,
+	"review": `{{ define "system" }}you are an expert software engineer reviewing the staged changes of a git repository before they are committed.{{ end }}
+Task: Find the bugs, security issues and other problems that the diff below introduces.
+
+Guidelines:
+- lines of the diff start with their line number in the new file, removed lines have no number.
+- only report problems in the changed lines, with the line number of the new file.
+- severity is "high" for bugs, security issues and data loss, "medium" for likely problems and missing error handling, "low" for maintainability and style.
+- skip praise, summaries and nitpicks that a formatter or linter would catch.
+- your answer should only be a JSON array, no other text or ` + "`" + `, for example:
+[{"file": "cmd/main.go", "line": 42, "severity": "high", "message": "err is ignored, a failed write goes unnoticed"}]
+- answer [] if you find no problems.
+
+Diff:
+{{ placeholder }}
+
+JSON:`,
+	"explain_commit": `{{ define "system" }}you are an expert software engineer explaining the history of a code base to a colleague.{{ end }}
+Task: Explain what the commits below did and why, from their messages and their diff.
+
+Guidelines:
+- start with a one or two sentence summary of the change as a whole.
+- then explain the notable changes, grouped by topic rather than by file, as bullet points.
+- give the reasons and consequences the messages and the code support, and say so where the reason is unclear.
+- write the explanation in {{ output.lang }}, as markdown.
+
+{{ placeholder }}
+
+Explanation:`,
+	"explain_file": `{{ define "system" }}you are an expert software engineer explaining a source file to a colleague who is new to the code base.{{ end }}
+Task: Summarize what the file below is for and how it works.
+
+Guidelines:
+- start with a one or two sentence summary of the purpose of the file.
+- then describe its main parts, such as types, functions and their relations, as bullet points.
+- point out anything surprising or easy to get wrong.
+- write the explanation in {{ output.lang }}, as markdown.
+
+{{ placeholder }}
+
+Explanation:`,
+}
+
+// ProfileDefaults contains the built-in prompt profiles, see
+// "prompt.profiles" in the README
+var ProfileDefaults = map[string]map[string]interface{}{
+	"gitmoji": {
+		"system": "you are an expert software engineer writing git commit messages in the gitmoji style.",
+		"template": `Task: Write a commit message for the git diff below.
+
+Guidelines:
+- start the subject with the emoji that fits the intent of the change, followed by a short summary in imperative mood, for example "✨ Add the changelog command".
+- use ✨ for new features, 🐛 for bug fixes, ♻️ for refactoring, 📝 for documentation, ✅ for tests, 🔧 for configuration, ⬆️ for dependency upgrades, 🔥 for removed code or files, 🎨 for structure and formatting, ⚡️ for performance and 🔒️ for security.
+- keep the subject under 72 characters.
+- add a body after a blank line only if the change needs explaining.
+- your answer should only include the commit message, without any other text or code blocks.
+
+{{ .Diff }}
+
+Commit message:`,
+		"format": "brief",
+		"lint":   false,
+	},
+	"kernel-style": {
+		"system": "you are an experienced Linux kernel maintainer writing a commit message for a patch.",
+		"template": `Task: Write a commit message for the git diff below in the style of the Linux kernel.
+
+Guidelines:
+- the subject is "subsystem: summary", where subsystem is the area of the code that changed in lower case, and summary is in imperative mood without a trailing period, under 72 characters.
+- after a blank line, explain in plain prose what the problem was and why this change solves it, wrapped at 72 characters.
+- describe the change as if giving orders to the code base, "Make xyzzy do frotz" rather than "This patch makes xyzzy do frotz".
+- do not use bullet points or markdown.
+- your answer should only include the commit message, without any other text or code blocks.
+
+{{ .Diff }}
+
+Commit message:`,
+		"format": "rich",
+		"lint":   false,
+	},
+	"jira": {
+		"system": "you are an expert software engineer writing git commit messages for a team that tracks its work in Jira.",
+		"template": `Task: Write a commit message for the git diff below.
+
+Guidelines:
+{{- if .IssueKey }}
+- start the subject with "{{ .IssueKey }} ", followed by a short summary in imperative mood.
+{{- else }}
+- start the subject with the issue key in the branch name {{ .Branch }}, such as PROJ-123, followed by a short summary in imperative mood.
+{{- end }}
+- keep the subject under 72 characters.
+- add a body of bullet points after a blank line if the change needs explaining.
+- your answer should only include the commit message, without any other text or code blocks.
+
+{{ .Diff }}
+
+Commit message:`,
+		"format": "brief",
+		"lint":   false,
+	},
+	"one-line": {
+		"system": "you are an expert software engineer writing concise git commit messages.",
+		"template": `Task: Write a single line commit message for the git diff below.
+
+Guidelines:
+- answer with one line in the form "type(scope): subject", where scope is optional.
+- use one of the types build, chore, ci, docs, feat, fix, perf, refactor, style or test.
+- write the subject in imperative mood, in lower case and without a trailing period, keeping the whole line under 72 characters.
+- do not add a body.
+- your answer should only include the commit message, without any other text or code blocks.
+
+{{ .Diff }}
+
+Commit message:`,
+		"format": "brief",
+	},
 }
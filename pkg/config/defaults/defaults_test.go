@@ -47,6 +47,22 @@ func TestPromptDefaults(t *testing.T) {
 				"{{ placeholder }}",
 			},
 		},
+		{
+			name: "summarize chunk prompt",
+			key:  "summarize_chunk",
+			contains: []string{
+				"Summarize",
+				"{{ placeholder }}",
+			},
+		},
+		{
+			name: "combine summaries prompt",
+			key:  "combine_summaries",
+			contains: []string{
+				"summaries",
+				"{{ placeholder }}",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -65,3 +81,26 @@ func TestPromptDefaults(t *testing.T) {
 		})
 	}
 }
+
+func TestContextLimit(t *testing.T) {
+	tests := []struct {
+		model string
+		want  int
+		found bool
+	}{
+		{model: "gpt-4o", want: 128000, found: true},
+		{model: "gpt-4o-2024-08-06", want: 128000, found: true},
+		{model: "gpt-4-0613", want: 8192, found: true},
+		{model: "claude-3-5-sonnet-latest", want: 200000, found: true},
+		{model: "moonshot-v1-128k", want: 131072, found: true},
+		{model: "llama3", found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, ok := ContextLimit(tt.model)
+			if ok != tt.found || got != tt.want {
+				t.Errorf("ContextLimit(%q) = %d, %v, want %d, %v", tt.model, got, ok, tt.want, tt.found)
+			}
+		})
+	}
+}
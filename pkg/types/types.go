@@ -8,6 +8,14 @@ const (
 	DefaultTemperature      = 0.7
 	DefaultTopP             = 1.0
 	DefaultFrequencyPenalty = 0.0
+	DefaultMaxInputTokens   = 32000
+)
+
+// Roles of chat messages
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
 )
 
 // Message represents a chat message
@@ -29,8 +37,11 @@ type CompletionRequest struct {
 
 // CompletionResponse represents a chat completion response
 type CompletionResponse struct {
-	Content string                 `json:"content"`
-	Raw     map[string]interface{} `json:"raw"`
+	Content  string                 `json:"content"`
+	Raw      map[string]interface{} `json:"raw"`
+	Usage    *Usage                 `json:"usage,omitempty"`    // nil if the provider did not report usage
+	Provider string                 `json:"provider,omitempty"` // provider that produced the content
+	Choices  []string               `json:"choices,omitempty"`  // all answers when several were requested, Content is the first
 }
 
 // Choice represents a completion choice
@@ -40,9 +51,17 @@ type Choice struct {
 
 // Usage represents token usage information
 type Usage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	InputTokens  int `json:"input_tokens"`  // all prompt tokens, including cached ones
+	OutputTokens int `json:"output_tokens"` // generated tokens
+	CachedTokens int `json:"cached_tokens"` // prompt tokens served from the provider's cache
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// ModelPrice is the price of a model in USD per million tokens
+type ModelPrice struct {
+	Input  float64 `json:"input" yaml:"input"`
+	Output float64 `json:"output" yaml:"output"`
+	Cached float64 `json:"cached,omitempty" yaml:"cached,omitempty"` // price of cached input tokens, Input if zero
 }
 
 // ClientConfig represents the configuration for an LLM client
@@ -53,6 +72,7 @@ type ClientConfig struct {
 	CompletionPath    string            `json:"completion_path,omitempty"`
 	AnswerPath        string            `json:"answer_path,omitempty"`
 	MaxTokens         int               `json:"max_tokens"`
+	MaxInputTokens    int               `json:"max_input_tokens,omitempty"` // prompt budget before the diff is summarized in chunks, 0 for the model default
 	Temperature       float64           `json:"temperature"`
 	TopP              float64           `json:"top_p"`
 	TopK              int               `json:"top_k,omitempty"`              // Ollama top k
@@ -73,4 +93,22 @@ type ClientConfig struct {
 	Provider          string            `json:"provider"`
 	ProjectID         string            `json:"project_id,omitempty"` // Vertex AI project ID
 	Location          string            `json:"location,omitempty"`   // Vertex AI location
+
+	// ResolveAPIKey returns the api_key when it references a secret that
+	// is resolved when the provider is first used, see ResolveKey
+	ResolveAPIKey func() (string, error) `json:"-"`
+}
+
+// ResolveKey sets APIKey from ResolveAPIKey, once
+func (c *ClientConfig) ResolveKey() error {
+	if c.ResolveAPIKey == nil {
+		return nil
+	}
+	key, err := c.ResolveAPIKey()
+	if err != nil {
+		return err
+	}
+	c.APIKey = key
+	c.ResolveAPIKey = nil
+	return nil
 }